@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
@@ -8,13 +9,16 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/oscillatelabsllc/engram/internal/api"
+	"github.com/oscillatelabsllc/engram/internal/archive"
 	"github.com/oscillatelabsllc/engram/internal/db"
 	"github.com/oscillatelabsllc/engram/internal/embedding"
+	"github.com/oscillatelabsllc/engram/internal/embedqueue"
 	"github.com/oscillatelabsllc/engram/internal/health"
 	"github.com/oscillatelabsllc/engram/internal/mcp"
 	"github.com/oscillatelabsllc/engram/internal/proxy"
@@ -33,11 +37,20 @@ func main() {
 		runServe(args)
 	case "stdio":
 		runStdio()
+	case "migrate-dimensions":
+		runMigrateDimensions(args)
+	case "export":
+		runExport(args)
+	case "import":
+		runImport(args)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown subcommand: %s\n", subcmd)
-		fmt.Fprintf(os.Stderr, "Usage: engram [serve|stdio]\n")
-		fmt.Fprintf(os.Stderr, "  serve   Start the HTTP/SSE server (default)\n")
-		fmt.Fprintf(os.Stderr, "  stdio   Stdio proxy to a running server\n")
+		fmt.Fprintf(os.Stderr, "Usage: engram [serve|stdio|migrate-dimensions|export|import]\n")
+		fmt.Fprintf(os.Stderr, "  serve               Start the HTTP/SSE server (default)\n")
+		fmt.Fprintf(os.Stderr, "  stdio               Stdio proxy to a running server\n")
+		fmt.Fprintf(os.Stderr, "  migrate-dimensions  Change the stored embedding vector size (server must be stopped)\n")
+		fmt.Fprintf(os.Stderr, "  export              Write every episode to JSONL or Parquet (server must be stopped)\n")
+		fmt.Fprintf(os.Stderr, "  import              Load episodes from an export (server must be stopped)\n")
 		os.Exit(1)
 	}
 }
@@ -55,10 +68,7 @@ func runServe(args []string) {
 		resolvedPort = *port
 	}
 
-	dbPath := os.Getenv("DUCKDB_PATH")
-	if dbPath == "" {
-		dbPath = filepath.Join(".", "engram.duckdb")
-	}
+	dbPath := resolveDBPath()
 
 	embeddingURL := os.Getenv("EMBEDDING_URL")
 	if embeddingURL == "" {
@@ -76,12 +86,77 @@ func runServe(args []string) {
 
 	embeddingAPIKey := os.Getenv("EMBEDDING_API_KEY")
 
-	store, err := db.NewStore(dbPath)
+	// Unset means "whatever the database recorded" (768 for a new one)
+	embeddingDims := 0
+	if v := os.Getenv("EMBEDDING_DIMENSIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid EMBEDDING_DIMENSIONS %q: must be a positive integer", v)
+		}
+		embeddingDims = n
+	}
+
+	batchSize := embedding.DefaultBatchSize
+	if v := os.Getenv("EMBEDDING_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid EMBEDDING_BATCH_SIZE %q: must be a positive integer", v)
+		}
+		batchSize = n
+	}
+
+	// EMBEDDING_CACHE_SIZE=0 turns the embedding cache off
+	cacheSize := embedding.DefaultCacheSize
+	if v := os.Getenv("EMBEDDING_CACHE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid EMBEDDING_CACHE_SIZE %q: must be a non-negative integer", v)
+		}
+		cacheSize = n
+	}
+	cachePersist := os.Getenv("EMBEDDING_CACHE_PERSIST") == "true"
+
+	// EMBEDDING_CHUNK_SIZE=0 embeds content whole, however long
+	chunker := embedding.Chunker{Size: embedding.DefaultChunkSize, Overlap: embedding.DefaultChunkOverlap}
+	if v := os.Getenv("EMBEDDING_CHUNK_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid EMBEDDING_CHUNK_SIZE %q: must be a non-negative integer", v)
+		}
+		chunker.Size = n
+	}
+	if v := os.Getenv("EMBEDDING_CHUNK_OVERLAP"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || (chunker.Size > 0 && n >= chunker.Size) {
+			log.Fatalf("Invalid EMBEDDING_CHUNK_OVERLAP %q: must be a non-negative integer below EMBEDDING_CHUNK_SIZE", v)
+		}
+		chunker.Overlap = n
+	}
+
+	store, err := db.NewStoreWithDimensions(dbPath, embeddingDims)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	embedder := embedding.NewClient(embeddingURL, embeddingModel, embeddingAPIKey)
+	// Queries and new episodes are embedded with the active embedding
+	// space's model, which an admin call can switch at runtime
+	client := embedding.NewClient(embeddingURL, embeddingModel, embeddingAPIKey)
+	client.SetBatchSize(batchSize)
+	embedder := embedding.NewModelSwitch(client, store.ActiveEmbeddingModel)
+
+	// Repeated queries and identical content are embedded once (see
+	// embedding.Cache). The health probe bypasses it: it must reach the
+	// endpoint to judge it.
+	var cache *embedding.Cache
+	var cached embedding.Embedder = embedder
+	if cacheSize > 0 {
+		var vectors embedding.VectorStore
+		if cachePersist {
+			vectors = store
+		}
+		cache = embedding.NewCache(embedder, cacheSize, vectors)
+		cached = cache
+	}
 
 	fmt.Fprintf(os.Stderr, "===================================\n")
 	fmt.Fprintf(os.Stderr, "Engram memory system starting...\n")
@@ -89,6 +164,17 @@ func runServe(args []string) {
 	fmt.Fprintf(os.Stderr, "Database: %s\n", dbPath)
 	fmt.Fprintf(os.Stderr, "Embedding endpoint: %s\n", embeddingURL)
 	fmt.Fprintf(os.Stderr, "Embedding model: %s\n", embeddingModel)
+	fmt.Fprintf(os.Stderr, "Embedding dimensions: %d\n", store.EmbeddingDimensions())
+	if cache != nil {
+		fmt.Fprintf(os.Stderr, "Embedding cache: %d queries in memory, content persisted: %t\n", cacheSize, cachePersist)
+	}
+	if chunker.Size > 0 {
+		fmt.Fprintf(os.Stderr, "Embedding chunks: %d characters, %d overlap\n", chunker.Size, chunker.Overlap)
+	}
+	if space := store.ActiveEmbeddingModel(); space != "" {
+		fmt.Fprintf(os.Stderr, "Active embedding space: %s (%d dimensions, overrides EMBEDDING_MODEL)\n",
+			space, store.ActiveEmbeddingDimensions())
+	}
 	fmt.Fprintf(os.Stderr, "Port: %s\n", resolvedPort)
 	fmt.Fprintf(os.Stderr, "===================================\n")
 	fmt.Fprintf(os.Stderr, "\nMCP SSE endpoint: http://localhost:%s/mcp/sse\n", resolvedPort)
@@ -98,17 +184,28 @@ func runServe(args []string) {
 	// degrade vector search — warn loudly, but leave re-embedding as a
 	// deliberate operator action.
 	warnCtx, warnCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	if stale, err := store.CountReembedTargets(warnCtx, embeddingModel, false); err == nil && stale.Total() > 0 {
+	if stale, err := store.CountReembedTargets(warnCtx, embedder.Model(), false); err == nil && stale.Total() > 0 {
 		fmt.Fprintf(os.Stderr, "WARNING: %d stored episode embeddings are missing or were generated by a different model than %q.\n",
-			stale.Total(), embeddingModel)
+			stale.Total(), embedder.Model())
 		fmt.Fprintf(os.Stderr, "         Vector search quality degrades when embedding spaces are mixed.\n")
 		fmt.Fprintf(os.Stderr, "         Refresh them with: curl -X POST http://localhost:%s/api/v1/admin/reembed\n\n", resolvedPort)
 	}
 	warnCancel()
 
-	mcpServer := mcp.NewServer(store, embedder)
-	apiServer := api.NewServer(store, embedder, resolvedPort)
+	mcpServer := mcp.NewServer(store, cached)
+	apiServer := api.NewServer(store, cached, resolvedPort)
 	apiServer.AddMCPServer(mcpServer.GetMCPServer())
+	mcpServer.SetChunker(chunker)
+	apiServer.SetChunker(chunker)
+	apiServer.SetEmbedderFactory(func(model string) api.Embedder {
+		if model == cached.Model() {
+			return cached
+		}
+		return embedder.For(model)
+	})
+	if cache != nil {
+		apiServer.SetEmbeddingCache(cache)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -124,11 +221,30 @@ func runServe(args []string) {
 			fmt.Fprintf(os.Stderr, "WARNING: invalid ENGRAM_EMBEDDING_PROBE_INTERVAL %q, using %s\n", v, probeInterval)
 		}
 	}
-	prober := health.NewEmbeddingProber(embedder, probeInterval, 768)
+	prober := health.NewEmbeddingProber(embedder, probeInterval, store.ActiveEmbeddingDimensions())
+	prober.SetExpectedDimensionsFunc(store.ActiveEmbeddingDimensions)
 	prober.Start(ctx)
 	apiServer.SetEmbeddingHealth(prober)
 	mcpServer.SetEmbeddingHealth(prober)
 
+	// Episodes stored while the embedding endpoint was unreachable are
+	// queued; embed them in the background with exponential backoff, and
+	// retry immediately once the probe sees the endpoint recover.
+	retryInterval := 30 * time.Second
+	if v := os.Getenv("ENGRAM_EMBEDDING_RETRY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			retryInterval = d
+		} else {
+			fmt.Fprintf(os.Stderr, "WARNING: invalid ENGRAM_EMBEDDING_RETRY_INTERVAL %q, using %s\n", v, retryInterval)
+		}
+	}
+	embedWorker := embedqueue.NewWorker(store, cached, retryInterval)
+	embedWorker.SetChunker(chunker)
+	prober.SetRecoveryHook(embedWorker.Recover)
+	apiServer.SetEmbeddingQueue(embedWorker)
+	mcpServer.SetEmbeddingQueue(embedWorker)
+	embedWorker.Start(ctx)
+
 	// The process must not exit before store.Close() completes — DuckDB
 	// checkpoints its WAL on close, and an unflushed WAL containing the
 	// startup migration DDL can fail to replay on the next boot.
@@ -139,6 +255,7 @@ func runServe(args []string) {
 		if err := apiServer.Shutdown(context.Background()); err != nil {
 			fmt.Fprintf(os.Stderr, "Shutdown error: %v\n", err)
 		}
+		embedWorker.Stop()
 		store.Close()
 		close(shutdownDone)
 	}()
@@ -151,6 +268,157 @@ func runServe(args []string) {
 	<-shutdownDone
 }
 
+// resolveDBPath returns DUCKDB_PATH, defaulting to ./engram.duckdb
+func resolveDBPath() string {
+	if dbPath := os.Getenv("DUCKDB_PATH"); dbPath != "" {
+		return dbPath
+	}
+	return filepath.Join(".", "engram.duckdb")
+}
+
+// runMigrateDimensions walks an operator through changing the stored
+// embedding size. It opens the database directly, so the server must be
+// stopped (DuckDB allows a single writer).
+func runMigrateDimensions(args []string) {
+	fs := flag.NewFlagSet("migrate-dimensions", flag.ExitOnError)
+	dims := fs.Int("dimensions", 0, "New embedding vector size (e.g. 1024, 1536)")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	fs.Parse(args)
+
+	if *dims <= 0 {
+		fmt.Fprintf(os.Stderr, "Usage: engram migrate-dimensions --dimensions N [--yes]\n")
+		os.Exit(1)
+	}
+
+	dbPath := resolveDBPath()
+	store, err := db.NewStore(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database (is engram serve still running?): %v", err)
+	}
+	defer store.Close()
+
+	current := store.EmbeddingDimensions()
+	if current == *dims {
+		fmt.Fprintf(os.Stderr, "Database %s already stores %d-dimensional embeddings; nothing to do.\n", dbPath, current)
+		return
+	}
+
+	stale, err := store.CountReembedTargets(context.Background(), "", true)
+	if err != nil {
+		log.Fatalf("Failed to inspect database: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Database:             %s\n", dbPath)
+	fmt.Fprintf(os.Stderr, "Current dimensions:   %d\n", current)
+	fmt.Fprintf(os.Stderr, "New dimensions:       %d\n", *dims)
+	fmt.Fprintf(os.Stderr, "Live episodes:        %d\n\n", stale.Total())
+	fmt.Fprintf(os.Stderr, "This clears EVERY stored embedding and rebuilds the vector index.\n")
+	fmt.Fprintf(os.Stderr, "Episode content is untouched, but vector search returns nothing useful\n")
+	fmt.Fprintf(os.Stderr, "until the episodes are re-embedded with a %d-dimensional model.\n\n", *dims)
+
+	if !*yes {
+		fmt.Fprintf(os.Stderr, "Continue? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Fprintf(os.Stderr, "Aborted.\n")
+			os.Exit(1)
+		}
+	}
+
+	cleared, err := store.MigrateEmbeddingDimensions(context.Background(), *dims)
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nMigrated to %d dimensions (%d embeddings cleared).\n\n", *dims, cleared)
+	fmt.Fprintf(os.Stderr, "Next steps:\n")
+	fmt.Fprintf(os.Stderr, "  1. Start the server with a %d-dimensional model:\n", *dims)
+	fmt.Fprintf(os.Stderr, "       EMBEDDING_MODEL=<model> EMBEDDING_DIMENSIONS=%d engram serve\n", *dims)
+	fmt.Fprintf(os.Stderr, "  2. Regenerate embeddings:\n")
+	fmt.Fprintf(os.Stderr, "       curl -X POST http://localhost:3490/api/v1/admin/reembed\n")
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("output", "", "Output file (default: stdout; required for parquet)")
+	formatName := fs.String("format", "", "jsonl or parquet (default: from --output extension, else jsonl)")
+	withEmbeddings := fs.Bool("embeddings", false, "Include embeddings and their model stamp")
+	fs.Parse(args)
+
+	format := archive.FormatForPath(*output)
+	if *formatName != "" {
+		f, err := archive.ParseFormat(*formatName)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		format = f
+	}
+	if format == archive.Parquet && *output == "" {
+		log.Fatalf("Parquet export needs --output FILE")
+	}
+
+	store, err := db.NewStore(resolveDBPath())
+	if err != nil {
+		log.Fatalf("Failed to open database (is engram serve still running? use GET /api/v1/admin/export instead): %v", err)
+	}
+	defer store.Close()
+
+	var n int
+	if *output == "" {
+		n, err = archive.Export(context.Background(), store, os.Stdout, format, *withEmbeddings)
+	} else {
+		n, err = archive.ExportFile(context.Background(), store, *output, format, *withEmbeddings)
+	}
+	if err != nil {
+		store.Close()
+		log.Fatalf("Export failed: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d episodes (%s)\n", n, format)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatName := fs.String("format", "", "jsonl or parquet (default: from file extension, else jsonl)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: engram import [--format jsonl|parquet] FILE   (FILE may be - for JSONL on stdin)\n")
+		os.Exit(1)
+	}
+	path := fs.Arg(0)
+
+	format := archive.FormatForPath(path)
+	if *formatName != "" {
+		f, err := archive.ParseFormat(*formatName)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		format = f
+	}
+
+	store, err := db.NewStore(resolveDBPath())
+	if err != nil {
+		log.Fatalf("Failed to open database (is engram serve still running? use POST /api/v1/admin/import instead): %v", err)
+	}
+	defer store.Close()
+
+	var result db.ImportResult
+	if path == "-" {
+		result, err = archive.Import(context.Background(), store, os.Stdin, format)
+	} else {
+		result, err = archive.ImportFile(context.Background(), store, path, format)
+	}
+	if err != nil {
+		// Close first: log.Fatalf skips deferred calls, and committed
+		// batches must be checkpointed
+		store.Close()
+		log.Fatalf("Import failed after %d inserted, %d skipped, %d erased: %v", result.Inserted, result.Skipped, result.Erased, err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d episodes (%d already present, %d permanently deleted, skipped)\n",
+		result.Inserted, result.Skipped, result.Erased)
+	fmt.Fprintf(os.Stderr, "Episodes without a usable embedding are queued and embedded when the server next runs.\n")
+}
+
 func runStdio() {
 	serverURL := os.Getenv("ENGRAM_SERVER_URL")
 	if serverURL == "" {
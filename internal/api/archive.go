@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/archive"
+)
+
+// handleExport streams every episode, expired ones included, as JSONL
+// (default) or Parquet. ?embeddings=true includes each episode's searched
+// vector and the model that produced it.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	format, err := archive.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	withEmbeddings := false
+	if v := r.URL.Query().Get("embeddings"); v != "" {
+		withEmbeddings, err = strconv.ParseBool(v)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "embeddings must be true or false")
+			return
+		}
+	}
+
+	contentType := "application/x-ndjson"
+	if format == archive.Parquet {
+		contentType = "application/vnd.apache.parquet"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="engram-%s.%s"`,
+		time.Now().UTC().Format("20060102-150405"), format))
+
+	// Headers are sent with the first byte, so a failure mid-stream can only
+	// be logged; the truncated body is the client's signal
+	n, err := archive.Export(r.Context(), s.store, w, format, withEmbeddings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: export failed after %d episodes: %v\n", n, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d episodes (%s)\n", n, format)
+}
+
+// handleImport loads an archive produced by handleExport or `engram export`.
+// Episodes whose ID already exists are skipped, so an import can be retried;
+// so are permanently deleted ones, which an old archive must not restore.
+// Episodes arriving without a usable vector are queued for embedding.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	format, err := archive.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := archive.Import(r.Context(), s.store, r.Body, format)
+	if result.Inserted > 0 && s.embeddingQueue != nil {
+		s.embeddingQueue.Kick()
+	}
+	if err != nil {
+		// Batches before the failure are committed either way
+		code := http.StatusInternalServerError
+		if errors.Is(err, archive.ErrInvalidArchive) {
+			code = http.StatusBadRequest
+		}
+		errorResponse(w, code, fmt.Sprintf(
+			"import stopped after %d inserted, %d skipped, %d erased: %v", result.Inserted, result.Skipped, result.Erased, err))
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success":  true,
+		"inserted": result.Inserted,
+		"skipped":  result.Skipped,
+		"erased":   result.Erased,
+	})
+}
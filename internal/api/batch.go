@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// maxBatchEpisodes bounds one bulk request so its single transaction stays
+// comfortably inside the API timeout; larger backlogs are sent in parts
+const maxBatchEpisodes = 1000
+
+// BatchItemResult reports the outcome of one episode in a bulk request
+type BatchItemResult struct {
+	Index      int    `json:"index"`
+	ID         string `json:"id,omitempty"`
+	Supersedes string `json:"supersedes,omitempty"` // The episode that held the item's key
+	Error      string `json:"error,omitempty"`
+}
+
+// handleAddMemoryBatch stores many episodes at once. The body is a JSON array
+// of AddMemoryRequest objects or NDJSON (one object per line). Valid items
+// are inserted in one transaction without embeddings; they are queued and
+// embedded in the background, so the request never waits on the embedding
+// server. Invalid items are reported per index and skipped.
+func (s *Server) handleAddMemoryBatch(w http.ResponseWriter, r *http.Request) {
+	reqs, err := decodeBatch(r.Body)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if len(reqs) == 0 {
+		errorResponse(w, http.StatusBadRequest, "no episodes in request")
+		return
+	}
+	if len(reqs) > maxBatchEpisodes {
+		errorResponse(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch has %d episodes, the limit is %d; split it into smaller requests", len(reqs), maxBatchEpisodes))
+		return
+	}
+
+	results := make([]BatchItemResult, len(reqs))
+	var episodes []*models.Episode
+	var positions []int
+	keys := make(map[[2]string]int) // group and key to the item writing it
+	for i, req := range reqs {
+		results[i].Index = i
+		if req.Dedupe != "" {
+			results[i].Error = "dedupe applies to single writes only"
+			continue
+		}
+		ep, err := req.Episode()
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if ep.Key != "" {
+			k := [2]string{ep.GroupID, ep.Key}
+			if j, ok := keys[k]; ok {
+				results[i].Error = fmt.Sprintf("key %q is already written by item %d", ep.Key, j)
+				continue
+			}
+			keys[k] = i
+		}
+		episodes = append(episodes, ep)
+		positions = append(positions, i)
+	}
+
+	if err := s.store.InsertEpisodes(r.Context(), episodes); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to store episodes: "+err.Error())
+		return
+	}
+	for j, ep := range episodes {
+		results[positions[j]].ID = ep.ID
+		results[positions[j]].Supersedes = ep.Supersedes
+	}
+	if len(episodes) > 0 && s.embeddingQueue != nil {
+		s.embeddingQueue.Kick()
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success":          len(episodes) == len(reqs),
+		"inserted":         len(episodes),
+		"failed":           len(reqs) - len(episodes),
+		"embedding_queued": len(episodes),
+		"results":          results,
+	})
+}
+
+// decodeBatch reads a JSON array or NDJSON stream of AddMemoryRequest
+func decodeBatch(body io.Reader) ([]AddMemoryRequest, error) {
+	br := bufio.NewReader(body)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if first == '[' {
+		var reqs []AddMemoryRequest
+		if err := json.NewDecoder(br).Decode(&reqs); err != nil {
+			return nil, err
+		}
+		return reqs, nil
+	}
+
+	var reqs []AddMemoryRequest
+	dec := json.NewDecoder(br)
+	for {
+		var req AddMemoryRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return reqs, nil
+			}
+			return nil, fmt.Errorf("record %d: %w", len(reqs)+1, err)
+		}
+		reqs = append(reqs, req)
+	}
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		if _, err := br.Discard(1); err != nil {
+			return 0, err
+		}
+	}
+}
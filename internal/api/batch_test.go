@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type kickCounter struct{ kicks int }
+
+func (k *kickCounter) Kick() { k.kicks++ }
+
+func TestAddMemoryBatch(t *testing.T) {
+	embedder := &fakeEmbedder{model: "test-model", dims: 768}
+	s, store := setupReembedServer(t, embedder)
+	queue := &kickCounter{}
+	s.SetEmbeddingQueue(queue)
+	ctx := context.Background()
+
+	post := func(body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/api/v1/memory/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	t.Run("JSON array with a bad item", func(t *testing.T) {
+		w, resp := post(`[
+			{"content": "first", "source": "import"},
+			{"content": "no source"},
+			{"content": "third", "source": "import", "valid_at": "2025-01-02T03:04:05Z"}
+		]`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %v", w.Code, resp)
+		}
+		if resp["inserted"] != 2.0 || resp["failed"] != 1.0 || resp["success"] != false {
+			t.Errorf("Expected 2 inserted and 1 failed, got %v", resp)
+		}
+		results := resp["results"].([]interface{})
+		bad := results[1].(map[string]interface{})
+		if bad["error"] != "source is required" || bad["id"] != nil {
+			t.Errorf("Expected item 1 rejected for missing source, got %v", bad)
+		}
+		for _, i := range []int{0, 2} {
+			if id := results[i].(map[string]interface{})["id"]; id == nil || id == "" {
+				t.Errorf("Expected item %d to have an ID, got %v", i, results[i])
+			}
+		}
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		w, resp := post("{\"content\": \"a\", \"source\": \"import\"}\n{\"content\": \"b\", \"source\": \"import\"}\n")
+		if w.Code != http.StatusOK || resp["inserted"] != 2.0 || resp["success"] != true {
+			t.Errorf("Expected 2 inserted from NDJSON, got %d: %v", w.Code, resp)
+		}
+	})
+
+	t.Run("embedding is deferred to the queue", func(t *testing.T) {
+		if embedder.calls != 0 {
+			t.Errorf("Bulk insert should not embed synchronously, got %d calls", embedder.calls)
+		}
+		n, err := store.CountQueuedEmbeddings(ctx)
+		if err != nil {
+			t.Fatalf("CountQueuedEmbeddings failed: %v", err)
+		}
+		if n != 4 {
+			t.Errorf("Expected 4 queued embeddings, got %d", n)
+		}
+		if queue.kicks != 2 {
+			t.Errorf("Expected the worker kicked once per batch, got %d", queue.kicks)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		if w, _ := post(`[{"content": `); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		if w, _ := post(""); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+}
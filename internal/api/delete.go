@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oscillatelabsllc/engram/internal/db"
+)
+
+// defaultDeletedBy is recorded in the audit log when a request names no actor
+const defaultDeletedBy = "api"
+
+// DeleteEpisodesRequest is the body of a bulk delete-by-filter request.
+// Filter fields are ANDed and at least one is required; expired episodes
+// match too.
+type DeleteEpisodesRequest struct {
+	GroupID   string   `json:"group_id,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Before    string   `json:"before,omitempty"`
+	After     string   `json:"after,omitempty"`
+	DeletedBy string   `json:"deleted_by,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	DryRun    bool     `json:"dry_run,omitempty"`
+}
+
+// handleDeleteEpisode permanently removes one episode. ?deleted_by= and
+// ?reason= are recorded in the audit log.
+func (s *Server) handleDeleteEpisode(w http.ResponseWriter, r *http.Request) {
+	episodeID := chi.URLParam(r, "id")
+	if episodeID == "" {
+		errorResponse(w, http.StatusBadRequest, "episode id is required")
+		return
+	}
+
+	d := db.Deletion{By: r.URL.Query().Get("deleted_by"), Reason: r.URL.Query().Get("reason")}
+	if d.By == "" {
+		d.By = defaultDeletedBy
+	}
+
+	n, err := s.store.DeleteEpisodes(r.Context(), []string{episodeID}, d)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to delete episode: "+err.Error())
+		return
+	}
+	if n == 0 {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("episode not found: %s", episodeID))
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success": true,
+		"message": "Episode permanently deleted",
+	})
+}
+
+// handleDeleteEpisodes permanently removes every episode matching a filter.
+// With dry_run the matching IDs are returned and nothing is deleted.
+func (s *Server) handleDeleteEpisodes(w http.ResponseWriter, r *http.Request) {
+	var req DeleteEpisodesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	filter := db.DeleteFilter{GroupID: req.GroupID, Source: req.Source, Tags: req.Tags}
+	if req.Before != "" {
+		t, err := time.Parse(time.RFC3339, req.Before)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid before format, use ISO 8601")
+			return
+		}
+		filter.Before = &t
+	}
+	if req.After != "" {
+		t, err := time.Parse(time.RFC3339, req.After)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid after format, use ISO 8601")
+			return
+		}
+		filter.After = &t
+	}
+	if filter.IsEmpty() {
+		errorResponse(w, http.StatusBadRequest, "at least one of group_id, source, tags, before, after is required")
+		return
+	}
+
+	ids, err := s.store.MatchEpisodeIDs(r.Context(), filter)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to match episodes: "+err.Error())
+		return
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+
+	if req.DryRun {
+		successResponse(w, map[string]interface{}{
+			"success": true,
+			"dry_run": true,
+			"matched": len(ids),
+			"ids":     ids,
+		})
+		return
+	}
+
+	d := db.Deletion{By: req.DeletedBy, Reason: req.Reason}
+	if d.By == "" {
+		d.By = defaultDeletedBy
+	}
+	n, err := s.store.DeleteEpisodes(r.Context(), ids, d)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to delete episodes: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success": true,
+		"deleted": n,
+		"ids":     ids,
+	})
+}
+
+// handleListDeletions returns the most recent audit-log entries
+func (s *Server) handleListDeletions(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	records, err := s.store.ListDeletions(r.Context(), limit)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to list deletions: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"deletions": records,
+		"count":     len(records),
+	})
+}
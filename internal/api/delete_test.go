@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestDeleteEndpoints(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	var eps []*models.Episode
+	for _, source := range []string{"keep", "purge", "purge"} {
+		ep := &models.Episode{Content: "content from " + source, Source: source}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+		eps = append(eps, ep)
+	}
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	t.Run("single delete", func(t *testing.T) {
+		w, resp := do("DELETE", "/api/v1/memory/episodes/"+eps[0].ID+"?reason=test", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %v", w.Code, resp)
+		}
+		if w, _ := do("DELETE", "/api/v1/memory/episodes/"+eps[0].ID, ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 deleting twice, got %d", w.Code)
+		}
+	})
+
+	t.Run("bulk requires a filter", func(t *testing.T) {
+		if w, _ := do("POST", "/api/v1/memory/episodes/delete", `{"reason": "all"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an empty filter, got %d", w.Code)
+		}
+	})
+
+	t.Run("bulk dry run deletes nothing", func(t *testing.T) {
+		w, resp := do("POST", "/api/v1/memory/episodes/delete", `{"source": "purge", "dry_run": true}`)
+		if w.Code != http.StatusOK || resp["matched"] != 2.0 {
+			t.Fatalf("Expected 2 matched, got %d: %v", w.Code, resp)
+		}
+		if n, _ := store.CountEpisodes(ctx); n != 2 {
+			t.Errorf("Dry run should not delete, %d episodes left", n)
+		}
+	})
+
+	t.Run("bulk delete", func(t *testing.T) {
+		w, resp := do("POST", "/api/v1/memory/episodes/delete", `{"source": "purge", "deleted_by": "ops"}`)
+		if w.Code != http.StatusOK || resp["deleted"] != 2.0 {
+			t.Fatalf("Expected 2 deleted, got %d: %v", w.Code, resp)
+		}
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		w, resp := do("GET", "/api/v1/admin/deletions", "")
+		if w.Code != http.StatusOK || resp["count"] != 3.0 {
+			t.Fatalf("Expected 3 audit entries, got %d: %v", w.Code, resp)
+		}
+		for _, e := range resp["deletions"].([]interface{}) {
+			entry := e.(map[string]interface{})
+			if _, ok := entry["content"]; ok {
+				t.Errorf("Audit entry must not carry content: %v", entry)
+			}
+			if entry["source"] == "keep" && (entry["deleted_by"] != "api" || entry["reason"] != "test") {
+				t.Errorf("Expected default actor and reason recorded, got %v", entry)
+			}
+		}
+	})
+}
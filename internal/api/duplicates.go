@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+)
+
+// DuplicateScanStatus reports the state and findings of the current or most
+// recent duplicate scan
+type DuplicateScanStatus struct {
+	Running    bool                  `json:"running"`
+	Threshold  float64               `json:"threshold"`
+	GroupID    string                `json:"group_id,omitempty"` // Scanned group; "" = every group
+	Groups     int                   `json:"groups"`
+	GroupsDone int                   `json:"groups_done"`
+	Clusters   []DuplicateReportItem `json:"clusters"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// DuplicateReportItem is one cluster of a scan report. Cluster numbers the
+// clusters from 0 in report order; consolidation refers to them by it.
+type DuplicateReportItem struct {
+	Cluster int `json:"cluster"`
+	db.DuplicateCluster
+	Consolidated bool `json:"consolidated"`
+}
+
+// handleStartDuplicateScan launches an async scan for clusters of
+// near-duplicate episodes: {"threshold": 0.95, "group_id": "..."}, both
+// optional. The report is read with GET /admin/duplicates.
+func (s *Server) handleStartDuplicateScan(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Threshold float64 `json:"threshold"`
+		GroupID   string  `json:"group_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Threshold < 0 || req.Threshold > 1 {
+		errorResponse(w, http.StatusBadRequest, "threshold must be between 0.0 and 1.0")
+		return
+	}
+	if req.Threshold == 0 {
+		req.Threshold = db.DefaultDedupeThreshold
+	}
+
+	var groups []string
+	if req.GroupID != "" {
+		groups = []string{req.GroupID}
+	} else {
+		var err error
+		groups, err = s.store.DuplicateGroups(r.Context())
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "failed to list groups: "+err.Error())
+			return
+		}
+	}
+
+	s.dupMu.Lock()
+	if s.dupScan.Running {
+		s.dupMu.Unlock()
+		status := s.duplicateReport()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "a duplicate scan is already running",
+			"job":     status,
+		})
+		return
+	}
+
+	now := time.Now()
+	s.dupScan = DuplicateScanStatus{
+		Running:   true,
+		Threshold: req.Threshold,
+		GroupID:   req.GroupID,
+		Groups:    len(groups),
+		Clusters:  []DuplicateReportItem{},
+		StartedAt: &now,
+	}
+	// Worker outlives the request; tie it to server lifetime instead
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	s.dupCancel = workerCancel
+	s.dupMu.Unlock()
+
+	go s.runDuplicateScan(workerCtx, groups, req.Threshold)
+
+	successResponse(w, map[string]interface{}{
+		"success":   true,
+		"message":   "duplicate scan started",
+		"groups":    len(groups),
+		"threshold": req.Threshold,
+	})
+}
+
+// handleGetDuplicateScan reports scan progress and the clusters found so far
+func (s *Server) handleGetDuplicateScan(w http.ResponseWriter, r *http.Request) {
+	successResponse(w, map[string]interface{}{
+		"job": s.duplicateReport(),
+	})
+}
+
+// duplicateReport snapshots the scan status, clusters included
+func (s *Server) duplicateReport() DuplicateScanStatus {
+	s.dupMu.Lock()
+	defer s.dupMu.Unlock()
+	status := s.dupScan
+	status.Clusters = append([]DuplicateReportItem(nil), s.dupScan.Clusters...)
+	return status
+}
+
+// runDuplicateScan compares the episodes of each group in turn, appending
+// the clusters found to the report as it goes
+func (s *Server) runDuplicateScan(ctx context.Context, groups []string, threshold float64) {
+	var jobErr error
+	for _, group := range groups {
+		if ctx.Err() != nil {
+			jobErr = ctx.Err()
+			break
+		}
+		clusters, err := s.store.DuplicateClusters(ctx, group, threshold)
+		if err != nil {
+			jobErr = fmt.Errorf("scanning group %s: %w", group, err)
+			break
+		}
+
+		s.dupMu.Lock()
+		for _, c := range clusters {
+			s.dupScan.Clusters = append(s.dupScan.Clusters, DuplicateReportItem{
+				Cluster:          len(s.dupScan.Clusters),
+				DuplicateCluster: c,
+			})
+		}
+		s.dupScan.GroupsDone++
+		s.dupMu.Unlock()
+	}
+
+	now := time.Now()
+	s.dupMu.Lock()
+	s.dupScan.Running = false
+	s.dupScan.FinishedAt = &now
+	if jobErr != nil {
+		s.dupScan.Error = jobErr.Error()
+	}
+	final := s.dupScan
+	s.dupCancel = nil
+	s.dupMu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "Duplicate scan finished: %d clusters in %d/%d groups at threshold %.2f\n",
+		len(final.Clusters), final.GroupsDone, final.Groups, threshold)
+}
+
+// handleConsolidateDuplicates expires all but one episode of clusters from
+// the last finished scan, recording superseded_by in the losers' metadata.
+// {"clusters": [{"cluster": 0, "canonical_id": "..."}]} picks clusters and,
+// optionally, their survivors (default: the suggested canonical episode);
+// {"all": true} consolidates every cluster not yet consolidated.
+func (s *Server) handleConsolidateDuplicates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		All      bool `json:"all"`
+		Clusters []struct {
+			Cluster     int    `json:"cluster"`
+			CanonicalID string `json:"canonical_id"`
+		} `json:"clusters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.All == (len(req.Clusters) > 0) {
+		errorResponse(w, http.StatusBadRequest, "give either clusters or all")
+		return
+	}
+
+	report := s.duplicateReport()
+	if report.Running {
+		errorResponse(w, http.StatusConflict, "a duplicate scan is still running")
+		return
+	}
+	if report.FinishedAt == nil {
+		errorResponse(w, http.StatusConflict, "no duplicate scan to consolidate: start one with POST /api/v1/admin/duplicates")
+		return
+	}
+
+	// Resolve the whole request before expiring anything
+	type pick struct {
+		cluster   int
+		canonical string
+		ids       []string
+	}
+	var picks []pick
+	choose := func(n int, canonical string) error {
+		if n < 0 || n >= len(report.Clusters) {
+			return fmt.Errorf("no cluster %d in the report", n)
+		}
+		c := report.Clusters[n]
+		if canonical == "" {
+			canonical = c.CanonicalID
+		}
+		p := pick{cluster: n, canonical: canonical}
+		found := false
+		for _, m := range c.Episodes {
+			p.ids = append(p.ids, m.ID)
+			found = found || m.ID == canonical
+		}
+		if !found {
+			return fmt.Errorf("episode %s is not in cluster %d", canonical, n)
+		}
+		picks = append(picks, p)
+		return nil
+	}
+	if req.All {
+		for _, c := range report.Clusters {
+			if !c.Consolidated {
+				_ = choose(c.Cluster, "") // the suggested canonical is always a member
+			}
+		}
+	}
+	for _, c := range req.Clusters {
+		if err := choose(c.Cluster, c.CanonicalID); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	type outcome struct {
+		Cluster     int    `json:"cluster"`
+		CanonicalID string `json:"canonical_id"`
+		Expired     int    `json:"expired"`
+		Error       string `json:"error,omitempty"`
+	}
+	results := make([]outcome, 0, len(picks))
+	expired := 0
+	for _, p := range picks {
+		n, err := s.store.SupersedeEpisodes(r.Context(), p.canonical, p.ids)
+		o := outcome{Cluster: p.cluster, CanonicalID: p.canonical, Expired: n}
+		if err != nil {
+			o.Error = err.Error()
+		} else {
+			expired += n
+			s.markConsolidated(report.StartedAt, p.cluster)
+		}
+		results = append(results, o)
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success":  true,
+		"expired":  expired,
+		"clusters": results,
+	})
+}
+
+// markConsolidated flags a cluster of the report started at startedAt, unless
+// a newer scan has replaced it since
+func (s *Server) markConsolidated(startedAt *time.Time, cluster int) {
+	s.dupMu.Lock()
+	defer s.dupMu.Unlock()
+	if s.dupScan.StartedAt == startedAt && cluster < len(s.dupScan.Clusters) {
+		s.dupScan.Clusters[cluster].Consolidated = true
+	}
+}
+
+// stopDuplicateScan cancels a running duplicate scan, if any
+func (s *Server) stopDuplicateScan() {
+	s.dupMu.Lock()
+	cancel := s.dupCancel
+	s.dupMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
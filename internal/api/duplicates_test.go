@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func waitForDuplicateScan(t *testing.T, s *Server) DuplicateScanStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		status := s.duplicateReport()
+		if !status.Running && status.FinishedAt != nil {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("duplicate scan did not finish in time")
+	return DuplicateScanStatus{}
+}
+
+func TestDuplicateScanEndpoints(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	var eps []*models.Episode
+	for i, vec := range [][]float32{emb(1, 0), emb(1, 0.1), emb(0, 1)} {
+		ep := &models.Episode{
+			Content: "episode " + string(rune('a'+i)), Source: "test", Embedding: vec, EmbeddingModel: "test-model",
+			CreatedAt: time.Now().Add(time.Duration(i-10) * time.Minute),
+		}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+		eps = append(eps, ep)
+	}
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/api/v1/admin/duplicates/consolidate", `{"all": true}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 before any scan, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := post("/api/v1/admin/duplicates", `{"threshold": 0.99}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	status := waitForDuplicateScan(t, s)
+	if status.Error != "" || len(status.Clusters) != 1 || len(status.Clusters[0].Episodes) != 2 {
+		t.Fatalf("Expected one cluster of two, got %+v", status)
+	}
+	if status.Clusters[0].CanonicalID != eps[0].ID {
+		t.Errorf("Expected the oldest episode suggested, got %s", status.Clusters[0].CanonicalID)
+	}
+
+	t.Run("a canonical outside the cluster is refused", func(t *testing.T) {
+		w := post("/api/v1/admin/duplicates/consolidate", `{"clusters": [{"cluster": 0, "canonical_id": "`+eps[2].ID+`"}]}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("consolidation keeps the chosen episode", func(t *testing.T) {
+		w := post("/api/v1/admin/duplicates/consolidate", `{"clusters": [{"cluster": 0, "canonical_id": "`+eps[1].ID+`"}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Expired int `json:"expired"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Expired != 1 {
+			t.Fatalf("Expected one episode expired, got %s", w.Body.String())
+		}
+		loser, err := store.GetEpisode(ctx, eps[0].ID)
+		if err != nil {
+			t.Fatalf("GetEpisode failed: %v", err)
+		}
+		if loser.ExpiredAt == nil || !strings.Contains(loser.Metadata, eps[1].ID) {
+			t.Errorf("Expected the loser expired and superseded, got %+v", loser)
+		}
+		if !s.duplicateReport().Clusters[0].Consolidated {
+			t.Error("Expected the cluster marked consolidated")
+		}
+	})
+}
@@ -3,58 +3,83 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/filter"
 	"github.com/oscillatelabsllc/engram/internal/models"
 )
 
 // AddMemoryRequest represents the request body for adding a memory
 type AddMemoryRequest struct {
-	Content           string   `json:"content"`
-	Name              string   `json:"name,omitempty"`
-	Source            string   `json:"source"`
-	SourceModel       string   `json:"source_model,omitempty"`
-	SourceDescription string   `json:"source_description,omitempty"`
-	GroupID           string   `json:"group_id,omitempty"`
-	Tags              []string `json:"tags,omitempty"`
-	ValidAt           string   `json:"valid_at,omitempty"`
-	Metadata          string   `json:"metadata,omitempty"`
+	models.EpisodeInput
+	Dedupe          string  `json:"dedupe,omitempty"`           // Single writes only: "reject", "return_existing" or "merge"
+	DedupeThreshold float64 `json:"dedupe_threshold,omitempty"` // Similarity for a near-duplicate
 }
 
 // SearchRequest represents the request parameters for searching memories
 type SearchRequest struct {
-	Query          string   `json:"query,omitempty"`
-	GroupID        string   `json:"group_id,omitempty"`
-	MaxResults     int      `json:"max_results,omitempty"`
-	Before         string   `json:"before,omitempty"`
-	After          string   `json:"after,omitempty"`
-	Tags           []string `json:"tags,omitempty"`
-	Source         string   `json:"source,omitempty"`
-	IncludeExpired bool     `json:"include_expired,omitempty"`
-	MinSimilarity  float64  `json:"min_similarity,omitempty"`
-	SearchMode     string   `json:"search_mode,omitempty"`
-	SearchAlpha    float64  `json:"search_alpha,omitempty"`
-	TagBoost       float64  `json:"tag_boost,omitempty"`
+	Query               string       `json:"query,omitempty"`
+	SimilarTo           string       `json:"similar_to,omitempty"`
+	GroupID             string       `json:"group_id,omitempty"`
+	MaxResults          int          `json:"max_results,omitempty"`
+	Before              string       `json:"before,omitempty"`
+	After               string       `json:"after,omitempty"`
+	Tags                []string     `json:"tags,omitempty"`
+	Source              string       `json:"source,omitempty"`
+	Filter              *filter.Expr `json:"filter,omitempty"`
+	IncludeExpired      bool         `json:"include_expired,omitempty"`
+	AsOf                string       `json:"as_of,omitempty"`
+	ValidBefore         string       `json:"valid_before,omitempty"`
+	ValidAfter          string       `json:"valid_after,omitempty"`
+	MinSimilarity       float64      `json:"min_similarity,omitempty"`
+	SearchMode          string       `json:"search_mode,omitempty"`
+	SearchAlpha         float64      `json:"search_alpha,omitempty"`
+	Fusion              string       `json:"fusion,omitempty"`
+	TagBoost            float64      `json:"tag_boost,omitempty"`
+	Diversity           float64      `json:"diversity,omitempty"`
+	RecencyWeight       float64      `json:"recency_weight,omitempty"`
+	RecencyHalfLifeDays float64      `json:"recency_half_life_days,omitempty"`
+	RecencyField        string       `json:"recency_field,omitempty"`
+	ImportanceWeight    float64      `json:"importance_weight,omitempty"`
+	Cursor              string       `json:"cursor,omitempty"`
+	Explain             bool         `json:"explain,omitempty"`
+	Strict              bool         `json:"strict,omitempty"`
 }
 
 // GetEpisodesRequest represents query parameters for getting episodes
 type GetEpisodesRequest struct {
-	GroupID    string `json:"group_id,omitempty"`
-	MaxResults int    `json:"max_results,omitempty"`
-	Before     string `json:"before,omitempty"`
-	After      string `json:"after,omitempty"`
+	GroupID    string       `json:"group_id,omitempty"`
+	MaxResults int          `json:"max_results,omitempty"`
+	Before     string       `json:"before,omitempty"`
+	After      string       `json:"after,omitempty"`
+	Filter     *filter.Expr `json:"filter,omitempty"`
+	Cursor     string       `json:"cursor,omitempty"`
 }
 
+// LookupEpisodesRequest is the body of a multi-ID episode lookup
+type LookupEpisodesRequest struct {
+	IDs              []string `json:"ids"`
+	IncludeEmbedding bool     `json:"include_embedding,omitempty"`
+}
+
+// maxLookupIDs bounds a single multi-ID lookup
+const maxLookupIDs = 100
+
 // UpdateEpisodeRequest represents the request body for updating an episode
 type UpdateEpisodeRequest struct {
 	Tags      *[]string `json:"tags,omitempty"`
 	ExpiresAt *string   `json:"expires_at,omitempty"`
 	Metadata  *string   `json:"metadata,omitempty"`
+	Content   *string   `json:"content,omitempty"` // Keeps the ID; the prior text becomes a version
+	Name      *string   `json:"name,omitempty"`
 }
 
 // handleAddMemory processes requests to add a new memory
@@ -65,73 +90,69 @@ func (s *Server) handleAddMemory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required fields
-	if req.Content == "" {
-		errorResponse(w, http.StatusBadRequest, "content is required")
+	episode, err := req.Episode()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	if req.Source == "" {
-		errorResponse(w, http.StatusBadRequest, "source is required")
+	policy := db.DedupePolicy{Action: req.Dedupe, Threshold: req.DedupeThreshold}
+	if err := policy.Validate(); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Set defaults
-	if req.GroupID == "" {
-		req.GroupID = "default"
-	}
-
-	// Parse valid_at time
-	var validAt *time.Time
-	if req.ValidAt != "" {
-		t, err := time.Parse(time.RFC3339, req.ValidAt)
-		if err != nil {
-			errorResponse(w, http.StatusBadRequest, "invalid valid_at format, use ISO 8601")
-			return
-		}
-		validAt = &t
-	}
-
 	// Generate embedding
 	embedCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	var embedding []float32
-	emb, err := s.embedder.Generate(embedCtx, req.Content)
+	emb, chunks, err := s.chunker.Embed(embedCtx, s.embedder, req.Content)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to generate embedding: %v\n", err)
 	} else {
 		embedding = emb
 		fmt.Fprintf(os.Stderr, "Success: Generated embedding with %d dimensions\n", len(emb))
 	}
-
-	// Create episode
-	episode := &models.Episode{
-		Name:              req.Name,
-		Content:           req.Content,
-		Source:            req.Source,
-		SourceModel:       req.SourceModel,
-		SourceDescription: req.SourceDescription,
-		GroupID:           req.GroupID,
-		Tags:              req.Tags,
-		ValidAt:           validAt,
-		Metadata:          req.Metadata,
-		Embedding:         embedding,
-		EmbeddingModel:    s.embedder.Model(),
+	episode.Embedding = embedding
+	episode.Chunks = chunks
+	episode.EmbeddingModel = s.embedder.Model()
+
+	// Store in database, unless the dedupe policy finds a duplicate
+	stored, dedupe, err := s.store.InsertEpisodeDeduped(r.Context(), episode, policy)
+	if errors.Is(err, db.ErrDuplicate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error(), "dedupe": dedupe})
+		return
 	}
-
-	// Store in database
-	if err := s.store.InsertEpisode(r.Context(), episode); err != nil {
+	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Failed to store episode: "+err.Error())
 		return
 	}
 
+	// A duplicate stores nothing: answer with the episode it duplicates
+	if dedupe.Action != "created" {
+		successResponse(w, map[string]interface{}{
+			"success": true,
+			"episode": stored,
+			"dedupe":  dedupe,
+		})
+		return
+	}
+
 	// Return created episode (strip embedding — internal use only)
 	episode.Embedding = nil
-	successResponse(w, map[string]interface{}{
-		"success":  true,
-		"episode":  episode,
-		"embedded": len(embedding) > 0,
-	})
+	// Episodes stored without a vector are queued for background embedding
+	resp := map[string]interface{}{
+		"success":          true,
+		"episode":          episode,
+		"embedded":         len(embedding) > 0,
+		"embedding_queued": len(embedding) == 0,
+	}
+	if policy.Action != db.DedupeOff {
+		resp["dedupe"] = dedupe
+	}
+	successResponse(w, resp)
 }
 
 // handleSearch processes search requests
@@ -148,11 +169,18 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Parse from query parameters
 		req.Query = r.URL.Query().Get("query")
+		req.SimilarTo = r.URL.Query().Get("similar_to")
 		req.GroupID = r.URL.Query().Get("group_id")
 		req.Source = r.URL.Query().Get("source")
 		req.Before = r.URL.Query().Get("before")
 		req.After = r.URL.Query().Get("after")
+		req.AsOf = r.URL.Query().Get("as_of")
+		req.ValidBefore = r.URL.Query().Get("valid_before")
+		req.ValidAfter = r.URL.Query().Get("valid_after")
 		req.SearchMode = r.URL.Query().Get("search_mode")
+		req.Fusion = r.URL.Query().Get("fusion")
+		req.RecencyField = r.URL.Query().Get("recency_field")
+		req.Cursor = r.URL.Query().Get("cursor")
 
 		if maxResults := r.URL.Query().Get("max_results"); maxResults != "" {
 			fmt.Sscanf(maxResults, "%d", &req.MaxResults)
@@ -166,12 +194,38 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Query().Get("include_expired") == "true" {
 			req.IncludeExpired = true
 		}
+		if r.URL.Query().Get("explain") == "true" {
+			req.Explain = true
+		}
+		if r.URL.Query().Get("strict") == "true" {
+			req.Strict = true
+		}
 		if tags := r.URL.Query().Get("tags"); tags != "" {
 			req.Tags = strings.Split(tags, ",")
 		}
 		if tagBoost := r.URL.Query().Get("tag_boost"); tagBoost != "" {
 			fmt.Sscanf(tagBoost, "%f", &req.TagBoost)
 		}
+		if diversity := r.URL.Query().Get("diversity"); diversity != "" {
+			fmt.Sscanf(diversity, "%f", &req.Diversity)
+		}
+		if f := r.URL.Query().Get("filter"); f != "" {
+			expr, err := filter.Parse(f)
+			if err != nil {
+				errorResponse(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+				return
+			}
+			req.Filter = expr
+		}
+		if recency := r.URL.Query().Get("recency_weight"); recency != "" {
+			fmt.Sscanf(recency, "%f", &req.RecencyWeight)
+		}
+		if halfLife := r.URL.Query().Get("recency_half_life_days"); halfLife != "" {
+			fmt.Sscanf(halfLife, "%f", &req.RecencyHalfLifeDays)
+		}
+		if importance := r.URL.Query().Get("importance_weight"); importance != "" {
+			fmt.Sscanf(importance, "%f", &req.ImportanceWeight)
+		}
 	}
 
 	// Validate search_mode
@@ -180,14 +234,51 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A seeded search takes its query from the seed episode
+	if req.Query != "" && req.SimilarTo != "" {
+		errorResponse(w, http.StatusBadRequest, "query and similar_to cannot be combined")
+		return
+	}
+
+	// Validate fusion
+	if req.Fusion != "" && req.Fusion != "linear" && req.Fusion != "rrf" {
+		errorResponse(w, http.StatusBadRequest, "fusion must be 'linear' or 'rrf'")
+		return
+	}
+
 	// Validate search_alpha range (only check when explicitly provided)
 	if req.SearchAlpha < 0 || req.SearchAlpha > 1 {
 		errorResponse(w, http.StatusBadRequest, "search_alpha must be between 0.0 and 1.0")
 		return
 	}
 
-	// Set defaults
-	if req.GroupID == "" {
+	// Validate diversity range
+	if req.Diversity < 0 || req.Diversity > 1 {
+		errorResponse(w, http.StatusBadRequest, "diversity must be between 0.0 and 1.0")
+		return
+	}
+
+	// Validate the filter expression (the string syntax is checked as it's parsed)
+	if req.Filter != nil {
+		if err := req.Filter.Validate(); err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+			return
+		}
+	}
+
+	// Validate recency and importance weighting
+	if req.RecencyWeight < 0 || req.RecencyHalfLifeDays < 0 || req.ImportanceWeight < 0 {
+		errorResponse(w, http.StatusBadRequest, "recency_weight, recency_half_life_days, and importance_weight must not be negative")
+		return
+	}
+	if req.RecencyField != "" && req.RecencyField != "created_at" && req.RecencyField != "valid_at" {
+		errorResponse(w, http.StatusBadRequest, "recency_field must be 'created_at' or 'valid_at'")
+		return
+	}
+
+	// Set defaults. A filter expression that names groups scopes the search
+	// itself, so it isn't narrowed to the default group.
+	if req.GroupID == "" && !req.Filter.References("group_id") {
 		req.GroupID = "default"
 	}
 	if req.MaxResults == 0 {
@@ -196,6 +287,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	// Generate embedding for query if provided (skip for keyword mode)
 	var queryEmbedding []float32
+	var embeddingErr error
 	if req.Query != "" && req.SearchMode != "keyword" {
 		embedCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
@@ -203,6 +295,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		emb, err := s.embedder.Generate(embedCtx, req.Query)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Failed to generate query embedding: %v\n", err)
+			embeddingErr = err
 		} else {
 			queryEmbedding = emb
 			fmt.Fprintf(os.Stderr, "Success: Generated query embedding with %d dimensions\n", len(emb))
@@ -228,32 +321,98 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		afterTime = &t
 	}
 
-	// Search episodes using the existing Search method
-	episodes, err := s.store.Search(r.Context(), models.SearchParams{
-		Query:          req.Query,
-		QueryEmbedding: queryEmbedding,
-		GroupID:        req.GroupID,
-		MaxResults:     req.MaxResults,
-		Before:         beforeTime,
-		After:          afterTime,
-		Tags:           req.Tags,
-		Source:         req.Source,
-		IncludeExpired: req.IncludeExpired,
-		MinSimilarity:  req.MinSimilarity,
-		SearchMode:     req.SearchMode,
-		SearchAlpha:    req.SearchAlpha,
-		TagBoost:       req.TagBoost,
+	// Parse as-of and valid-time filters
+	var asOf, validBefore, validAfter *time.Time
+	if req.AsOf != "" {
+		t, err := time.Parse(time.RFC3339, req.AsOf)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid as_of format, use ISO 8601")
+			return
+		}
+		asOf = &t
+	}
+	if req.ValidBefore != "" {
+		t, err := time.Parse(time.RFC3339, req.ValidBefore)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid valid_before format, use ISO 8601")
+			return
+		}
+		validBefore = &t
+	}
+	if req.ValidAfter != "" {
+		t, err := time.Parse(time.RFC3339, req.ValidAfter)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid valid_after format, use ISO 8601")
+			return
+		}
+		validAfter = &t
+	}
+
+	page, err := s.store.SearchPage(r.Context(), models.SearchParams{
+		Query:               req.Query,
+		QueryEmbedding:      queryEmbedding,
+		SimilarTo:           req.SimilarTo,
+		GroupID:             req.GroupID,
+		MaxResults:          req.MaxResults,
+		Before:              beforeTime,
+		After:               afterTime,
+		Tags:                req.Tags,
+		Source:              req.Source,
+		Filter:              req.Filter,
+		IncludeExpired:      req.IncludeExpired,
+		AsOf:                asOf,
+		ValidBefore:         validBefore,
+		ValidAfter:          validAfter,
+		MinSimilarity:       req.MinSimilarity,
+		SearchMode:          req.SearchMode,
+		SearchAlpha:         req.SearchAlpha,
+		Fusion:              req.Fusion,
+		TagBoost:            req.TagBoost,
+		Diversity:           req.Diversity,
+		RecencyWeight:       req.RecencyWeight,
+		RecencyHalfLifeDays: req.RecencyHalfLifeDays,
+		RecencyField:        req.RecencyField,
+		ImportanceWeight:    req.ImportanceWeight,
+		Cursor:              req.Cursor,
+		Explain:             req.Explain,
+		Strict:              req.Strict,
+		EmbeddingError:      embeddingErr,
 	})
 
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Search failed: "+err.Error())
+		errorResponse(w, searchErrorStatus(err), "Search failed: "+err.Error())
 		return
 	}
 
-	successResponse(w, map[string]interface{}{
-		"episodes": episodes,
-		"count":    len(episodes),
-	})
+	resp := pageResponse(page)
+	resp["search_mode_effective"] = page.SearchModeEffective
+	resp["warnings"] = page.Warnings
+	successResponse(w, resp)
+}
+
+// searchErrorStatus maps a search failure to an HTTP status: a bad cursor is
+// the caller's mistake, anything else is ours
+func searchErrorStatus(err error) int {
+	if errors.Is(err, db.ErrInvalidCursor) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, db.ErrSeedNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, db.ErrDegraded) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// pageResponse is the body for a page of episodes. next_cursor is always
+// present, empty on the last page.
+func pageResponse(page *models.SearchPage) map[string]interface{} {
+	return map[string]interface{}{
+		"episodes":    page.Episodes,
+		"count":       len(page.Episodes),
+		"next_cursor": page.NextCursor,
+	}
 }
 
 // handleGetEpisodes retrieves episodes by time range
@@ -264,13 +423,23 @@ func (s *Server) handleGetEpisodes(w http.ResponseWriter, r *http.Request) {
 	req.GroupID = r.URL.Query().Get("group_id")
 	req.Before = r.URL.Query().Get("before")
 	req.After = r.URL.Query().Get("after")
+	req.Cursor = r.URL.Query().Get("cursor")
 
 	if maxResults := r.URL.Query().Get("max_results"); maxResults != "" {
 		fmt.Sscanf(maxResults, "%d", &req.MaxResults)
 	}
+	if f := r.URL.Query().Get("filter"); f != "" {
+		expr, err := filter.Parse(f)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+			return
+		}
+		req.Filter = expr
+	}
 
-	// Set defaults
-	if req.GroupID == "" {
+	// Set defaults. A filter expression that names groups scopes the listing
+	// itself, so it isn't narrowed to the default group.
+	if req.GroupID == "" && !req.Filter.References("group_id") {
 		req.GroupID = "default"
 	}
 	if req.MaxResults == 0 {
@@ -296,26 +465,128 @@ func (s *Server) handleGetEpisodes(w http.ResponseWriter, r *http.Request) {
 		afterTime = &t
 	}
 
-	// Use Search method without query for chronological listing
-	episodes, err := s.store.Search(r.Context(), models.SearchParams{
+	// Search without a query lists chronologically
+	page, err := s.store.SearchPage(r.Context(), models.SearchParams{
 		GroupID:    req.GroupID,
 		MaxResults: req.MaxResults,
 		Before:     beforeTime,
 		After:      afterTime,
+		Filter:     req.Filter,
+		Cursor:     req.Cursor,
+	})
+
+	if err != nil {
+		errorResponse(w, searchErrorStatus(err), "Failed to get episodes: "+err.Error())
+		return
+	}
+
+	successResponse(w, pageResponse(page))
+}
+
+// handleGetEpisode returns one episode by ID, expired or not.
+// ?include_embedding=true adds the vector search uses.
+func (s *Server) handleGetEpisode(w http.ResponseWriter, r *http.Request) {
+	episodeID := chi.URLParam(r, "id")
+	includeEmbedding := false
+	if v := r.URL.Query().Get("include_embedding"); v != "" {
+		var err error
+		if includeEmbedding, err = strconv.ParseBool(v); err != nil {
+			errorResponse(w, http.StatusBadRequest, "include_embedding must be true or false")
+			return
+		}
+	}
+
+	episodes, err := s.store.GetEpisodesByID(r.Context(), []string{episodeID}, includeEmbedding)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to get episode: "+err.Error())
+		return
+	}
+	if len(episodes) == 0 {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("episode not found: %s", episodeID))
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"episode": models.NewEpisodeLookups(episodes, time.Now())[0],
+	})
+}
+
+// handleGetByKey returns the current episode holding a key:
+// ?key=...&group_id=..., the group defaulting to "default"
+func (s *Server) handleGetByKey(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if strings.TrimSpace(key) == "" {
+		errorResponse(w, http.StatusBadRequest, "key is required")
+		return
+	}
+	groupID := r.URL.Query().Get("group_id")
+	if groupID == "" {
+		groupID = "default"
+	}
+
+	ep, err := s.store.GetEpisodeByKey(r.Context(), groupID, key)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to get episode: "+err.Error())
+		return
+	}
+	if ep == nil {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("no current episode for key %q in group %s", key, groupID))
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"episode": ep,
 	})
+}
 
+// handleLookupEpisodes returns several episodes by ID in the order asked,
+// listing the IDs that don't exist under "missing"
+func (s *Server) handleLookupEpisodes(w http.ResponseWriter, r *http.Request) {
+	var req LookupEpisodesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if len(req.IDs) == 0 {
+		errorResponse(w, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if len(req.IDs) > maxLookupIDs {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("at most %d ids per lookup", maxLookupIDs))
+		return
+	}
+
+	episodes, err := s.store.GetEpisodesByID(r.Context(), req.IDs, req.IncludeEmbedding)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Failed to get episodes: "+err.Error())
 		return
 	}
 
 	successResponse(w, map[string]interface{}{
-		"episodes": episodes,
+		"episodes": models.NewEpisodeLookups(episodes, time.Now()),
 		"count":    len(episodes),
+		"missing":  missingIDs(req.IDs, episodes),
 	})
 }
 
-// handleUpdateEpisode updates an episode's metadata
+// missingIDs returns the requested IDs absent from found
+func missingIDs(ids []string, found []*models.Episode) []string {
+	seen := make(map[string]bool, len(found))
+	for _, ep := range found {
+		seen[ep.ID] = true
+	}
+	missing := []string{}
+	for _, id := range ids {
+		if !seen[id] {
+			missing = append(missing, id)
+			seen[id] = true
+		}
+	}
+	return missing
+}
+
+// handleUpdateEpisode updates an episode's metadata, or edits its content
+// and name, re-embedding new content
 func (s *Server) handleUpdateEpisode(w http.ResponseWriter, r *http.Request) {
 	episodeID := chi.URLParam(r, "id")
 	if episodeID == "" {
@@ -340,22 +611,46 @@ func (s *Server) handleUpdateEpisode(w http.ResponseWriter, r *http.Request) {
 		expiresAt = &t
 	}
 
-	// Update episode
-	err := s.store.UpdateEpisode(r.Context(), episodeID, models.UpdateParams{
+	if req.Content != nil && *req.Content == "" {
+		errorResponse(w, http.StatusBadRequest, "content cannot be empty")
+		return
+	}
+
+	params := models.UpdateParams{
 		Tags:      req.Tags,
 		ExpiredAt: expiresAt,
 		Metadata:  req.Metadata,
-	})
+		Content:   req.Content,
+		Name:      req.Name,
+	}
+	if req.Content != nil {
+		s.embedEdit(r.Context(), &params)
+	}
 
+	// Update episode
+	err := s.store.UpdateEpisode(r.Context(), episodeID, params)
+	if errors.Is(err, db.ErrEpisodeNotFound) {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, db.ErrKeyHeld) {
+		errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Failed to update episode: "+err.Error())
 		return
 	}
 
-	successResponse(w, map[string]interface{}{
+	resp := map[string]interface{}{
 		"success": true,
 		"message": "Episode updated successfully",
-	})
+	}
+	if req.Content != nil {
+		// New content stored without a vector is queued for background embedding
+		resp["embedded"] = len(params.Embedding) > 0
+	}
+	successResponse(w, resp)
 }
 
 // handleGetStatus returns system status
@@ -373,6 +668,9 @@ func (s *Server) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 		"embedding_model": s.embedder.Model(),
 		"reembed_running": reembedRunning,
 	}
+	if space := s.store.ActiveEmbeddingModel(); space != "" {
+		resp["embedding_space"] = space
+	}
 
 	// Live probe result: a degraded embedding endpoint silently downgrades
 	// search to keyword-only, so surface it wherever operators look
@@ -389,6 +687,12 @@ func (s *Server) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 	if stale, err := s.store.CountReembedTargets(r.Context(), s.embedder.Model(), false); err == nil {
 		resp["stale_embeddings"] = stale.Total()
 	}
+	if queued, err := s.store.CountQueuedEmbeddings(r.Context()); err == nil {
+		resp["embedding_queue"] = queued
+	}
+	if s.embeddingCache != nil {
+		resp["embedding_cache"] = s.embeddingCache.Stats()
+	}
 
 	successResponse(w, resp)
 }
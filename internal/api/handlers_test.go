@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestGetEpisodeEndpoints(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	live := &models.Episode{Content: "live", Source: "test"}
+	expired := &models.Episode{Content: "expired", Source: "test", ExpiredAt: &past}
+	for _, ep := range []*models.Episode{live, expired} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	t.Run("single", func(t *testing.T) {
+		w, resp := do("GET", "/api/v1/memory/episodes/"+expired.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %v", w.Code, resp)
+		}
+		ep := resp["episode"].(map[string]interface{})
+		if ep["content"] != "expired" || ep["expired"] != true {
+			t.Errorf("Expected the expired episode flagged, got %v", ep)
+		}
+		if w, _ := do("GET", "/api/v1/memory/episodes/missing", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for an unknown ID, got %d", w.Code)
+		}
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		w, resp := do("POST", "/api/v1/memory/episodes/lookup",
+			`{"ids": ["`+live.ID+`", "missing", "`+expired.ID+`"]}`)
+		if w.Code != http.StatusOK || resp["count"] != 2.0 {
+			t.Fatalf("Expected 2 episodes, got %d: %v", w.Code, resp)
+		}
+		eps := resp["episodes"].([]interface{})
+		if first := eps[0].(map[string]interface{}); first["id"] != live.ID || first["expired"] != false {
+			t.Errorf("Expected the live episode first and unexpired, got %v", first)
+		}
+		if missing := resp["missing"].([]interface{}); len(missing) != 1 || missing[0] != "missing" {
+			t.Errorf("Expected the unknown ID reported missing, got %v", missing)
+		}
+	})
+}
+
+func TestGetEpisodesPaging(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := store.InsertEpisode(ctx, &models.Episode{Content: "page me", Source: "test"}); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	_, first := get("/api/v1/memory/episodes?max_results=2")
+	cursor, _ := first["next_cursor"].(string)
+	if first["count"] != 2.0 || cursor == "" {
+		t.Fatalf("Expected 2 episodes and a cursor, got %v", first)
+	}
+	_, second := get("/api/v1/memory/episodes?max_results=2&cursor=" + cursor)
+	if second["count"] != 1.0 || second["next_cursor"] != "" {
+		t.Errorf("Expected the last episode and no cursor, got %v", second)
+	}
+
+	if w, _ := get("/api/v1/memory/episodes?cursor=bogus"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad cursor, got %d", w.Code)
+	}
+}
+
+func TestFilterKeepsDefaultGroup(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	for _, group := range []string{"default", "private"} {
+		ep := &models.Episode{Content: "tagged", Source: "test", GroupID: group, Tags: []string{"deploy"}}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	count := func(filter string) interface{} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/memory/episodes?filter="+url.QueryEscape(filter), nil))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp["count"]
+	}
+	if got := count("tags any [deploy]"); got != 1.0 {
+		t.Errorf("Expected a filter without a group to stay in the default group, got %v", got)
+	}
+	if got := count("tags any [deploy] AND group in [default, private]"); got != 2.0 {
+		t.Errorf("Expected a filter naming groups to scope the listing, got %v", got)
+	}
+}
+
+func TestSearchReportsDegradation(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{err: errors.New("embedder down")})
+	if err := store.InsertEpisode(context.Background(), &models.Episode{Content: "rollbacks are manual", Source: "test"}); err != nil {
+		t.Fatalf("Failed to insert episode: %v", err)
+	}
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	w, resp := get("/api/v1/memory/search?query=rollbacks&search_mode=hybrid&min_similarity=0.5")
+	if w.Code != http.StatusOK || resp["search_mode_effective"] != "keyword" {
+		t.Fatalf("Expected a keyword search, got %d %v", w.Code, resp)
+	}
+	warnings, _ := resp["warnings"].([]interface{})
+	if len(warnings) != 2 {
+		t.Fatalf("Expected the ignored parameter and the degradation, got %v", resp["warnings"])
+	}
+	ignored, degraded := warnings[0].(map[string]interface{}), warnings[1].(map[string]interface{})
+	if ignored["code"] != "parameter_ignored" || ignored["parameter"] != "min_similarity" {
+		t.Errorf("Expected min_similarity reported ignored, got %v", ignored)
+	}
+	if degraded["code"] != "mode_degraded" || degraded["requested_mode"] != "hybrid" || degraded["effective_mode"] != "keyword" ||
+		!strings.Contains(degraded["message"].(string), "embedder down") {
+		t.Errorf("Expected hybrid reported degraded to keyword with the reason, got %v", degraded)
+	}
+
+	if _, resp := get("/api/v1/memory/search?query=rollbacks&search_mode=keyword"); len(resp["warnings"].([]interface{})) != 0 {
+		t.Errorf("Expected no warnings for a search run as asked, got %v", resp["warnings"])
+	}
+
+	if w, _ := get("/api/v1/memory/search?query=rollbacks&strict=true"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for a strict search that would degrade, got %d", w.Code)
+	}
+}
+
+func TestAddMemoryDedupe(t *testing.T) {
+	s, _ := setupReembedServer(t, &fakeEmbedder{err: errors.New("embedder down")})
+
+	post := func(path, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	code, first := post("/api/v1/memory", `{"content":"deploys freeze on fridays","source":"test","tags":["ops"]}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected the first write stored, got %d %v", code, first)
+	}
+	id := first["episode"].(map[string]interface{})["id"]
+
+	code, resp := post("/api/v1/memory", `{"content":"deploys freeze on fridays","source":"test","dedupe":"reject"}`)
+	dedupe, _ := resp["dedupe"].(map[string]interface{})
+	if code != http.StatusConflict || dedupe["action"] != "rejected" || dedupe["existing_id"] != id {
+		t.Errorf("Expected 409 naming the existing episode, got %d %v", code, resp)
+	}
+
+	code, resp = post("/api/v1/memory", `{"content":"deploys freeze on fridays","source":"test","tags":["policy"],"dedupe":"merge"}`)
+	dedupe, _ = resp["dedupe"].(map[string]interface{})
+	tags, _ := resp["episode"].(map[string]interface{})["tags"].([]interface{})
+	if code != http.StatusOK || dedupe["action"] != "merged" || len(tags) != 2 {
+		t.Errorf("Expected the tags merged into the existing episode, got %d %v", code, resp)
+	}
+
+	if code, _ := post("/api/v1/memory", `{"content":"x","source":"test","dedupe":"skip"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown policy, got %d", code)
+	}
+	_, resp = post("/api/v1/memory/batch", `[{"content":"y","source":"test","dedupe":"reject"}]`)
+	if results, _ := resp["results"].([]interface{}); len(results) != 1 || results[0].(map[string]interface{})["error"] == nil {
+		t.Errorf("Expected a bulk item with dedupe rejected, got %v", resp)
+	}
+}
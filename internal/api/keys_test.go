@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKeyedMemoryEndpoints(t *testing.T) {
+	s, _ := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+	episodeField := func(resp map[string]interface{}, field string) interface{} {
+		ep, _ := resp["episode"].(map[string]interface{})
+		return ep[field]
+	}
+
+	if code, _ := do("GET", "/api/v1/memory/by-key?key=editor", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unheld key, got %d", code)
+	}
+
+	code, resp := do("POST", "/api/v1/memory", `{"content":"editor: vim","source":"test","key":"editor"}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected the write to succeed, got %d %v", code, resp)
+	}
+	firstID := episodeField(resp, "id")
+
+	code, resp = do("POST", "/api/v1/memory", `{"content":"editor: helix","source":"test","key":"editor"}`)
+	if code != http.StatusOK || episodeField(resp, "supersedes") != firstID {
+		t.Fatalf("Expected the second write to supersede %v, got %d %v", firstID, code, resp)
+	}
+	secondID := episodeField(resp, "id")
+
+	code, resp = do("GET", "/api/v1/memory/by-key?key=editor", "")
+	if code != http.StatusOK || episodeField(resp, "id") != secondID {
+		t.Errorf("Expected the current value by key, got %d %v", code, resp)
+	}
+
+	code, resp = do("POST", "/api/v1/memory/batch",
+		`[{"content":"shell: zsh","source":"test","key":"shell"},{"content":"shell: fish","source":"test","key":"shell"}]`)
+	results, _ := resp["results"].([]interface{})
+	if code != http.StatusOK || len(results) != 2 {
+		t.Fatalf("Expected per-item results, got %d %v", code, resp)
+	}
+	if r := results[1].(map[string]interface{}); r["error"] == nil {
+		t.Errorf("Expected the second write of the key rejected, got %v", r)
+	}
+
+	// A rejected item doesn't claim its key
+	code, resp = do("POST", "/api/v1/memory/batch",
+		`[{"content":"theme: dark","source":"test","key":"theme","valid_at":"yesterday"},{"content":"theme: light","source":"test","key":"theme"}]`)
+	results, _ = resp["results"].([]interface{})
+	if code != http.StatusOK || len(results) != 2 {
+		t.Fatalf("Expected per-item results, got %d %v", code, resp)
+	}
+	if r := results[0].(map[string]interface{}); r["error"] == nil {
+		t.Errorf("Expected the bad valid_at rejected, got %v", r)
+	}
+	if r := results[1].(map[string]interface{}); r["error"] != nil || r["id"] == nil {
+		t.Errorf("Expected the valid write of the key stored, got %v", r)
+	}
+
+	if code, _ := do("GET", "/api/v1/memory/by-key", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a key, got %d", code)
+	}
+}
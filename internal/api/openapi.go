@@ -70,7 +70,7 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
-							"description": "Memory added successfully",
+							"description": "Memory added successfully, or, under a dedupe policy, the existing episode it duplicates",
 							"content": map[string]interface{}{
 								"application/json": map[string]interface{}{
 									"schema": map[string]interface{}{
@@ -89,6 +89,118 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								},
 							},
 						},
+						"409": map[string]interface{}{
+							"description": "Duplicate rejected by dedupe: reject",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"error": map[string]interface{}{
+												"type": "string",
+											},
+											"dedupe": map[string]interface{}{
+												"$ref": "#/components/schemas/DedupeResult",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"/api/v1/memory/batch": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Add many memories",
+					"description": "Store up to 1000 episodes in one transaction. The body is a JSON array of AddMemoryRequest objects or NDJSON (one per line). Invalid items are reported by index and skipped. Embeddings are generated in the background, not during the request.",
+					"operationId": "addMemoryBatch",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"$ref": "#/components/schemas/AddMemoryRequest",
+									},
+								},
+							},
+							"application/x-ndjson": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"$ref": "#/components/schemas/AddMemoryRequest",
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Per-item results",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"success": map[string]interface{}{
+												"type":        "boolean",
+												"description": "True when every item was stored",
+											},
+											"inserted": map[string]interface{}{
+												"type": "integer",
+											},
+											"failed": map[string]interface{}{
+												"type": "integer",
+											},
+											"embedding_queued": map[string]interface{}{
+												"type":        "integer",
+												"description": "Episodes queued for background embedding",
+											},
+											"results": map[string]interface{}{
+												"type": "array",
+												"items": map[string]interface{}{
+													"type": "object",
+													"properties": map[string]interface{}{
+														"index": map[string]interface{}{
+															"type": "integer",
+														},
+														"id": map[string]interface{}{
+															"type": "string",
+														},
+														"supersedes": map[string]interface{}{
+															"type":        "string",
+															"description": "ID of the episode that held the item's key, now expired",
+														},
+														"error": map[string]interface{}{
+															"type": "string",
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						"400": map[string]interface{}{
+							"description": "Malformed or empty body",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/ErrorResponse",
+									},
+								},
+							},
+						},
+						"413": map[string]interface{}{
+							"description": "More than 1000 episodes",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/ErrorResponse",
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -115,6 +227,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": "default",
 							},
 						},
+						{
+							"name":        "similar_to",
+							"in":          "query",
+							"description": "Episode ID to search around instead of a query. Its stored embedding is the query vector (no embedding call is made), or, when it has none, its content is searched by keyword. The episode itself is excluded. Cannot be combined with query; 404 if the episode doesn't exist.",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
 						{
 							"name":        "max_results",
 							"in":          "query",
@@ -124,6 +244,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": 10,
 							},
 						},
+						{
+							"name":        "cursor",
+							"in":          "query",
+							"description": "next_cursor from the previous page of the same request",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
 						{
 							"name":        "before",
 							"in":          "query",
@@ -142,6 +270,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"format": "date-time",
 							},
 						},
+						{
+							"name":        "filter",
+							"in":          "query",
+							"description": "Filter expression, ANDed with the other filters: conditions joined by AND, OR, NOT and parentheses, e.g. `source = claude-code AND (tags any [deploy, ci] OR metadata.priority >= 2)`. Fields: group_id, source, source_model (=, !=, in [..]); tags (any, all, none [..]); created_at, valid_at, expired_at (<, <=, >, >= an ISO 8601 time); metadata.<key.path> (=, !=, <, <=, >, >=, in [..], exists). A value starting with '{' is read as the JSON form ({\"and\": [...]}, {\"or\": [...]}, {\"not\": {...}}, {\"field\", \"op\", \"value\"}). Unless it has a group_id condition, results stay within group_id (default 'default').",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
 						{
 							"name":        "source",
 							"in":          "query",
@@ -167,6 +303,33 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": false,
 							},
 						},
+						{
+							"name":        "as_of",
+							"in":          "query",
+							"description": "Point-in-time search (ISO 8601): only episodes created by then, valid by then (valid_at, else created_at), and not expired as of then. Expiry is judged at this time rather than now.",
+							"schema": map[string]interface{}{
+								"type":   "string",
+								"format": "date-time",
+							},
+						},
+						{
+							"name":        "valid_before",
+							"in":          "query",
+							"description": "Episodes that became valid before this time (ISO 8601). Episodes without valid_at are valid from created_at.",
+							"schema": map[string]interface{}{
+								"type":   "string",
+								"format": "date-time",
+							},
+						},
+						{
+							"name":        "valid_after",
+							"in":          "query",
+							"description": "Episodes that became valid after this time (ISO 8601). Episodes without valid_at are valid from created_at.",
+							"schema": map[string]interface{}{
+								"type":   "string",
+								"format": "date-time",
+							},
+						},
 						{
 							"name":        "min_similarity",
 							"in":          "query",
@@ -200,6 +363,16 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": 0.7,
 							},
 						},
+						{
+							"name":        "fusion",
+							"in":          "query",
+							"description": "How hybrid search merges the top vector and top keyword results. 'linear' blends their min-max normalized scores by search_alpha; 'rrf' (reciprocal rank fusion) uses only their ranks and ignores search_alpha. Only used when search_mode is 'hybrid'.",
+							"schema": map[string]interface{}{
+								"type":    "string",
+								"enum":    []string{"linear", "rrf"},
+								"default": "linear",
+							},
+						},
 						{
 							"name":        "tag_boost",
 							"in":          "query",
@@ -212,6 +385,79 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": 0.0,
 							},
 						},
+						{
+							"name":        "diversity",
+							"in":          "query",
+							"description": "Maximal marginal relevance re-ranking: the weight given to avoiding results similar to ones already returned, using the stored embeddings. 0.0 (default) = off. Results are then no longer in descending relevance order. Only applies in vector and hybrid modes with a query.",
+							"schema": map[string]interface{}{
+								"type":    "number",
+								"format":  "double",
+								"minimum": 0.0,
+								"maximum": 1.0,
+								"default": 0.0,
+							},
+						},
+						{
+							"name":        "recency_weight",
+							"in":          "query",
+							"description": "When > 0, adds recency_weight × 0.5^(age / half-life) to each result's relevance, so newer episodes rank higher. Age is measured from when the first page was read. 0.0 (default) = off. Only applies to ranked searches.",
+							"schema": map[string]interface{}{
+								"type":    "number",
+								"format":  "double",
+								"minimum": 0.0,
+								"default": 0.0,
+							},
+						},
+						{
+							"name":        "recency_half_life_days",
+							"in":          "query",
+							"description": "Days for the recency term to halve.",
+							"schema": map[string]interface{}{
+								"type":    "number",
+								"format":  "double",
+								"minimum": 0.0,
+								"default": 30.0,
+							},
+						},
+						{
+							"name":        "recency_field",
+							"in":          "query",
+							"description": "Timestamp the recency term decays from. 'valid_at' falls back to created_at for episodes without one.",
+							"schema": map[string]interface{}{
+								"type":    "string",
+								"enum":    []string{"created_at", "valid_at"},
+								"default": "created_at",
+							},
+						},
+						{
+							"name":        "importance_weight",
+							"in":          "query",
+							"description": "When > 0, adds importance_weight × the episode's importance to its relevance. Episodes stored without an importance count as 0.5. 0.0 (default) = off. Only applies to ranked searches.",
+							"schema": map[string]interface{}{
+								"type":    "number",
+								"format":  "double",
+								"minimum": 0.0,
+								"default": 0.0,
+							},
+						},
+						{
+							"name":        "explain",
+							"in":          "query",
+							"description": "Attach an explanation of its relevance to each result",
+							"schema": map[string]interface{}{
+								"type":    "boolean",
+								"default": false,
+							},
+						},
+						{
+							"name":        "strict",
+							"in":          "query",
+							"description": "Fail with 503 instead of falling back to a weaker search mode (vector to chronological, hybrid to keyword) when the query embedding is unavailable",
+							"schema": map[string]interface{}{
+								"type":    "boolean",
+								"default": false,
+							},
+						},
 					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
@@ -224,6 +470,9 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								},
 							},
 						},
+						"503": map[string]interface{}{
+							"description": "A strict search could not run in the requested mode",
+						},
 					},
 				},
 			},
@@ -251,6 +500,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"default": 10,
 							},
 						},
+						{
+							"name":        "cursor",
+							"in":          "query",
+							"description": "next_cursor from the previous page of the same request",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
 						{
 							"name":        "before",
 							"in":          "query",
@@ -269,6 +526,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 								"format": "date-time",
 							},
 						},
+						{
+							"name":        "filter",
+							"in":          "query",
+							"description": "Filter expression, ANDed with the other filters: conditions joined by AND, OR, NOT and parentheses, e.g. `source = claude-code AND (tags any [deploy, ci] OR metadata.priority >= 2)`. Fields: group_id, source, source_model (=, !=, in [..]); tags (any, all, none [..]); created_at, valid_at, expired_at (<, <=, >, >= an ISO 8601 time); metadata.<key.path> (=, !=, <, <=, >, >=, in [..], exists). A value starting with '{' is read as the JSON form ({\"and\": [...]}, {\"or\": [...]}, {\"not\": {...}}, {\"field\", \"op\", \"value\"}). Unless it has a group_id condition, results stay within group_id (default 'default').",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
 					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
@@ -284,11 +549,112 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 					},
 				},
 			},
+			"/api/v1/memory/by-key": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Get episode by key",
+					"description": "Returns the live episode holding a key, the key's current value",
+					"operationId": "getEpisodeByKey",
+					"parameters": []map[string]interface{}{
+						{
+							"name":     "key",
+							"in":       "query",
+							"required": true,
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						{
+							"name":        "group_id",
+							"in":          "query",
+							"description": "Group the key belongs to",
+							"schema": map[string]interface{}{
+								"type":    "string",
+								"default": "default",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Current episode for the key",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"episode": map[string]interface{}{
+												"$ref": "#/components/schemas/Episode",
+											},
+										},
+									},
+								},
+							},
+						},
+						"400": map[string]interface{}{
+							"description": "key missing",
+						},
+						"404": map[string]interface{}{
+							"description": "No live episode holds the key",
+						},
+					},
+				},
+			},
+			"/api/v1/memory/episodes/lookup": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Get episodes by ID",
+					"description": "Returns up to 100 episodes in the order requested, expired ones included and flagged. Unknown IDs are listed under missing.",
+					"operationId": "lookupEpisodes",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type":     "object",
+									"required": []string{"ids"},
+									"properties": map[string]interface{}{
+										"ids": map[string]interface{}{
+											"type":  "array",
+											"items": map[string]interface{}{"type": "string"},
+										},
+										"include_embedding": map[string]interface{}{
+											"type": "boolean",
+										},
+									},
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Episodes found",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"episodes": map[string]interface{}{
+												"type": "array",
+												"items": map[string]interface{}{
+													"$ref": "#/components/schemas/Episode",
+												},
+											},
+											"count": map[string]interface{}{"type": "integer"},
+											"missing": map[string]interface{}{
+												"type":  "array",
+												"items": map[string]interface{}{"type": "string"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
 			"/api/v1/memory/episodes/{id}": map[string]interface{}{
-				"put": map[string]interface{}{
-					"summary":     "Update episode",
-					"description": "Update metadata, tags, or expiration of an episode. Set expired_at to a past timestamp for soft-delete (reversible, hidden from default search). Use tags (e.g. 'deprecated') to demote content that should be filtered at query time.",
-					"operationId": "updateEpisode",
+				"get": map[string]interface{}{
+					"summary":     "Get episode by ID",
+					"description": "Returns one episode, expired or not. Expired episodes carry expired=true.",
+					"operationId": "getEpisode",
 					"parameters": []map[string]interface{}{
 						{
 							"name":        "id",
@@ -296,59 +662,524 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 							"required":    true,
 							"description": "Episode ID",
 							"schema": map[string]interface{}{
-								"type": "string",
+								"type": "string",
+							},
+						},
+						{
+							"name":        "include_embedding",
+							"in":          "query",
+							"description": "Include the stored embedding vector",
+							"schema": map[string]interface{}{
+								"type":    "boolean",
+								"default": false,
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "The episode",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"episode": map[string]interface{}{
+												"$ref": "#/components/schemas/Episode",
+											},
+										},
+									},
+								},
+							},
+						},
+						"404": map[string]interface{}{
+							"description": "Episode not found",
+						},
+					},
+				},
+				"put": map[string]interface{}{
+					"summary":     "Update episode",
+					"description": "Update metadata, tags, expiration, content or name of an episode. Editing content or name keeps the ID and saves the prior text as a version; new content is re-embedded, or queued for embedding when the endpoint is down. Set expired_at to a past timestamp for soft-delete (reversible, hidden from default search). Use tags (e.g. 'deprecated') to demote content that should be filtered at query time.",
+					"operationId": "updateEpisode",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "id",
+							"in":          "path",
+							"required":    true,
+							"description": "Episode ID",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+					},
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"$ref": "#/components/schemas/UpdateEpisodeRequest",
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Episode updated successfully",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"success": map[string]interface{}{
+												"type": "boolean",
+											},
+											"message": map[string]interface{}{
+												"type": "string",
+											},
+											"embedded": map[string]interface{}{
+												"type":        "boolean",
+												"description": "Content edits only: whether the new content was embedded (false: queued)",
+											},
+										},
+									},
+								},
+							},
+						},
+						"404": map[string]interface{}{
+							"description": "Episode not found",
+						},
+						"409": map[string]interface{}{
+							"description": "A future expired_at would make the episode live while another live episode holds its key",
+						},
+					},
+				},
+				"delete": map[string]interface{}{
+					"summary":     "Permanently delete episode",
+					"description": "Erase an episode's content, its prior versions and its embeddings in every embedding space. Irreversible. The deletion is recorded in the audit log (ID, group, source, who, when, why; never the content).",
+					"operationId": "deleteEpisode",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "id",
+							"in":          "path",
+							"required":    true,
+							"description": "Episode ID",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						{
+							"name":        "deleted_by",
+							"in":          "query",
+							"description": "Who requested the deletion (default 'api')",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						{
+							"name":        "reason",
+							"in":          "query",
+							"description": "Why the episode is being deleted",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Episode deleted",
+						},
+						"404": map[string]interface{}{
+							"description": "Episode not found",
+						},
+					},
+				},
+			},
+			"/api/v1/memory/episodes/{id}/versions": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "List episode versions",
+					"description": "Prior content and names of an episode, newest first. Each edit of content or name adds one; the current text is the episode itself.",
+					"operationId": "listEpisodeVersions",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "id",
+							"in":          "path",
+							"required":    true,
+							"description": "Episode ID",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "The episode's versions",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"episode_id": map[string]interface{}{"type": "string"},
+											"count":      map[string]interface{}{"type": "integer"},
+											"versions": map[string]interface{}{
+												"type": "array",
+												"items": map[string]interface{}{
+													"$ref": "#/components/schemas/EpisodeVersion",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						"404": map[string]interface{}{
+							"description": "Episode not found",
+						},
+					},
+				},
+			},
+			"/api/v1/memory/episodes/{id}/versions/{version}/restore": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Restore an episode version",
+					"description": "Makes a prior version's content and name current again and re-embeds it. The restore is itself an edit: the text it replaces becomes the newest version.",
+					"operationId": "restoreEpisodeVersion",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "id",
+							"in":          "path",
+							"required":    true,
+							"description": "Episode ID",
+							"schema": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						{
+							"name":        "version",
+							"in":          "path",
+							"required":    true,
+							"description": "Version number",
+							"schema": map[string]interface{}{
+								"type": "integer",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Version restored; returns the updated episode",
+						},
+						"404": map[string]interface{}{
+							"description": "Episode or version not found",
+						},
+					},
+				},
+			},
+			"/api/v1/memory/episodes/delete": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Permanently delete episodes by filter",
+					"description": "Erase every episode matching the filter, expired ones included. Filter fields are ANDed and at least one is required. Use dry_run to list the matching IDs without deleting.",
+					"operationId": "deleteEpisodes",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"$ref": "#/components/schemas/DeleteEpisodesRequest",
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Episodes deleted (or matched, for a dry run)",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"success": map[string]interface{}{"type": "boolean"},
+											"deleted": map[string]interface{}{"type": "integer"},
+											"matched": map[string]interface{}{
+												"type":        "integer",
+												"description": "Dry run only",
+											},
+											"ids": map[string]interface{}{
+												"type":  "array",
+												"items": map[string]interface{}{"type": "string"},
+											},
+										},
+									},
+								},
+							},
+						},
+						"400": map[string]interface{}{
+							"description": "Empty filter or invalid timestamp",
+						},
+					},
+				},
+			},
+			"/api/v1/status": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Get system status",
+					"description": "Returns current system status and episode count",
+					"operationId": "getStatus",
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "System status",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/StatusResponse",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"/api/v1/admin/export": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Export all episodes",
+					"description": "Streams every episode, expired ones included, as JSONL or Parquet. Not subject to the API request timeout.",
+					"operationId": "exportEpisodes",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "format",
+							"in":          "query",
+							"description": "jsonl (default) or parquet",
+							"schema": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"jsonl", "parquet"},
+							},
+						},
+						{
+							"name":        "embeddings",
+							"in":          "query",
+							"description": "Include each episode's searched vector and its embedding_model",
+							"schema": map[string]interface{}{
+								"type": "boolean",
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "The archive",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid parameters",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/ErrorResponse",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"/api/v1/admin/import": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Import an export",
+					"description": "Loads a JSONL or Parquet archive produced by export. Episodes whose ID already exists are skipped, so imports can be re-run. Not subject to the API request timeout.",
+					"operationId": "importEpisodes",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "format",
+							"in":          "query",
+							"description": "jsonl (default) or parquet",
+							"schema": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"jsonl", "parquet"},
+							},
+						},
+					},
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/x-ndjson": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"$ref": "#/components/schemas/Episode",
+								},
+							},
+							"application/vnd.apache.parquet": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type":   "string",
+									"format": "binary",
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Import counts",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"success": map[string]interface{}{
+												"type": "boolean",
+											},
+											"inserted": map[string]interface{}{
+												"type": "integer",
+											},
+											"skipped": map[string]interface{}{
+												"type":        "integer",
+												"description": "Episodes whose ID already existed",
+											},
+											"erased": map[string]interface{}{
+												"type":        "integer",
+												"description": "Episodes permanently deleted from this store, not restored",
+											},
+										},
+									},
+								},
+							},
+						},
+						"400": map[string]interface{}{
+							"description": "Malformed archive; episodes committed before the error are kept",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/ErrorResponse",
+									},
+								},
+							},
+						},
+						"500": map[string]interface{}{
+							"description": "Store failure; episodes committed before the error are kept",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"$ref": "#/components/schemas/ErrorResponse",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"/api/v1/admin/deletions": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "List deletions",
+					"description": "Most recent permanent deletions, newest first. Entries identify the episode and record who deleted it, when and why; content is not retained.",
+					"operationId": "listDeletions",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "limit",
+							"in":          "query",
+							"description": "Maximum entries to return",
+							"schema": map[string]interface{}{
+								"type":    "integer",
+								"default": 100,
 							},
 						},
 					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Audit log entries",
+						},
+					},
+				},
+			},
+			"/api/v1/admin/duplicates": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Start a duplicate scan",
+					"description": "Compares the vectors of live episodes, group by group, and reports clusters of near-duplicates. Runs asynchronously; poll GET for the report.",
+					"operationId": "startDuplicateScan",
 					"requestBody": map[string]interface{}{
-						"required": true,
+						"required": false,
 						"content": map[string]interface{}{
 							"application/json": map[string]interface{}{
 								"schema": map[string]interface{}{
-									"$ref": "#/components/schemas/UpdateEpisodeRequest",
+									"type": "object",
+									"properties": map[string]interface{}{
+										"threshold": map[string]interface{}{
+											"type":        "number",
+											"format":      "double",
+											"minimum":     0.0,
+											"maximum":     1.0,
+											"description": "Cosine similarity from which two episodes are duplicates (default 0.95)",
+										},
+										"group_id": map[string]interface{}{
+											"type":        "string",
+											"description": "Scan only this group (default: every group)",
+										},
+									},
 								},
 							},
 						},
 					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
-							"description": "Episode updated successfully",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"type": "object",
-										"properties": map[string]interface{}{
-											"success": map[string]interface{}{
-												"type": "boolean",
-											},
-											"message": map[string]interface{}{
-												"type": "string",
-											},
-										},
-									},
-								},
-							},
+							"description": "Duplicate scan started",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid threshold",
+						},
+						"409": map[string]interface{}{
+							"description": "A duplicate scan is already running",
 						},
 					},
 				},
-			},
-			"/api/v1/status": map[string]interface{}{
 				"get": map[string]interface{}{
-					"summary":     "Get system status",
-					"description": "Returns current system status and episode count",
-					"operationId": "getStatus",
+					"summary":     "Get the duplicate report",
+					"description": "Returns the state of the current or most recent duplicate scan and the clusters found. Each cluster is numbered, lists its members oldest first, and suggests the oldest as canonical.",
+					"operationId": "getDuplicateScan",
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
-							"description": "System status",
-							"content": map[string]interface{}{
-								"application/json": map[string]interface{}{
-									"schema": map[string]interface{}{
-										"$ref": "#/components/schemas/StatusResponse",
+							"description": "Scan status and clusters",
+						},
+					},
+				},
+			},
+			"/api/v1/admin/duplicates/consolidate": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Consolidate duplicate clusters",
+					"description": "Expires every member of the chosen clusters of the last finished scan except a canonical episode, recording superseded_by in their metadata. Give either clusters or all.",
+					"operationId": "consolidateDuplicates",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"clusters": map[string]interface{}{
+											"type": "array",
+											"items": map[string]interface{}{
+												"type":     "object",
+												"required": []string{"cluster"},
+												"properties": map[string]interface{}{
+													"cluster": map[string]interface{}{
+														"type":        "integer",
+														"description": "Cluster number from the report",
+													},
+													"canonical_id": map[string]interface{}{
+														"type":        "string",
+														"description": "Member to keep (default: the suggested canonical episode)",
+													},
+												},
+											},
+										},
+										"all": map[string]interface{}{
+											"type":        "boolean",
+											"description": "Consolidate every cluster not yet consolidated, keeping the suggested canonical episodes",
+										},
 									},
 								},
 							},
 						},
 					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Episodes expired, per cluster",
+						},
+						"400": map[string]interface{}{
+							"description": "Unknown cluster, or a canonical episode outside its cluster",
+						},
+						"409": map[string]interface{}{
+							"description": "No finished scan to consolidate",
+						},
+					},
 				},
 			},
 			"/api/v1/admin/reembed": map[string]interface{}{
@@ -368,6 +1199,10 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 											"description": "Re-embed every row, not just stale ones",
 											"default":     false,
 										},
+										"model": map[string]interface{}{
+											"type":        "string",
+											"description": "Embed with this model instead of the active one. When the model has an embedding space, the space is filled (backfill before activation).",
+										},
 									},
 								},
 							},
@@ -392,6 +1227,14 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 					"summary":     "Get re-embed status",
 					"description": "Returns the state of the current or most recent re-embed job plus current stale-embedding counts",
 					"operationId": "getReembedStatus",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "model",
+							"in":          "query",
+							"description": "Report staleness for this model instead of the active one",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
 							"description": "Job status and staleness counts",
@@ -399,6 +1242,118 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 					},
 				},
 			},
+			"/api/v1/admin/embedding-spaces": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "List embedding spaces",
+					"description": "Lists the primary embedding column and every per-model embedding space, with dimensions, which one search uses, and how many live episodes each is missing",
+					"operationId": "listEmbeddingSpaces",
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Embedding spaces and the active space (empty = primary column)",
+						},
+					},
+				},
+				"post": map[string]interface{}{
+					"summary":     "Create an embedding space",
+					"description": "Registers a vector table (with its own HNSW index) for a model, sized by probing the model once. Vectors for that model are written to the space from then on; search keeps using the active space until the new one is activated.",
+					"operationId": "createEmbeddingSpace",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type":     "object",
+									"required": []string{"model"},
+									"properties": map[string]interface{}{
+										"model": map[string]interface{}{
+											"type":        "string",
+											"description": "Embedding model name",
+										},
+									},
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Space created",
+						},
+						"409": map[string]interface{}{
+							"description": "Space already exists, or the model is the one the primary column is searched with",
+						},
+						"502": map[string]interface{}{
+							"description": "Embedding endpoint unavailable",
+						},
+					},
+				},
+				"delete": map[string]interface{}{
+					"summary":     "Drop an embedding space",
+					"description": "Deletes an inactive embedding space and all of its vectors",
+					"operationId": "dropEmbeddingSpace",
+					"parameters": []map[string]interface{}{
+						{
+							"name":     "model",
+							"in":       "query",
+							"required": true,
+							"schema":   map[string]interface{}{"type": "string"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Space dropped",
+						},
+						"404": map[string]interface{}{
+							"description": "No space for that model",
+						},
+						"409": map[string]interface{}{
+							"description": "The space is active",
+						},
+					},
+				},
+			},
+			"/api/v1/admin/embedding-spaces/activate": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Activate an embedding space",
+					"description": "Atomically switches search, query embeddings, and new writes to a space's model. Refused while live episodes are missing from the space unless forced. Pass primary=true to switch back to the primary embedding column.",
+					"operationId": "activateEmbeddingSpace",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"model": map[string]interface{}{
+											"type":        "string",
+											"description": "Model whose space to activate",
+										},
+										"primary": map[string]interface{}{
+											"type":        "boolean",
+											"description": "Switch back to the primary embedding column instead",
+										},
+										"force": map[string]interface{}{
+											"type":        "boolean",
+											"description": "Activate even if the space is not fully backfilled",
+											"default":     false,
+										},
+									},
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Space activated",
+						},
+						"404": map[string]interface{}{
+							"description": "No space for that model",
+						},
+						"409": map[string]interface{}{
+							"description": "Space is missing vectors for live episodes",
+						},
+					},
+				},
+			},
 		},
 		"components": map[string]interface{}{
 			"schemas": map[string]interface{}{
@@ -431,6 +1386,10 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 							"description": "Group ID for multi-tenant support",
 							"default":     "default",
 						},
+						"key": map[string]interface{}{
+							"type":        "string",
+							"description": "Name of a value with one current episode per group. Writing a live episode under a key expires the live episode holding it, recording superseded_by (the new ID) in its metadata. Read with GET /api/v1/memory/by-key.",
+						},
 						"tags": map[string]interface{}{
 							"type": "array",
 							"items": map[string]interface{}{
@@ -447,6 +1406,49 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 							"type":        "string",
 							"description": "JSON string with additional metadata",
 						},
+						"importance": map[string]interface{}{
+							"type":        "number",
+							"format":      "double",
+							"minimum":     0.0,
+							"maximum":     1.0,
+							"description": "How much the episode matters, for searches that set importance_weight. Unset ranks as 0.5.",
+						},
+						"dedupe": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"reject", "return_existing", "merge"},
+							"description": "What to do when the episode duplicates a live one in its group — same content, or an embedding at least dedupe_threshold similar: reject with 409, return the existing episode, or merge the new tags and metadata into it. Omit to always store. Single writes only; bulk items that set it are rejected.",
+						},
+						"dedupe_threshold": map[string]interface{}{
+							"type":        "number",
+							"format":      "double",
+							"minimum":     0.0,
+							"maximum":     1.0,
+							"description": "Cosine similarity from which an episode counts as a near-duplicate (default 0.95)",
+						},
+					},
+				},
+				"DedupeResult": map[string]interface{}{
+					"type":        "object",
+					"description": "What a write with a dedupe policy did",
+					"properties": map[string]interface{}{
+						"action": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"created", "rejected", "returned_existing", "merged"},
+						},
+						"existing_id": map[string]interface{}{
+							"type":        "string",
+							"description": "The episode the new one duplicates",
+						},
+						"match": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"exact", "similar"},
+							"description": "exact: same content; similar: embedding within the threshold",
+						},
+						"similarity": map[string]interface{}{
+							"type":        "number",
+							"format":      "double",
+							"description": "Cosine similarity of a similar match",
+						},
 					},
 				},
 				"AddMemoryResponse": map[string]interface{}{
@@ -462,6 +1464,13 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 							"type":        "boolean",
 							"description": "Whether embedding was generated",
 						},
+						"embedding_queued": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Whether the episode was queued for background embedding because generation failed",
+						},
+						"dedupe": map[string]interface{}{
+							"$ref": "#/components/schemas/DedupeResult",
+						},
 					},
 				},
 				"UpdateEpisodeRequest": map[string]interface{}{
@@ -480,6 +1489,69 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"metadata": map[string]interface{}{
 							"type": "string",
 						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "Corrected content; the prior content is kept as a version and the new content re-embedded",
+						},
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "New name; the prior name is kept as a version",
+						},
+					},
+				},
+				"EpisodeVersion": map[string]interface{}{
+					"type":        "object",
+					"description": "A prior content and name of an episode",
+					"properties": map[string]interface{}{
+						"episode_id": map[string]interface{}{"type": "string"},
+						"version": map[string]interface{}{
+							"type":        "integer",
+							"description": "Numbered from 1 per episode, oldest first",
+						},
+						"content": map[string]interface{}{"type": "string"},
+						"name":    map[string]interface{}{"type": "string"},
+						"replaced_at": map[string]interface{}{
+							"type":        "string",
+							"format":      "date-time",
+							"description": "When an edit superseded this version",
+						},
+					},
+				},
+				"DeleteEpisodesRequest": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"group_id": map[string]interface{}{
+							"type": "string",
+						},
+						"source": map[string]interface{}{
+							"type": "string",
+						},
+						"tags": map[string]interface{}{
+							"type":        "array",
+							"description": "Episodes must carry all of these tags",
+							"items": map[string]interface{}{
+								"type": "string",
+							},
+						},
+						"before": map[string]interface{}{
+							"type":   "string",
+							"format": "date-time",
+						},
+						"after": map[string]interface{}{
+							"type":   "string",
+							"format": "date-time",
+						},
+						"deleted_by": map[string]interface{}{
+							"type":        "string",
+							"description": "Who requested the deletion (default 'api')",
+						},
+						"reason": map[string]interface{}{
+							"type": "string",
+						},
+						"dry_run": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Return the matching IDs without deleting",
+						},
 					},
 				},
 				"SearchResponse": map[string]interface{}{
@@ -494,6 +1566,47 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"count": map[string]interface{}{
 							"type": "integer",
 						},
+						"next_cursor": map[string]interface{}{
+							"type":        "string",
+							"description": "Pass as cursor to fetch the next page; empty on the last page",
+						},
+						"search_mode_effective": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"vector", "keyword", "hybrid", "substring", "chronological"},
+							"description": "How the page was actually ranked; differs from search_mode after a fallback",
+						},
+						"warnings": map[string]interface{}{
+							"type":        "array",
+							"description": "What the search couldn't do as asked; empty when it ran as requested",
+							"items": map[string]interface{}{
+								"$ref": "#/components/schemas/SearchWarning",
+							},
+						},
+					},
+				},
+				"SearchWarning": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"mode_degraded", "parameter_ignored"},
+						},
+						"message": map[string]interface{}{
+							"type":        "string",
+							"description": "The reason, e.g. why the query embedding is unavailable",
+						},
+						"requested_mode": map[string]interface{}{
+							"type":        "string",
+							"description": "mode_degraded: the search mode asked for",
+						},
+						"effective_mode": map[string]interface{}{
+							"type":        "string",
+							"description": "mode_degraded: the search mode used instead",
+						},
+						"parameter": map[string]interface{}{
+							"type":        "string",
+							"description": "parameter_ignored: the parameter that had no effect",
+						},
 					},
 				},
 				"EpisodesResponse": map[string]interface{}{
@@ -508,6 +1621,10 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"count": map[string]interface{}{
 							"type": "integer",
 						},
+						"next_cursor": map[string]interface{}{
+							"type":        "string",
+							"description": "Pass as cursor to fetch the next page; empty on the last page",
+						},
 					},
 				},
 				"StatusResponse": map[string]interface{}{
@@ -548,6 +1665,13 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"group_id": map[string]interface{}{
 							"type": "string",
 						},
+						"key": map[string]interface{}{
+							"type": "string",
+						},
+						"supersedes": map[string]interface{}{
+							"type":        "string",
+							"description": "Set on a keyed write: the episode it replaced as the key's value",
+						},
 						"tags": map[string]interface{}{
 							"type": "array",
 							"items": map[string]interface{}{
@@ -569,6 +1693,24 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"metadata": map[string]interface{}{
 							"type": "string",
 						},
+						"importance": map[string]interface{}{
+							"type":   "number",
+							"format": "double",
+						},
+						"embedding": map[string]interface{}{
+							"type":        "array",
+							"description": "Stored vector, present only when include_embedding is set",
+							"items": map[string]interface{}{
+								"type": "number",
+							},
+						},
+						"embedding_model": map[string]interface{}{
+							"type": "string",
+						},
+						"expired": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Set by ID lookups: whether expired_at has passed",
+						},
 						"similarity": map[string]interface{}{
 							"type":        "number",
 							"format":      "double",
@@ -577,7 +1719,36 @@ func (s *Server) handleOpenAPISpec(w http.ResponseWriter, r *http.Request) {
 						"relevance": map[string]interface{}{
 							"type":        "number",
 							"format":      "double",
-							"description": "Ranking score used to order results. Without tag_boost, range is [0, 1]. With tag_boost, range is [0, 1 + tag_boost] since the boost is additive. In vector mode: min-max normalized cosine. In hybrid mode: blended normalized cosine + BM25. In keyword mode: normalized BM25. ILIKE fallback results (keyword mode, numeric tokens) return a fixed relevance of 1.0 since fallback matches are unranked.",
+							"description": "Ranking score used to order results. Without tag_boost, range is [0, 1]. With tag_boost, range is [0, 1 + tag_boost] since the boost is additive; recency_weight and importance_weight widen it the same way. In vector mode: min-max normalized cosine. In hybrid mode: blended normalized cosine + BM25. In keyword mode: normalized BM25. ILIKE fallback results (keyword mode, numeric tokens) return a fixed relevance of 1.0 since fallback matches are unranked.",
+						},
+						"explanation": map[string]interface{}{
+							"$ref": "#/components/schemas/Explanation",
+						},
+						"snippet": map[string]interface{}{
+							"type":        "string",
+							"description": "For a long episode embedded in chunks, the chunk a semantic search matched best",
+						},
+					},
+				},
+				"Explanation": map[string]interface{}{
+					"type":        "object",
+					"description": "Set on search results when explain is requested: the parts relevance was computed from. Parts that didn't apply are omitted.",
+					"properties": map[string]interface{}{
+						"bm25_score":            map[string]interface{}{"type": "number", "format": "double", "description": "Raw BM25 score"},
+						"similarity":            map[string]interface{}{"type": "number", "format": "double", "description": "Raw cosine similarity"},
+						"bm25_normalized":       map[string]interface{}{"type": "number", "format": "double", "description": "BM25 min-max normalized over the scored results"},
+						"similarity_normalized": map[string]interface{}{"type": "number", "format": "double", "description": "Similarity min-max normalized over the scored results"},
+						"alpha":                 map[string]interface{}{"type": "number", "format": "double", "description": "Hybrid linear fusion's weight on similarity"},
+						"vector_rank":           map[string]interface{}{"type": "integer", "description": "Rank among hybrid's vector candidates"},
+						"keyword_rank":          map[string]interface{}{"type": "integer", "description": "Rank among hybrid's keyword matches"},
+						"tag_match_ratio":       map[string]interface{}{"type": "number", "format": "double", "description": "Share of the boosted tags the episode has"},
+						"tag_boost":             map[string]interface{}{"type": "number", "format": "double", "description": "tag_boost × tag_match_ratio, added to relevance"},
+						"recency":               map[string]interface{}{"type": "number", "format": "double", "description": "Recency term added to relevance"},
+						"importance":            map[string]interface{}{"type": "number", "format": "double", "description": "Importance term added to relevance"},
+						"fallback": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"ilike", "hybrid_to_keyword", "vector_to_chronological", "similar_to_keyword"},
+							"description": "The fallback the search took, if any",
 						},
 					},
 				},
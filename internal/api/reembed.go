@@ -11,19 +11,25 @@ import (
 	"time"
 
 	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/embedding"
+	"github.com/oscillatelabsllc/engram/internal/models"
 )
 
-// embeddingDimensions is the vector size Engram's schema stores (FLOAT[768])
-const embeddingDimensions = 768
-
-// reembedBatchSize is how many rows are fetched per keyset page
+// reembedBatchSize is how many rows are fetched per keyset page; each page is
+// embedded with one GenerateBatch call, chunked by the embedder's batch size
 const reembedBatchSize = 64
 
+// maxReembedSweeps bounds the passes of one re-embed job. Pages are keyed
+// on id, so an episode written mid-pass behind the cursor is only found by
+// another pass.
+const maxReembedSweeps = 5
+
 // ReembedStatus reports the state of the current or most recent re-embed job
 type ReembedStatus struct {
 	Running    bool       `json:"running"`
 	Force      bool       `json:"force,omitempty"`
 	Model      string     `json:"model,omitempty"`
+	Space      string     `json:"space,omitempty"` // embedding space being filled; "" = primary column
 	Total      int        `json:"total"`
 	Done       int        `json:"done"`
 	Failed     int        `json:"failed"`
@@ -34,31 +40,49 @@ type ReembedStatus struct {
 
 // handleStartReembed launches an async re-embed pass over episodes. Default
 // mode regenerates only stale rows (missing embedding or stamped with a
-// different model); {"force": true} regenerates everything.
+// different model); {"force": true} regenerates everything. {"model": "..."}
+// fills that model's embedding space instead of the active model's — the
+// backfill half of a zero-downtime model swap.
 func (s *Server) handleStartReembed(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Force bool `json:"force"`
+		Force bool   `json:"force"`
+		Model string `json:"model"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
 		errorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
 
-	model := s.embedder.Model()
+	embedder, err := s.embedderForModel(req.Model)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	model := embedder.Model()
+	space := ""
+	if _, ok := s.store.LookupEmbeddingSpace(model); ok {
+		space = model
+	}
 
 	// Probe before touching any rows: fail fast if the endpoint is down or
-	// the configured model produces the wrong dimensionality
+	// the model produces the wrong dimensionality
 	probeCtx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
 	defer cancel()
-	probe, err := s.embedder.Generate(probeCtx, "engram re-embed dimension probe")
+	probe, err := embedder.Generate(probeCtx, "engram re-embed dimension probe")
 	if err != nil {
 		errorResponse(w, http.StatusBadGateway, "embedding endpoint unavailable: "+err.Error())
 		return
 	}
-	if len(probe) != embeddingDimensions {
-		errorResponse(w, http.StatusBadRequest, fmt.Sprintf(
-			"model %q produces %d-dimensional embeddings but the schema requires %d — choose a %d-dim model",
-			model, len(probe), embeddingDimensions, embeddingDimensions))
+	dims := s.store.SpaceDimensions(model)
+	if len(probe) != dims {
+		msg := fmt.Sprintf(
+			"model %q produces %d-dimensional embeddings but the store requires %d — choose a %d-dim model or run `engram migrate-dimensions --dimensions %d`",
+			model, len(probe), dims, dims, len(probe))
+		if space != "" {
+			msg = fmt.Sprintf("model %q produces %d-dimensional embeddings but its embedding space stores %d — drop and recreate the space",
+				model, len(probe), dims)
+		}
+		errorResponse(w, http.StatusBadRequest, msg)
 		return
 	}
 
@@ -87,6 +111,7 @@ func (s *Server) handleStartReembed(w http.ResponseWriter, r *http.Request) {
 		Running:   true,
 		Force:     req.Force,
 		Model:     model,
+		Space:     space,
 		Total:     counts.Total(),
 		StartedAt: &now,
 	}
@@ -95,7 +120,7 @@ func (s *Server) handleStartReembed(w http.ResponseWriter, r *http.Request) {
 	s.reembedCancel = workerCancel
 	s.reembedMu.Unlock()
 
-	go s.runReembed(workerCtx, model, req.Force)
+	go s.runReembed(workerCtx, embedder, req.Force)
 
 	successResponse(w, map[string]interface{}{
 		"success": true,
@@ -106,13 +131,19 @@ func (s *Server) handleStartReembed(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleGetReembed reports job progress plus current staleness counts
+// handleGetReembed reports job progress plus current staleness counts for
+// the active model, or for ?model= when given
 func (s *Server) handleGetReembed(w http.ResponseWriter, r *http.Request) {
 	s.reembedMu.Lock()
 	status := s.reembed
 	s.reembedMu.Unlock()
 
-	counts, err := s.store.CountReembedTargets(r.Context(), s.embedder.Model(), false)
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		model = s.embedder.Model()
+	}
+
+	counts, err := s.store.CountReembedTargets(r.Context(), model, false)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "failed to count stale embeddings: "+err.Error())
 		return
@@ -120,27 +151,39 @@ func (s *Server) handleGetReembed(w http.ResponseWriter, r *http.Request) {
 
 	successResponse(w, map[string]interface{}{
 		"job":              status,
-		"model":            s.embedder.Model(),
+		"model":            model,
 		"stale_embeddings": counts,
 	})
 }
 
-// runReembed walks the episodes table and regenerates vectors. Failures are
-// counted and skipped — keyset pagination guarantees forward progress, and a
-// later run retries anything still stale.
-func (s *Server) runReembed(ctx context.Context, model string, force bool) {
-	tables := []struct {
-		name   string
-		list   func(ctx context.Context, afterID string, limit int) ([]db.ReembedItem, error)
-		update func(ctx context.Context, id string, emb []float32) error
-	}{
+// reembedTable is a table the re-embed job walks: list pages its targets,
+// update stores a new vector and chunks for one row
+type reembedTable struct {
+	name   string
+	list   func(ctx context.Context, afterID string, limit int) ([]db.ReembedItem, error)
+	update func(ctx context.Context, id string, emb []float32, chunks []models.Chunk) error
+}
+
+// runReembed walks the episodes table and regenerates vectors with embedder,
+// writing them wherever its model's vectors live (see
+// db.Store.UpdateEpisodeEmbedding). Failures are counted and skipped — keyset
+// pagination guarantees forward progress, and a later run retries anything
+// still stale. Without force, the walk is repeated while it keeps finding
+// work, so episodes written behind the cursor during a backfill get their
+// vector before the space can be activated.
+func (s *Server) runReembed(ctx context.Context, embedder Embedder, force bool) {
+	model := embedder.Model()
+	tables := []reembedTable{
 		{
 			name: "episodes",
 			list: func(ctx context.Context, afterID string, limit int) ([]db.ReembedItem, error) {
 				return s.store.ListEpisodesForReembed(ctx, model, afterID, limit, force)
 			},
-			update: func(ctx context.Context, id string, emb []float32) error {
-				return s.store.UpdateEpisodeEmbedding(ctx, id, emb, model)
+			update: func(ctx context.Context, id string, emb []float32, chunks []models.Chunk) error {
+				if err := s.store.UpdateEpisodeEmbedding(ctx, id, emb, model); err != nil {
+					return err
+				}
+				return s.store.ReplaceEpisodeChunks(ctx, id, model, chunks)
 			},
 		},
 	}
@@ -149,45 +192,25 @@ func (s *Server) runReembed(ctx context.Context, model string, force bool) {
 
 tableLoop:
 	for _, table := range tables {
-		afterID := ""
-		for {
-			items, err := table.list(ctx, afterID, reembedBatchSize)
-			if err != nil {
-				jobErr = fmt.Errorf("listing %s: %w", table.name, err)
-				break tableLoop
-			}
-			if len(items) == 0 {
-				break
-			}
-
-			for _, item := range items {
-				if ctx.Err() != nil {
-					jobErr = ctx.Err()
+		for sweep := 1; ; sweep++ {
+			if sweep > 1 {
+				counts, err := s.store.CountReembedTargets(ctx, model, false)
+				if err != nil {
+					jobErr = fmt.Errorf("counting %s: %w", table.name, err)
 					break tableLoop
 				}
-				afterID = item.ID
-
-				embedCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-				emb, err := s.embedder.Generate(embedCtx, item.Text)
-				cancel()
-
-				ok := err == nil && len(emb) == embeddingDimensions
-				if ok {
-					if err := table.update(ctx, item.ID, emb); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: re-embed update failed for %s %s: %v\n", table.name, item.ID, err)
-						ok = false
-					}
-				} else if err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: re-embed generation failed for %s %s: %v\n", table.name, item.ID, err)
-				}
-
 				s.reembedMu.Lock()
-				s.reembed.Done++
-				if !ok {
-					s.reembed.Failed++
-				}
+				s.reembed.Total = s.reembed.Done + counts.Total()
 				s.reembedMu.Unlock()
 			}
+			updated, err := s.reembedSweep(ctx, embedder, table)
+			if err != nil {
+				jobErr = err
+				break tableLoop
+			}
+			if force || updated == 0 || sweep == maxReembedSweeps {
+				break
+			}
 		}
 	}
 
@@ -206,6 +229,64 @@ tableLoop:
 		final.Done-final.Failed, final.Total, final.Failed, model)
 }
 
+// reembedSweep pages once through table's targets, embedding each page with
+// one batched call. Returns the number of rows updated.
+func (s *Server) reembedSweep(ctx context.Context, embedder Embedder, table reembedTable) (int, error) {
+	dims := s.store.SpaceDimensions(embedder.Model())
+	afterID := ""
+	updated := 0
+	for {
+		items, err := table.list(ctx, afterID, reembedBatchSize)
+		if err != nil {
+			return updated, fmt.Errorf("listing %s: %w", table.name, err)
+		}
+		if len(items) == 0 {
+			return updated, nil
+		}
+
+		if ctx.Err() != nil {
+			return updated, ctx.Err()
+		}
+		afterID = items[len(items)-1].ID
+
+		// One batched call per page, long texts as their chunks; a failed
+		// item is nil and counted below without holding back the rest of
+		// the page
+		texts := make([]string, len(items))
+		for i, item := range items {
+			texts[i] = item.Text
+		}
+		embs, chunks, genErr := s.chunker.EmbedBatch(ctx, embedder, texts)
+		if ctx.Err() != nil {
+			return updated, ctx.Err()
+		}
+
+		failed := 0
+		for i, item := range items {
+			emb := embs[i]
+			ok := emb != nil && len(emb) == dims
+			if ok {
+				if err := table.update(ctx, item.ID, emb, chunks[i]); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: re-embed update failed for %s %s: %v\n", table.name, item.ID, err)
+					ok = false
+				}
+			} else if emb == nil {
+				fmt.Fprintf(os.Stderr, "Warning: re-embed generation failed for %s %s: %v\n",
+					table.name, item.ID, embedding.ItemError(genErr, i))
+			}
+			if !ok {
+				failed++
+			}
+		}
+		updated += len(items) - failed
+
+		s.reembedMu.Lock()
+		s.reembed.Done += len(items)
+		s.reembed.Failed += failed
+		s.reembedMu.Unlock()
+	}
+}
+
 // stopReembed cancels a running re-embed job, if any
 func (s *Server) stopReembed() {
 	s.reembedMu.Lock()
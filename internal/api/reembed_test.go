@@ -37,6 +37,20 @@ func (f *fakeEmbedder) Generate(ctx context.Context, text string) ([]float32, er
 	return emb, nil
 }
 
+// GenerateBatch embeds item by item, so calls counts texts, not requests
+func (f *fakeEmbedder) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embs := make([][]float32, len(texts))
+	var firstErr error
+	for i, text := range texts {
+		emb, err := f.Generate(ctx, text)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		embs[i] = emb
+	}
+	return embs, firstErr
+}
+
 func (f *fakeEmbedder) Model() string { return f.model }
 
 func setupReembedServer(t *testing.T, embedder Embedder) (*Server, *db.Store) {
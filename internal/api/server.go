@@ -16,6 +16,7 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/embedding"
 	"github.com/oscillatelabsllc/engram/internal/health"
 	"github.com/oscillatelabsllc/engram/internal/models"
 )
@@ -23,6 +24,10 @@ import (
 // Embedder generates vector embeddings for text
 type Embedder interface {
 	Generate(ctx context.Context, text string) ([]float32, error)
+	// GenerateBatch embeds texts in as few requests as possible. The result
+	// is index-aligned with texts; failed items are nil and described by the
+	// error (see embedding.BatchError).
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
 	// Model returns the embedding model name, used to stamp provenance
 	Model() string
 }
@@ -32,11 +37,25 @@ type EmbeddingHealth interface {
 	Status() health.EmbeddingStatus
 }
 
+// EmbeddingQueue wakes the background worker that embeds queued episodes
+type EmbeddingQueue interface {
+	Kick()
+}
+
+// EmbeddingCache reports the embedding cache's hit and miss counts
+type EmbeddingCache interface {
+	Stats() embedding.CacheStats
+}
+
 // Server implements the HTTP API server for Engram
 type Server struct {
 	store           *db.Store
 	embedder        Embedder
 	embeddingHealth EmbeddingHealth
+	embeddingQueue  EmbeddingQueue
+	embeddingCache  EmbeddingCache
+	chunker         embedding.Chunker
+	embedderFor     func(model string) Embedder
 	router          *chi.Mux
 	port            string
 
@@ -51,6 +70,11 @@ type Server struct {
 	reembedMu     sync.Mutex
 	reembed       ReembedStatus
 	reembedCancel context.CancelFunc
+
+	// Duplicate scan state (see duplicates.go)
+	dupMu     sync.Mutex
+	dupScan   DuplicateScanStatus
+	dupCancel context.CancelFunc
 }
 
 // NewServer creates a new HTTP API server
@@ -72,6 +96,48 @@ func (s *Server) SetEmbeddingHealth(h EmbeddingHealth) {
 	s.embeddingHealth = h
 }
 
+// SetEmbeddingQueue attaches the background embedding worker so bulk writes
+// can wake it instead of waiting for its next poll. Optional.
+func (s *Server) SetEmbeddingQueue(q EmbeddingQueue) {
+	s.embeddingQueue = q
+}
+
+// SetEmbeddingCache attaches the embedding cache whose counters /status
+// reports. Optional.
+func (s *Server) SetEmbeddingCache(c EmbeddingCache) {
+	s.embeddingCache = c
+}
+
+// SetChunker sets how long content is split for embedding. Optional: the
+// zero Chunker embeds content whole.
+func (s *Server) SetChunker(c embedding.Chunker) {
+	s.chunker = c
+}
+
+// SetEmbedderFactory supplies embedders pinned to a specific model, used to
+// backfill and register embedding spaces for models other than the active
+// one. Optional: without it, only the configured embedder's model can be
+// re-embedded or registered.
+func (s *Server) SetEmbedderFactory(f func(model string) Embedder) {
+	s.embedderFor = f
+}
+
+// embedderForModel returns an embedder for model ("" = the active one).
+// With a factory the result is pinned, so a long-running job keeps using its
+// model even if the active embedding space is switched underneath it.
+func (s *Server) embedderForModel(model string) (Embedder, error) {
+	if model == "" {
+		model = s.embedder.Model()
+	}
+	if s.embedderFor != nil {
+		return s.embedderFor(model), nil
+	}
+	if model != s.embedder.Model() {
+		return nil, fmt.Errorf("no embedder available for model %q (only %q is configured)", model, s.embedder.Model())
+	}
+	return s.embedder, nil
+}
+
 // setupRouter configures all HTTP routes
 func (s *Server) setupRouter() {
 	r := chi.NewRouter()
@@ -103,20 +169,43 @@ func (s *Server) setupRouter() {
 	// NO TIMEOUT MIDDLEWARE - SSE connections must stay open indefinitely
 	// This gets mounted dynamically via AddMCPServer
 
-	// API routes WITH timeout middleware (these are short-lived REST requests)
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(middleware.Timeout(60 * time.Second)) // Only apply timeout to API routes
-
-		// Memory operations
-		r.Post("/memory", s.handleAddMemory)
-		r.Get("/memory/search", s.handleSearch)
-		r.Get("/memory/episodes", s.handleGetEpisodes)
-		r.Put("/memory/episodes/{id}", s.handleUpdateEpisode)
-		r.Get("/status", s.handleGetStatus)
-
-		// Admin operations
-		r.Post("/admin/reembed", s.handleStartReembed)
-		r.Get("/admin/reembed", s.handleGetReembed)
+		// API routes WITH timeout middleware (these are short-lived REST requests)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Timeout(60 * time.Second))
+
+			// Memory operations
+			r.Post("/memory", s.handleAddMemory)
+			r.Post("/memory/batch", s.handleAddMemoryBatch)
+			r.Get("/memory/search", s.handleSearch)
+			r.Get("/memory/episodes", s.handleGetEpisodes)
+			r.Get("/memory/by-key", s.handleGetByKey)
+			r.Get("/memory/episodes/{id}", s.handleGetEpisode)
+			r.Post("/memory/episodes/lookup", s.handleLookupEpisodes)
+			r.Put("/memory/episodes/{id}", s.handleUpdateEpisode)
+			r.Get("/memory/episodes/{id}/versions", s.handleListEpisodeVersions)
+			r.Post("/memory/episodes/{id}/versions/{version}/restore", s.handleRestoreEpisodeVersion)
+			r.Delete("/memory/episodes/{id}", s.handleDeleteEpisode)
+			r.Post("/memory/episodes/delete", s.handleDeleteEpisodes)
+			r.Get("/status", s.handleGetStatus)
+
+			// Admin operations
+			r.Post("/admin/reembed", s.handleStartReembed)
+			r.Get("/admin/reembed", s.handleGetReembed)
+			r.Get("/admin/embedding-spaces", s.handleListEmbeddingSpaces)
+			r.Post("/admin/embedding-spaces", s.handleCreateEmbeddingSpace)
+			r.Post("/admin/embedding-spaces/activate", s.handleActivateEmbeddingSpace)
+			r.Delete("/admin/embedding-spaces", s.handleDropEmbeddingSpace)
+			r.Get("/admin/deletions", s.handleListDeletions)
+			r.Post("/admin/duplicates", s.handleStartDuplicateScan)
+			r.Get("/admin/duplicates", s.handleGetDuplicateScan)
+			r.Post("/admin/duplicates/consolidate", s.handleConsolidateDuplicates)
+		})
+
+		// Export and import stream the whole database, which can take
+		// longer than any fixed timeout
+		r.Get("/admin/export", s.handleExport)
+		r.Post("/admin/import", s.handleImport)
 	})
 
 	s.router = r
@@ -168,6 +257,7 @@ func (s *Server) Addr() string {
 // data-durability problem, not a cosmetic one.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.stopReembed()
+	s.stopDuplicateScan()
 	s.mu.Lock()
 	srv := s.httpServer
 	s.mu.Unlock()
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+)
+
+// EmbeddingSpaceInfo describes one searchable vector space for the admin API
+type EmbeddingSpaceInfo struct {
+	Model      string     `json:"model"` // empty for the primary column
+	Primary    bool       `json:"primary,omitempty"`
+	Dimensions int        `json:"dimensions"`
+	Active     bool       `json:"active"`
+	Missing    int        `json:"missing"` // live episodes without a vector in this space
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// handleListEmbeddingSpaces reports the primary column plus every registered
+// space, with coverage so an operator can tell when a backfill is complete
+func (s *Server) handleListEmbeddingSpaces(w http.ResponseWriter, r *http.Request) {
+	active := s.store.ActiveEmbeddingModel()
+
+	primary := EmbeddingSpaceInfo{
+		Primary:    true,
+		Dimensions: s.store.EmbeddingDimensions(),
+		Active:     active == "",
+	}
+	// While it is searched, the primary column's coverage is measured against
+	// the configured model; once a space is active it is only a fallback
+	if active == "" {
+		primary.Model = s.embedder.Model()
+		counts, err := s.store.CountReembedTargets(r.Context(), primary.Model, false)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "failed to count embeddings: "+err.Error())
+			return
+		}
+		primary.Missing = counts.Total()
+	}
+	spaces := []EmbeddingSpaceInfo{primary}
+
+	for _, sp := range s.store.EmbeddingSpaces() {
+		counts, err := s.store.CountReembedTargets(r.Context(), sp.Model, false)
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "failed to count embeddings: "+err.Error())
+			return
+		}
+		createdAt := sp.CreatedAt
+		spaces = append(spaces, EmbeddingSpaceInfo{
+			Model:      sp.Model,
+			Dimensions: sp.Dimensions,
+			Active:     sp.Model == active,
+			Missing:    counts.Total(),
+			CreatedAt:  &createdAt,
+		})
+	}
+
+	successResponse(w, map[string]interface{}{
+		"active_space": active,
+		"spaces":       spaces,
+	})
+}
+
+// handleCreateEmbeddingSpace registers a vector table for a model. The
+// model is probed once so the space is sized from what it actually produces.
+func (s *Server) handleCreateEmbeddingSpace(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		errorResponse(w, http.StatusBadRequest, "model is required")
+		return
+	}
+	if _, ok := s.store.LookupEmbeddingSpace(req.Model); ok {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf("embedding space already exists: %s", req.Model))
+		return
+	}
+
+	// While the primary column is searched, the configured model's vectors
+	// must keep landing there; a space for it would divert new writes away
+	// from what search reads
+	if s.store.ActiveEmbeddingModel() == "" && req.Model == s.embedder.Model() {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf(
+			"%s is the model the primary embedding column is searched with; create a space for the replacement model instead", req.Model))
+		return
+	}
+
+	embedder, err := s.embedderForModel(req.Model)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	probe, err := embedder.Generate(probeCtx, "engram embedding space dimension probe")
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, "embedding endpoint unavailable: "+err.Error())
+		return
+	}
+
+	space, err := s.store.CreateEmbeddingSpace(r.Context(), req.Model, len(probe))
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to create embedding space: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success": true,
+		"space":   space,
+		"message": fmt.Sprintf("embedding space created; backfill it with POST /api/v1/admin/reembed {\"model\": %q}", req.Model),
+	})
+}
+
+// handleActivateEmbeddingSpace switches search (and new writes) to a space.
+// Activation is refused while live episodes still lack a vector in the
+// target space, unless forced — searching a half-filled space silently
+// hides whatever has not been backfilled yet.
+func (s *Server) handleActivateEmbeddingSpace(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model   string `json:"model"`
+		Primary bool   `json:"primary"`
+		Force   bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if (req.Model == "") == !req.Primary {
+		errorResponse(w, http.StatusBadRequest, `specify exactly one of "model" or "primary": true`)
+		return
+	}
+
+	if req.Model != "" {
+		if _, ok := s.store.LookupEmbeddingSpace(req.Model); !ok {
+			errorResponse(w, http.StatusNotFound, fmt.Sprintf("embedding space not found: %s", req.Model))
+			return
+		}
+		if _, err := s.embedderForModel(req.Model); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	err := s.store.ActivateEmbeddingSpace(r.Context(), req.Model, req.Force)
+	if errors.Is(err, db.ErrSpaceIncomplete) {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf(
+			"%v; finish the backfill (POST /api/v1/admin/reembed {\"model\": %q}) or pass \"force\": true",
+			err, req.Model))
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to activate embedding space: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success":      true,
+		"active_space": req.Model,
+		"primary":      req.Primary,
+	})
+}
+
+// handleDropEmbeddingSpace deletes an inactive space and all its vectors
+func (s *Server) handleDropEmbeddingSpace(w http.ResponseWriter, r *http.Request) {
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		errorResponse(w, http.StatusBadRequest, "model query parameter is required")
+		return
+	}
+	if _, ok := s.store.LookupEmbeddingSpace(model); !ok {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("embedding space not found: %s", model))
+		return
+	}
+	if s.store.ActiveEmbeddingModel() == model {
+		errorResponse(w, http.StatusConflict, "cannot drop the active embedding space; activate another one first")
+		return
+	}
+
+	if err := s.store.DropEmbeddingSpace(r.Context(), model); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "failed to drop embedding space: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("embedding space %s dropped", model),
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEmbeddingSpaceSwap(t *testing.T) {
+	live := &fakeEmbedder{model: "old-model", dims: 768}
+	next := &fakeEmbedder{model: "new-model", dims: 1024}
+	s, store := setupReembedServer(t, live)
+	s.SetEmbedderFactory(func(model string) Embedder {
+		if model == next.model {
+			return next
+		}
+		return live
+	})
+	ctx := context.Background()
+
+	if err := store.InsertEpisode(ctx, &models.Episode{
+		Content: "existing", Source: "test", Embedding: make([]float32, 768), EmbeddingModel: "old-model",
+	}); err != nil {
+		t.Fatalf("Failed to insert episode: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("space for the live primary model is refused", func(t *testing.T) {
+		if w := do("POST", "/api/v1/admin/embedding-spaces", `{"model": "old-model"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("create sizes the space from the model", func(t *testing.T) {
+		w := do("POST", "/api/v1/admin/embedding-spaces", `{"model": "new-model"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		sp, ok := store.LookupEmbeddingSpace("new-model")
+		if !ok || sp.Dimensions != 1024 {
+			t.Errorf("Expected registered 1024-dim space, got %+v (ok=%v)", sp, ok)
+		}
+	})
+
+	t.Run("activation refused before backfill", func(t *testing.T) {
+		if w := do("POST", "/api/v1/admin/embedding-spaces/activate", `{"model": "new-model"}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409 for an unfilled space, got %d: %s", w.Code, w.Body.String())
+		}
+		if store.ActiveEmbeddingModel() != "" {
+			t.Error("Space should not be active after a refused activation")
+		}
+	})
+
+	t.Run("re-embed with model backfills the space", func(t *testing.T) {
+		w := do("POST", "/api/v1/admin/reembed", `{"model": "new-model"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		status := waitForReembed(t, s)
+		if status.Space != "new-model" || status.Done != 1 || status.Failed != 0 {
+			t.Errorf("Expected 1 row backfilled into new-model, got %+v", status)
+		}
+	})
+
+	t.Run("activation flips the searched space", func(t *testing.T) {
+		w := do("POST", "/api/v1/admin/embedding-spaces/activate", `{"model": "new-model"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if store.ActiveEmbeddingModel() != "new-model" {
+			t.Errorf("Expected new-model active, got %q", store.ActiveEmbeddingModel())
+		}
+	})
+
+	t.Run("list reports coverage and the active space", func(t *testing.T) {
+		w := do("GET", "/api/v1/admin/embedding-spaces", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var body struct {
+			ActiveSpace string               `json:"active_space"`
+			Spaces      []EmbeddingSpaceInfo `json:"spaces"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body.ActiveSpace != "new-model" || len(body.Spaces) != 2 {
+			t.Fatalf("Unexpected listing: %+v", body)
+		}
+		sp := body.Spaces[1]
+		if !sp.Active || sp.Missing != 0 || sp.Dimensions != 1024 {
+			t.Errorf("Expected active, fully covered 1024-dim space, got %+v", sp)
+		}
+	})
+
+	t.Run("active space cannot be dropped", func(t *testing.T) {
+		if w := do("DELETE", "/api/v1/admin/embedding-spaces?model=new-model", ""); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("activate requires exactly one target", func(t *testing.T) {
+		if w := do("POST", "/api/v1/admin/embedding-spaces/activate", `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// embedEdit embeds an edit's new content into params, as handleAddMemory
+// embeds a new episode. On failure params carries no vector and the store
+// queues the episode for background embedding.
+func (s *Server) embedEdit(ctx context.Context, params *models.UpdateParams) {
+	embedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	emb, chunks, err := s.chunker.Embed(embedCtx, s.embedder, *params.Content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to generate embedding for edit: %v\n", err)
+		return
+	}
+	params.Embedding = emb
+	params.Chunks = chunks
+	params.EmbeddingModel = s.embedder.Model()
+}
+
+// handleListEpisodeVersions returns an episode's prior versions, newest first
+func (s *Server) handleListEpisodeVersions(w http.ResponseWriter, r *http.Request) {
+	episodeID := chi.URLParam(r, "id")
+	episodes, err := s.store.GetEpisodesByID(r.Context(), []string{episodeID}, false)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to get episode: "+err.Error())
+		return
+	}
+	if len(episodes) == 0 {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("episode not found: %s", episodeID))
+		return
+	}
+
+	versions, err := s.store.ListEpisodeVersions(r.Context(), episodeID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to list versions: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"episode_id": episodeID,
+		"versions":   versions,
+		"count":      len(versions),
+	})
+}
+
+// handleRestoreEpisodeVersion makes a prior version current again. The
+// restore is an edit: the text it replaces becomes the newest version, and
+// the content is re-embedded.
+func (s *Server) handleRestoreEpisodeVersion(w http.ResponseWriter, r *http.Request) {
+	episodeID := chi.URLParam(r, "id")
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		errorResponse(w, http.StatusBadRequest, "version must be a positive integer")
+		return
+	}
+
+	v, err := s.store.GetEpisodeVersion(r.Context(), episodeID, version)
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	params := models.UpdateParams{Content: &v.Content, Name: &v.Name}
+	s.embedEdit(r.Context(), &params)
+	if err := s.store.UpdateEpisode(r.Context(), episodeID, params); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to restore version: "+err.Error())
+		return
+	}
+
+	episode, err := s.store.GetEpisode(r.Context(), episodeID)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to get episode: "+err.Error())
+		return
+	}
+
+	successResponse(w, map[string]interface{}{
+		"success":  true,
+		"message":  fmt.Sprintf("Restored version %d", version),
+		"episode":  episode,
+		"embedded": len(params.Embedding) > 0,
+	})
+}
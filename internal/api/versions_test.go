@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEpisodeVersionEndpoints(t *testing.T) {
+	s, store := setupReembedServer(t, &fakeEmbedder{model: "test-model", dims: 768})
+	ctx := context.Background()
+
+	ep := &models.Episode{Content: "standup is at 9:30", Source: "test"}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert episode: %v", err)
+	}
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	code, resp := do("PUT", "/api/v1/memory/episodes/"+ep.ID, `{"content":"standup is at 10:00"}`)
+	if code != http.StatusOK || resp["embedded"] != true {
+		t.Fatalf("Expected the edit embedded, got %d %v", code, resp)
+	}
+	got, err := store.GetEpisode(ctx, ep.ID)
+	if err != nil || got.Content != "standup is at 10:00" {
+		t.Fatalf("Expected the content edited in place, got %+v, %v", got, err)
+	}
+
+	code, resp = do("GET", "/api/v1/memory/episodes/"+ep.ID+"/versions", "")
+	versions, _ := resp["versions"].([]interface{})
+	if code != http.StatusOK || len(versions) != 1 {
+		t.Fatalf("Expected one version, got %d %v", code, resp)
+	}
+	if v := versions[0].(map[string]interface{}); v["content"] != "standup is at 9:30" {
+		t.Errorf("Expected the original content as a version, got %v", v)
+	}
+
+	code, resp = do("POST", "/api/v1/memory/episodes/"+ep.ID+"/versions/1/restore", "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected the restore to succeed, got %d %v", code, resp)
+	}
+	if got, _ := store.GetEpisode(ctx, ep.ID); got.Content != "standup is at 9:30" {
+		t.Errorf("Expected the original content restored, got %q", got.Content)
+	}
+	if versions, _ := store.ListEpisodeVersions(ctx, ep.ID); len(versions) != 2 || versions[0].Content != "standup is at 10:00" {
+		t.Errorf("Expected the replaced content kept as version 2, got %+v", versions)
+	}
+
+	if code, _ := do("POST", "/api/v1/memory/episodes/"+ep.ID+"/versions/7/restore", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing version, got %d", code)
+	}
+	if code, _ := do("GET", "/api/v1/memory/episodes/missing/versions", ""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing episode, got %d", code)
+	}
+	if code, _ := do("PUT", "/api/v1/memory/episodes/"+ep.ID, `{"content":""}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty content, got %d", code)
+	}
+	if code, _ := do("PUT", "/api/v1/memory/episodes/missing", `{"content":"anything"}`); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for editing a missing episode, got %d", code)
+	}
+}
@@ -0,0 +1,225 @@
+// Package archive moves episodes in and out of a store in portable file
+// formats, so a memory database can be carried between machines and DuckDB
+// versions without copying the .duckdb file itself.
+package archive
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// ErrInvalidArchive is returned when an archive can't be read: a malformed
+// record, an unreadable Parquet file, or an episode without an ID. Any other
+// import error is the store's.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Format is an archive file format
+type Format string
+
+const (
+	// JSONL is one JSON episode per line; streams in both directions
+	JSONL Format = "jsonl"
+	// Parquet is a columnar file written and read by DuckDB
+	Parquet Format = "parquet"
+)
+
+// importBatchSize is how many episodes are committed per import transaction.
+// Import skips IDs already present, so an interrupted import is resumed by
+// running it again.
+const importBatchSize = 500
+
+// ParseFormat validates a format name. An empty name selects JSONL.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "jsonl", "ndjson":
+		return JSONL, nil
+	case "parquet":
+		return Parquet, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (use jsonl or parquet)", name)
+	}
+}
+
+// FormatForPath infers the format from a file extension, defaulting to JSONL
+func FormatForPath(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".parquet") {
+		return Parquet
+	}
+	return JSONL
+}
+
+// Export writes every episode to w. Parquet is staged in a temporary file,
+// since DuckDB writes it by path.
+func Export(ctx context.Context, store *db.Store, w io.Writer, format Format, withEmbeddings bool) (int, error) {
+	if format == Parquet {
+		return withTempFile(func(path string) (int, error) {
+			n, err := store.ExportParquet(ctx, path, withEmbeddings)
+			if err != nil {
+				return 0, err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return 0, fmt.Errorf("failed to open export: %w", err)
+			}
+			defer f.Close()
+			if _, err := io.Copy(w, f); err != nil {
+				return 0, fmt.Errorf("failed to write export: %w", err)
+			}
+			return n, nil
+		})
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	count := 0
+	err := store.ExportEpisodes(ctx, withEmbeddings, func(ep *models.Episode) error {
+		count++
+		return enc.Encode(ep)
+	})
+	if err != nil {
+		return count, err
+	}
+	if err := bw.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write export: %w", err)
+	}
+	return count, nil
+}
+
+// ExportFile writes every episode to a file at path
+func ExportFile(ctx context.Context, store *db.Store, path string, format Format, withEmbeddings bool) (int, error) {
+	if format == Parquet {
+		return store.ExportParquet(ctx, path, withEmbeddings)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	n, err := Export(ctx, store, f, format, withEmbeddings)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write export file: %w", cerr)
+	}
+	return n, err
+}
+
+// Import reads episodes from r and stores the ones not already present or
+// permanently deleted.
+// Parquet input is staged in a temporary file, since DuckDB reads it by path.
+func Import(ctx context.Context, store *db.Store, r io.Reader, format Format) (db.ImportResult, error) {
+	if format == Parquet {
+		var result db.ImportResult
+		_, err := withTempFile(func(path string) (int, error) {
+			f, err := os.Create(path)
+			if err != nil {
+				return 0, fmt.Errorf("failed to stage import: %w", err)
+			}
+			_, err = io.Copy(f, r)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return 0, fmt.Errorf("failed to stage import: %w", err)
+			}
+			result, err = ImportFile(ctx, store, path, Parquet)
+			return 0, err
+		})
+		return result, err
+	}
+
+	b := newBatcher(ctx, store)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for line := 1; ; line++ {
+		var ep models.Episode
+		if err := dec.Decode(&ep); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return b.result, fmt.Errorf("%w: record %d: %v", ErrInvalidArchive, line, err)
+		}
+		if err := b.add(&ep); err != nil {
+			return b.result, err
+		}
+	}
+	return b.result, b.flush()
+}
+
+// ImportFile imports the archive at path
+func ImportFile(ctx context.Context, store *db.Store, path string, format Format) (db.ImportResult, error) {
+	if format == Parquet {
+		b := newBatcher(ctx, store)
+		var addErr error
+		err := store.ReadParquetEpisodes(ctx, path, func(ep *models.Episode) error {
+			addErr = b.add(ep)
+			return addErr
+		})
+		if err != nil {
+			// A failure not raised by storing the episodes is the file's
+			if addErr == nil {
+				err = fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			return b.result, err
+		}
+		return b.result, b.flush()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return db.ImportResult{}, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+	return Import(ctx, store, f, format)
+}
+
+// batcher groups episodes into import transactions
+type batcher struct {
+	ctx    context.Context
+	store  *db.Store
+	batch  []*models.Episode
+	result db.ImportResult
+}
+
+func newBatcher(ctx context.Context, store *db.Store) *batcher {
+	return &batcher{ctx: ctx, store: store}
+}
+
+func (b *batcher) add(ep *models.Episode) error {
+	if ep.ID == "" {
+		return fmt.Errorf("%w: episode without id (record %d)", ErrInvalidArchive, b.result.Inserted+b.result.Skipped+b.result.Erased+len(b.batch)+1)
+	}
+	ep.Similarity, ep.Relevance = nil, nil
+	b.batch = append(b.batch, ep)
+	if len(b.batch) >= importBatchSize {
+		return b.flush()
+	}
+	return nil
+}
+
+func (b *batcher) flush() error {
+	res, err := b.store.ImportEpisodes(b.ctx, b.batch)
+	b.batch = b.batch[:0]
+	if err != nil {
+		return err
+	}
+	b.result.Inserted += res.Inserted
+	b.result.Skipped += res.Skipped
+	b.result.Erased += res.Erased
+	return nil
+}
+
+// withTempFile runs fn with the path of a fresh temporary file, removed after
+func withTempFile(fn func(path string) (int, error)) (int, error) {
+	dir, err := os.MkdirTemp("", "engram-archive-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	return fn(filepath.Join(dir, "episodes.parquet"))
+}
@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{"": JSONL, "jsonl": JSONL, "NDJSON": JSONL, "parquet": Parquet}
+	for name, want := range cases {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if FormatForPath("backup.PARQUET") != Parquet || FormatForPath("backup.jsonl") != JSONL {
+		t.Error("FormatForPath did not infer from the extension")
+	}
+}
+
+func seedStore(t *testing.T) (*db.Store, []*models.Episode) {
+	t.Helper()
+	store, err := db.NewStore(t.TempDir() + "/source.duckdb")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	validAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	emb := make([]float32, 768)
+	emb[3] = 0.5
+	eps := []*models.Episode{
+		{
+			Content: "full", Name: "n", Source: "s", SourceModel: "sm", SourceDescription: "sd",
+			GroupID: "g", Tags: []string{"a", "b"}, ValidAt: &validAt, Metadata: `{"k":1}`,
+			Embedding: emb, EmbeddingModel: "test-model",
+		},
+		{Content: "expired, no vector", Source: "s", ExpiredAt: &expired},
+	}
+	for _, ep := range eps {
+		if err := store.InsertEpisode(context.Background(), ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+	return store, eps
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, eps := seedStore(t)
+
+	for _, format := range []Format{JSONL, Parquet} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := Export(ctx, source, &buf, format, true)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			if n != len(eps) {
+				t.Fatalf("Expected %d exported, got %d", len(eps), n)
+			}
+			archived := buf.Bytes()
+
+			target, err := db.NewStore(t.TempDir() + "/target.duckdb")
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			defer target.Close()
+
+			result, err := Import(ctx, target, bytes.NewReader(archived), format)
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if result.Inserted != len(eps) || result.Skipped != 0 {
+				t.Errorf("Expected %d inserted, got %+v", len(eps), result)
+			}
+
+			got, err := target.GetEpisode(ctx, eps[0].ID)
+			if err != nil {
+				t.Fatalf("Imported episode missing: %v", err)
+			}
+			if got.Content != "full" || got.GroupID != "g" || len(got.Tags) != 2 || got.Metadata != `{"k":1}` ||
+				got.ValidAt == nil || !got.ValidAt.Equal(*eps[0].ValidAt) || !got.CreatedAt.Equal(eps[0].CreatedAt.Truncate(time.Microsecond)) {
+				t.Errorf("Fields not preserved: %+v", got)
+			}
+			exp, err := target.GetEpisode(ctx, eps[1].ID)
+			if err != nil || exp.ExpiredAt == nil {
+				t.Errorf("Expired episode not preserved: %+v, %v", exp, err)
+			}
+
+			// The vector came along with its stamp; the vectorless one is queued
+			stale, err := target.CountReembedTargets(ctx, "test-model", false)
+			if err != nil {
+				t.Fatalf("CountReembedTargets failed: %v", err)
+			}
+			if stale.Episodes != 0 {
+				t.Errorf("Expected the exported vector to be current for test-model, got %d stale", stale.Episodes)
+			}
+			if q, _ := target.CountQueuedEmbeddings(ctx); q != 1 {
+				t.Errorf("Expected the episode without a vector queued, got %d", q)
+			}
+
+			again, err := Import(ctx, target, bytes.NewReader(archived), format)
+			if err != nil {
+				t.Fatalf("Re-import failed: %v", err)
+			}
+			if again.Inserted != 0 || again.Skipped != len(eps) {
+				t.Errorf("Re-import should skip everything, got %+v", again)
+			}
+		})
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	ctx := context.Background()
+	store, err := db.NewStore(t.TempDir() + "/target.duckdb")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for name, input := range map[string]string{
+		"malformed record": `{"id": "a", "content": "x", "source": "test"}` + "\n{not json\n",
+		"missing id":       `{"content": "x", "source": "test"}` + "\n",
+	} {
+		if _, err := Import(ctx, store, strings.NewReader(input), JSONL); !errors.Is(err, ErrInvalidArchive) {
+			t.Errorf("%s: expected ErrInvalidArchive, got %v", name, err)
+		}
+	}
+
+	// A store that can't take the episodes is not the archive's fault
+	store.Close()
+	_, err = Import(ctx, store, strings.NewReader(`{"id": "b", "content": "x", "source": "test"}`+"\n"), JSONL)
+	if err == nil || errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Expected a store error, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Long episodes are embedded as overlapping chunks (see embedding.Chunker).
+// The episode keeps one vector, the mean of its chunks', where every vector
+// lives; the chunks' own vectors are kept beside it, stamped with their
+// model, in a chunk table per vector table (chunkTable) — fixed-size, with
+// its own HNSW index, so search takes the nearest chunks from the index as
+// it does episode vectors. Semantic search scores an episode by the nearer
+// of its vector and its best chunk, and returns that chunk as the result's
+// snippet.
+//
+// There is no primary key: replacing an episode's chunks deletes and
+// re-inserts them in one transaction, which DuckDB's eager unique-constraint
+// checks reject.
+
+// chunkTable names the chunk table of a vector table
+func chunkTable(table string) string {
+	return table + "_chunks"
+}
+
+// chunkTableDDL creates the chunk table of a vector table of dims-sized
+// vectors
+func chunkTableDDL(table string, dims int) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (
+			episode_id VARCHAR NOT NULL,
+			embedding_model VARCHAR NOT NULL,
+			chunk_index INTEGER NOT NULL,
+			content VARCHAR NOT NULL,
+			embedding FLOAT[%[2]d] NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_%[1]s_episode_id ON %[1]s (episode_id);
+	`, chunkTable(table), dims)
+}
+
+// ensureChunkTables creates the chunk tables of the primary column and every
+// space, and moves the chunks of the single episode_chunks table older
+// releases kept, whose unsized vectors no index could serve, into them
+func (s *Store) ensureChunkTables(ctx context.Context) error {
+	tables := map[string]int{"episodes": s.dims}
+	for _, sp := range s.spaces {
+		tables[sp.table] = sp.Dimensions
+	}
+	for table, dims := range tables {
+		if _, err := s.db.ExecContext(ctx, chunkTableDDL(table, dims)); err != nil {
+			return fmt.Errorf("failed to create %s: %w", chunkTable(table), err)
+		}
+	}
+
+	var legacy int
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'episode_chunks'").Scan(&legacy); err != nil {
+		return fmt.Errorf("failed to look up legacy episode chunks: %w", err)
+	}
+	if legacy == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The primary column read the chunks of each episode's vector model, a
+	// space those of its own model
+	const copyChunks = `INSERT INTO %s (episode_id, embedding_model, chunk_index, content, embedding)
+		SELECT episode_id, embedding_model, chunk_index, content, CAST(embedding AS FLOAT[%d])
+		FROM episode_chunks WHERE len(embedding) = ?`
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(copyChunks, chunkTable("episodes"), s.dims), s.dims); err != nil {
+		return fmt.Errorf("failed to move episode chunks: %w", err)
+	}
+	for _, sp := range s.spaces {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(copyChunks+" AND embedding_model = ?", chunkTable(sp.table), sp.Dimensions),
+			sp.Dimensions, sp.Model); err != nil {
+			return fmt.Errorf("failed to move %s episode chunks: %w", sp.Model, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DROP TABLE episode_chunks"); err != nil {
+		return fmt.Errorf("failed to drop legacy episode chunks: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit episode chunk migration: %w", err)
+	}
+	return nil
+}
+
+// chunkTables returns the chunk table of every vector table
+func (s *Store) chunkTables() []string {
+	tables := []string{chunkTable("episodes")}
+	for _, sp := range s.EmbeddingSpaces() {
+		tables = append(tables, chunkTable(sp.table))
+	}
+	return tables
+}
+
+// writeChunks replaces id's chunks for model with chunks, in model's space
+// or, without one, beside the primary column. Chunks of the wrong size are
+// dropped, as their episode's vector would be.
+func (s *Store) writeChunks(ctx context.Context, ex execer, id, model string, chunks []models.Chunk) error {
+	table, dims := chunkTable("episodes"), s.dims
+	if sp, ok := s.lookupSpace(model); ok {
+		table, dims = chunkTable(sp.table), sp.Dimensions
+	}
+	for _, ch := range chunks {
+		if len(ch.Embedding) != dims {
+			fmt.Fprintf(os.Stderr, "Warning: %s chunk embedding has %d dimensions, the store expects %d; storing episode %s without chunks\n",
+				model, len(ch.Embedding), dims, id)
+			chunks = nil
+			break
+		}
+	}
+
+	if _, err := ex.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE episode_id = ? AND embedding_model = ?", table), id, model); err != nil {
+		return fmt.Errorf("failed to clear episode chunks: %w", err)
+	}
+	for _, ch := range chunks {
+		data, err := json.Marshal(ch.Embedding)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chunk embedding: %w", err)
+		}
+		if _, err := ex.ExecContext(ctx, fmt.Sprintf(`
+			INSERT INTO %s (episode_id, embedding_model, chunk_index, content, embedding)
+			VALUES (?, ?, ?, ?, ?)
+		`, table), id, model, ch.Index, ch.Content, string(data)); err != nil {
+			return fmt.Errorf("failed to write episode chunk: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteChunks removes id's chunks from every chunk table
+func (s *Store) deleteChunks(ctx context.Context, ex execer, id string) error {
+	for _, table := range s.chunkTables() {
+		if _, err := ex.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE episode_id = ?", table), id); err != nil {
+			return fmt.Errorf("failed to clear episode chunks: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReplaceEpisodeChunks sets id's chunk vectors for model, to go with the
+// vector UpdateEpisodeEmbedding stored. No chunks clears them: the content
+// fits in one embedding.
+func (s *Store) ReplaceEpisodeChunks(ctx context.Context, id, model string, chunks []models.Chunk) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := s.writeChunks(ctx, tx, id, model, chunks); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit episode chunks: %w", err)
+	}
+	return nil
+}
+
+// chunkSource is the chunk table a search reads, beside its vector table.
+// A space's chunks are all of its model; beside the primary column, only
+// the chunks of the model that produced each episode's stored vector count.
+type chunkSource struct {
+	table   string
+	primary bool
+}
+
+func newChunkSource(vectors vectorSource) chunkSource {
+	return chunkSource{table: chunkTable(vectors.table), primary: vectors.table == "episodes"}
+}
+
+// live is the condition keeping current chunks of the table as c
+func (c chunkSource) live() string {
+	if c.primary {
+		return "EXISTS (SELECT 1 FROM episodes p WHERE p.id = c.episode_id AND p.embedding_model = c.embedding_model)"
+	}
+	return "TRUE"
+}
+
+// approximateHits selects the pool nearest chunks to query (a FLOAT[dims]
+// literal) through the table's HNSW index, in the index's bare shape: a
+// chunk's model is checked by live afterwards
+func (c chunkSource) approximateHits(query string, pool int) string {
+	return fmt.Sprintf(`SELECT episode_id, embedding_model, array_cosine_distance(embedding, %s) AS distance
+		FROM %s ORDER BY distance LIMIT %d`, query, c.table, pool)
+}
+
+// exactHits selects each episode's nearest current chunk to query, for the
+// pool nearest of the episodes in the subquery episodes, by scan
+func (c chunkSource) exactHits(query, episodes string, pool int) string {
+	return fmt.Sprintf(`SELECT c.episode_id, c.embedding_model, MIN(array_cosine_distance(c.embedding, %s)) AS distance
+		FROM %s c WHERE %s AND c.episode_id IN (%s)
+		GROUP BY c.episode_id, c.embedding_model ORDER BY distance LIMIT %d`, query, c.table, c.live(), episodes, pool)
+}
+
+// attachSnippets sets each chunked episode's Snippet to its chunk nearest
+// query
+func (s *Store) attachSnippets(ctx context.Context, src chunkSource, query string, episodes []models.Episode) error {
+	if len(episodes) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(episodes))
+	args := make([]interface{}, len(episodes))
+	at := make(map[string]int, len(episodes))
+	for i, ep := range episodes {
+		placeholders[i] = "?"
+		args[i] = ep.ID
+		at[ep.ID] = i
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT c.episode_id, c.content FROM %s c
+		WHERE %s AND c.episode_id IN (%s)
+		QUALIFY row_number() OVER (PARTITION BY c.episode_id ORDER BY array_cosine_distance(c.embedding, %s), c.chunk_index) = 1
+	`, src.table, src.live(), strings.Join(placeholders, ", "), query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to read chunk snippets: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return fmt.Errorf("failed to scan chunk snippet: %w", err)
+		}
+		episodes[at[id]].Snippet = content
+	}
+	return rows.Err()
+}
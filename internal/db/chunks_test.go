@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestChunkedSearch(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	chunks := []models.Chunk{
+		{Index: 0, Content: "agenda and planning for the quarter", Embedding: emb(1, 0)},
+		{Index: 1, Content: "the database failover drill went badly", Embedding: emb(0, 1)},
+	}
+	long := &models.Episode{
+		Content: "agenda and planning for the quarter ... the database failover drill went badly",
+		Source:  "test", Embedding: emb(0.5, 0.5), Chunks: chunks, EmbeddingModel: "test-model",
+	}
+	short := &models.Episode{Content: "failover runbook", Source: "test", Embedding: emb(0.6, 0.8), EmbeddingModel: "test-model"}
+	for _, ep := range []*models.Episode{long, short} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	search := func() []models.Episode {
+		t.Helper()
+		page, err := store.SearchPage(ctx, models.SearchParams{QueryEmbedding: emb(0, 1), SearchMode: "vector", MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(page.Episodes) != 2 {
+			t.Fatalf("Expected both episodes, got %d", len(page.Episodes))
+		}
+		return page.Episodes
+	}
+
+	t.Run("an episode scores by its best chunk", func(t *testing.T) {
+		eps := search()
+		if eps[0].ID != long.ID {
+			t.Fatalf("Expected the chunked episode first, got %q", eps[0].Content)
+		}
+		if eps[0].Similarity == nil || math.Abs(*eps[0].Similarity-1) > 1e-6 {
+			t.Errorf("Expected its best chunk's similarity 1, got %v", eps[0].Similarity)
+		}
+		if eps[0].Snippet != chunks[1].Content {
+			t.Errorf("Expected the matching chunk as snippet, got %q", eps[0].Snippet)
+		}
+		if eps[1].Snippet != "" {
+			t.Errorf("Expected no snippet for an unchunked episode, got %q", eps[1].Snippet)
+		}
+	})
+
+	t.Run("chunks of another model are ignored", func(t *testing.T) {
+		if err := store.ReplaceEpisodeChunks(ctx, long.ID, "test-model", nil); err != nil {
+			t.Fatalf("ReplaceEpisodeChunks failed: %v", err)
+		}
+		if err := store.ReplaceEpisodeChunks(ctx, long.ID, "other-model", chunks); err != nil {
+			t.Fatalf("ReplaceEpisodeChunks failed: %v", err)
+		}
+		eps := search()
+		if eps[0].ID != short.ID || eps[1].Snippet != "" {
+			t.Errorf("Expected the parent vector alone to rank, got %q first, snippet %q", eps[0].Content, eps[1].Snippet)
+		}
+	})
+
+	t.Run("deleting an episode deletes its chunks", func(t *testing.T) {
+		if _, err := store.DeleteEpisodes(ctx, []string{long.ID}, Deletion{}); err != nil {
+			t.Fatalf("DeleteEpisodes failed: %v", err)
+		}
+		var n int
+		if err := store.db.QueryRow("SELECT COUNT(*) FROM episodes_chunks").Scan(&n); err != nil {
+			t.Fatalf("Failed to count chunks: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("Expected no chunks left, got %d", n)
+		}
+	})
+}
+
+func TestChunkedSpaceSearch(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	sp, err := store.CreateEmbeddingSpace(ctx, "space-model", 4)
+	if err != nil {
+		t.Fatalf("CreateEmbeddingSpace failed: %v", err)
+	}
+	chunks := []models.Chunk{
+		{Index: 0, Content: "quarterly planning", Embedding: []float32{1, 0, 0, 0}},
+		{Index: 1, Content: "failover drill", Embedding: []float32{0, 1, 0, 0}},
+	}
+	long := &models.Episode{
+		Content: "quarterly planning ... failover drill", Source: "test",
+		Embedding: []float32{0.5, 0.5, 0, 0}, Chunks: chunks, EmbeddingModel: "space-model",
+	}
+	if err := store.InsertEpisode(ctx, long); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if err := store.ActivateEmbeddingSpace(ctx, "space-model", false); err != nil {
+		t.Fatalf("ActivateEmbeddingSpace failed: %v", err)
+	}
+
+	page, err := store.SearchPage(ctx, models.SearchParams{QueryEmbedding: []float32{0, 1, 0, 0}, SearchMode: "vector", MaxResults: 10})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(page.Episodes) != 1 || page.Episodes[0].Snippet != chunks[1].Content {
+		t.Fatalf("Expected the episode found by its chunk, got %+v", page.Episodes)
+	}
+	if sim := page.Episodes[0].Similarity; sim == nil || math.Abs(*sim-1) > 1e-6 {
+		t.Errorf("Expected its best chunk's similarity 1, got %v", sim)
+	}
+
+	if err := store.ActivateEmbeddingSpace(ctx, "", false); err != nil {
+		t.Fatalf("ActivateEmbeddingSpace failed: %v", err)
+	}
+	if err := store.DropEmbeddingSpace(ctx, "space-model"); err != nil {
+		t.Fatalf("DropEmbeddingSpace failed: %v", err)
+	}
+	var n int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?", chunkTable(sp.table)).Scan(&n); err != nil {
+		t.Fatalf("Failed to look up chunk table: %v", err)
+	}
+	if n != 0 {
+		t.Error("Expected the space's chunk table dropped with it")
+	}
+}
+
+func TestLegacyChunkMigration(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	ep := &models.Episode{Content: "a long episode", Source: "test", Embedding: makeEmbedding(1), EmbeddingModel: "test-model"}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if _, err := store.db.Exec(`
+		CREATE TABLE episode_chunks (
+			episode_id VARCHAR NOT NULL,
+			embedding_model VARCHAR NOT NULL,
+			chunk_index INTEGER NOT NULL,
+			content VARCHAR NOT NULL,
+			embedding FLOAT[] NOT NULL
+		)`); err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	data, _ := json.Marshal(makeEmbedding(1))
+	bad, _ := json.Marshal([]float32{1, 0})
+	for i, vec := range []string{string(data), string(bad)} {
+		if _, err := store.db.Exec("INSERT INTO episode_chunks VALUES (?, 'test-model', ?, 'a chunk', CAST(? AS FLOAT[]))",
+			ep.ID, i, vec); err != nil {
+			t.Fatalf("Failed to insert legacy chunk: %v", err)
+		}
+	}
+
+	if err := store.ensureChunkTables(ctx); err != nil {
+		t.Fatalf("ensureChunkTables failed: %v", err)
+	}
+	var moved, legacy int
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM episodes_chunks WHERE episode_id = ?", ep.ID).Scan(&moved); err != nil {
+		t.Fatalf("Failed to count chunks: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("Expected the chunk of the store's size moved, got %d", moved)
+	}
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'episode_chunks'").Scan(&legacy); err != nil {
+		t.Fatalf("Failed to look up legacy table: %v", err)
+	}
+	if legacy != 0 {
+		t.Error("Expected the legacy table dropped")
+	}
+}
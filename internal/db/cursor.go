@@ -0,0 +1,108 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was
+// issued for a different search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorKind says which ordering a cursor continues
+type cursorKind string
+
+const (
+	// cursorTime is a keyset on (created_at, id), newest first
+	cursorTime cursorKind = "time"
+	// cursorRank is a keyset on (relevance, id), best first
+	cursorRank cursorKind = "rank"
+	// cursorFallback continues the keyword ILIKE fallback, newest first
+	cursorFallback cursorKind = "fallback"
+	// cursorDiverse is an offset into a diversified search's window
+	cursorDiverse cursorKind = "diverse"
+)
+
+// cursor is the decoded form of an opaque continuation token. It names the
+// last row returned; the next page starts strictly after it.
+type cursor struct {
+	Kind      cursorKind `json:"k"`
+	Search    string     `json:"s"` // searchFingerprint of the issuing search
+	ID        string     `json:"i"`
+	CreatedAt time.Time  `json:"c"`
+	Relevance float64    `json:"r,omitempty"`
+
+	// Ranked pages only consider episodes created up to the first page, so
+	// new writes don't shift the min-max normalisation under later pages
+	Until *time.Time `json:"u,omitempty"`
+
+	// Semantic pages rank the same vector candidate pool as the first page
+	Pool  int  `json:"p,omitempty"`
+	Exact bool `json:"x,omitempty"`
+
+	// Diversified pages re-rank the same window and start Offset into it
+	Window int `json:"w,omitempty"`
+	Offset int `json:"o,omitempty"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	switch c.Kind {
+	case cursorTime, cursorRank, cursorFallback, cursorDiverse:
+		return &c, nil
+	}
+	return nil, ErrInvalidCursor
+}
+
+// check rejects a cursor issued for a different search or ordering
+func (c *cursor) check(kind cursorKind, fingerprint string) error {
+	if c.Kind != kind || c.Search != fingerprint {
+		return fmt.Errorf("%w: it was issued for a different search", ErrInvalidCursor)
+	}
+	return nil
+}
+
+// searchFingerprint identifies everything about a search that decides its
+// results and their order, so a cursor can't continue a different one. Page
+// size may change between pages. Whether the query was embedded is included:
+// if the embedding endpoint fails mid-way the ranking changes, and a cursor
+// from the semantic ranking is meaningless for the keyword-only one.
+func searchFingerprint(p models.SearchParams, semantic bool) string {
+	p.Cursor, p.MaxResults, p.QueryEmbedding, p.Explain, p.Strict = "", 0, nil, false, false
+	data, _ := json.Marshal(struct {
+		Params   models.SearchParams
+		Semantic bool
+	}{p, semantic})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// paginate turns a query result fetched with one row past limit into a
+// page, with a cursor after its last row when there are more
+func paginate(episodes []models.Episode, limit int, next func(last models.Episode) cursor) *models.SearchPage {
+	page := &models.SearchPage{Episodes: episodes}
+	if len(episodes) > limit {
+		page.Episodes = episodes[:limit]
+		page.NextCursor = next(page.Episodes[limit-1]).encode()
+	}
+	return page
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// walkPages collects every page of a search, failing on a repeated episode
+func walkPages(t *testing.T, store *Store, params models.SearchParams) [][]models.Episode {
+	t.Helper()
+	var pages [][]models.Episode
+	seen := map[string]bool{}
+	for {
+		page, err := store.SearchPage(context.Background(), params)
+		if err != nil {
+			t.Fatalf("SearchPage failed on page %d: %v", len(pages)+1, err)
+		}
+		for _, ep := range page.Episodes {
+			if seen[ep.ID] {
+				t.Fatalf("Episode %s returned twice", ep.ID)
+			}
+			seen[ep.ID] = true
+		}
+		pages = append(pages, page.Episodes)
+		if page.NextCursor == "" {
+			return pages
+		}
+		if len(pages) > 10 {
+			t.Fatal("Pagination did not terminate")
+		}
+		params.Cursor = page.NextCursor
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Two episodes share a timestamp so the id tie-breaker is exercised
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	stamps := []time.Time{base, base.Add(time.Minute), base.Add(time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)}
+	for i, at := range stamps {
+		emb := make([]float32, 768)
+		emb[0] = 1
+		emb[1] = float32(i) * 0.3
+		ep := &models.Episode{Content: "paged", Source: "test", GroupID: "pages", CreatedAt: at,
+			Embedding: emb, EmbeddingModel: "test-model"}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	t.Run("chronological", func(t *testing.T) {
+		pages := walkPages(t, store, models.SearchParams{GroupID: "pages", MaxResults: 2})
+		if len(pages) != 3 || len(pages[2]) != 1 {
+			t.Fatalf("Expected pages of 2, 2, 1, got %d pages", len(pages))
+		}
+		var prev *models.Episode
+		for _, page := range pages {
+			for i := range page {
+				if prev != nil && page[i].CreatedAt.After(prev.CreatedAt) {
+					t.Errorf("Episodes out of order: %v after %v", page[i].CreatedAt, prev.CreatedAt)
+				}
+				prev = &page[i]
+			}
+		}
+	})
+
+	t.Run("ranked", func(t *testing.T) {
+		query := make([]float32, 768)
+		query[0] = 1
+		pages := walkPages(t, store, models.SearchParams{Query: "paged", QueryEmbedding: query, GroupID: "pages", MaxResults: 2})
+		total := 0
+		last := 2.0
+		for _, page := range pages {
+			for _, ep := range page {
+				if ep.Relevance == nil || *ep.Relevance > last {
+					t.Errorf("Relevance not descending across pages: %v after %v", ep.Relevance, last)
+				} else {
+					last = *ep.Relevance
+				}
+				total++
+			}
+		}
+		if total != len(stamps) {
+			t.Errorf("Expected %d episodes across pages, got %d", len(stamps), total)
+		}
+	})
+
+	t.Run("cursor from another search is rejected", func(t *testing.T) {
+		page, err := store.SearchPage(ctx, models.SearchParams{GroupID: "pages", MaxResults: 2})
+		if err != nil || page.NextCursor == "" {
+			t.Fatalf("Expected a next cursor, got %v", err)
+		}
+		_, err = store.SearchPage(ctx, models.SearchParams{GroupID: "other", MaxResults: 2, Cursor: page.NextCursor})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+		_, err = store.SearchPage(ctx, models.SearchParams{GroupID: "pages", Cursor: "not-a-cursor"})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for garbage, got %v", err)
+		}
+	})
+}
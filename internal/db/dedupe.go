@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Dedupe actions: what a write does when the new episode duplicates a live
+// one in its group
+const (
+	DedupeOff            = ""                // Always store
+	DedupeReject         = "reject"          // Refuse the write
+	DedupeReturnExisting = "return_existing" // Store nothing, answer with the existing episode
+	DedupeMerge          = "merge"           // Fold the new tags and metadata into the existing episode
+)
+
+// DefaultDedupeThreshold is the cosine similarity from which a new episode
+// counts as a near-duplicate
+const DefaultDedupeThreshold = 0.95
+
+// ErrDuplicate is returned when a dedupe policy rejects a write
+var ErrDuplicate = errors.New("duplicate episode")
+
+// DedupePolicy configures duplicate detection for a write
+type DedupePolicy struct {
+	Action    string  // One of the Dedupe actions
+	Threshold float64 // Similarity for a near-duplicate; 0 means DefaultDedupeThreshold
+}
+
+// Validate reports a policy the store can't apply
+func (p DedupePolicy) Validate() error {
+	switch p.Action {
+	case DedupeOff, DedupeReject, DedupeReturnExisting, DedupeMerge:
+	default:
+		return fmt.Errorf("unknown dedupe policy %q: use %q, %q or %q", p.Action, DedupeReject, DedupeReturnExisting, DedupeMerge)
+	}
+	if p.Threshold < 0 || p.Threshold > 1 {
+		return fmt.Errorf("dedupe_threshold must be between 0.0 and 1.0")
+	}
+	return nil
+}
+
+// DedupeResult says what a deduplicated write did
+type DedupeResult struct {
+	Action     string   `json:"action"`                // "created", "rejected", "returned_existing" or "merged"
+	ExistingID string   `json:"existing_id,omitempty"` // The episode the new one duplicates
+	Match      string   `json:"match,omitempty"`       // "exact" (same content) or "similar" (vector within the threshold)
+	Similarity *float64 `json:"similarity,omitempty"`  // Cosine similarity of a "similar" match
+}
+
+// InsertEpisodeDeduped stores ep unless it duplicates a live episode in its
+// group: one with the same content, or, when ep carries a vector of the
+// searched model, one whose vector is at least the policy's threshold
+// similar. A duplicate is then rejected with ErrDuplicate, answered with
+// the existing episode, or merged into it, per the policy. The returned
+// episode is what the caller should report: ep, or the existing one.
+func (s *Store) InsertEpisodeDeduped(ctx context.Context, ep *models.Episode, policy DedupePolicy) (*models.Episode, DedupeResult, error) {
+	if policy.Action == DedupeOff {
+		if err := s.InsertEpisode(ctx, ep); err != nil {
+			return nil, DedupeResult{}, err
+		}
+		return ep, DedupeResult{Action: "created"}, nil
+	}
+
+	dup, err := s.findDuplicate(ctx, ep, policy.Threshold)
+	if err != nil {
+		return nil, DedupeResult{}, err
+	}
+	if dup.ExistingID == "" {
+		if err := s.InsertEpisode(ctx, ep); err != nil {
+			return nil, DedupeResult{}, err
+		}
+		return ep, DedupeResult{Action: "created"}, nil
+	}
+
+	switch policy.Action {
+	case DedupeReject:
+		dup.Action = "rejected"
+		return nil, dup, fmt.Errorf("%w: %s match of episode %s", ErrDuplicate, dup.Match, dup.ExistingID)
+	case DedupeMerge:
+		dup.Action = "merged"
+		existing, err := s.mergeIntoEpisode(ctx, dup.ExistingID, ep.Tags, ep.Metadata)
+		return existing, dup, err
+	default:
+		dup.Action = "returned_existing"
+		existing, err := s.GetEpisode(ctx, dup.ExistingID)
+		return existing, dup, err
+	}
+}
+
+// findDuplicate returns the live episode in ep's group that ep duplicates:
+// an exact content match first, else its nearest vector within threshold.
+// ExistingID is empty when there is none.
+func (s *Store) findDuplicate(ctx context.Context, ep *models.Episode, threshold float64) (DedupeResult, error) {
+	group := ep.GroupID
+	if group == "" {
+		group = "default"
+	}
+
+	var id string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id FROM episodes WHERE group_id = ? AND content = ? AND "+livePredicate+" ORDER BY created_at LIMIT 1",
+		group, ep.Content).Scan(&id)
+	if err == nil {
+		return DedupeResult{ExistingID: id, Match: "exact"}, nil
+	}
+	if err != sql.ErrNoRows {
+		return DedupeResult{}, fmt.Errorf("failed to look up duplicates: %w", err)
+	}
+
+	// Vectors are only comparable within one model's space
+	dims := s.ActiveEmbeddingDimensions()
+	if len(ep.Embedding) != dims || ep.EmbeddingModel == "" {
+		return DedupeResult{}, nil
+	}
+	if threshold <= 0 {
+		threshold = DefaultDedupeThreshold
+	}
+	embJSON, err := json.Marshal(ep.Embedding)
+	if err != nil {
+		return DedupeResult{}, fmt.Errorf("failed to marshal embedding: %w", err)
+	}
+	vec, model, from := s.exportVector()
+	var sim float64
+	err = s.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT episodes.id, array_cosine_similarity(%s, CAST(? AS FLOAT[%d])) AS sim
+		FROM %s
+		WHERE episodes.group_id = ? AND %s = ? AND %s IS NOT NULL AND %s
+		ORDER BY sim DESC LIMIT 1
+	`, vec, dims, from, model, vec, livePredicate), string(embJSON), group, ep.EmbeddingModel).Scan(&id, &sim)
+	if err == sql.ErrNoRows || (err == nil && sim < threshold) {
+		return DedupeResult{}, nil
+	}
+	if err != nil {
+		return DedupeResult{}, fmt.Errorf("failed to look up duplicates: %w", err)
+	}
+	return DedupeResult{ExistingID: id, Match: "similar", Similarity: &sim}, nil
+}
+
+// mergeIntoEpisode adds tags to episode id's and merges metadata, a JSON
+// object, into its metadata object, the new keys winning. Returns the
+// updated episode.
+func (s *Store) mergeIntoEpisode(ctx context.Context, id string, tags []string, metadata string) (*models.Episode, error) {
+	existing, err := s.GetEpisode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var params models.UpdateParams
+	merged := append([]string(nil), existing.Tags...)
+	have := make(map[string]bool, len(merged))
+	for _, t := range merged {
+		have[t] = true
+	}
+	for _, t := range tags {
+		if !have[t] {
+			have[t] = true
+			merged = append(merged, t)
+		}
+	}
+	if len(merged) > len(existing.Tags) {
+		params.Tags = &merged
+	}
+
+	if metadata != "" {
+		m, err := mergeMetadata(existing.Metadata, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("cannot merge into episode %s: %w", id, err)
+		}
+		params.Metadata = &m
+	}
+
+	if params.Tags == nil && params.Metadata == nil {
+		return existing, nil
+	}
+	if err := s.UpdateEpisode(ctx, id, params); err != nil {
+		return nil, err
+	}
+	return s.GetEpisode(ctx, id)
+}
+
+// mergeMetadata merges the keys of incoming, a JSON object, into existing's,
+// the incoming keys winning. Empty metadata is an empty object.
+func mergeMetadata(existing, incoming string) (string, error) {
+	var combined map[string]interface{}
+	if existing != "" {
+		if err := json.Unmarshal([]byte(existing), &combined); err != nil {
+			return "", fmt.Errorf("stored metadata is not a JSON object")
+		}
+	}
+	if combined == nil {
+		combined = map[string]interface{}{} // empty, or JSON null
+	}
+	var add map[string]interface{}
+	if err := json.Unmarshal([]byte(incoming), &add); err != nil {
+		return "", fmt.Errorf("metadata is not a JSON object")
+	}
+	for k, v := range add {
+		combined[k] = v
+	}
+	data, err := json.Marshal(combined)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode merged metadata: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestInsertEpisodeDeduped(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	newEp := func(content string, vec []float32) *models.Episode {
+		return &models.Episode{Content: content, Source: "test", Embedding: vec, EmbeddingModel: "test-model"}
+	}
+
+	original := newEp("the user prefers dark mode", emb(1, 0))
+	original.Tags = []string{"prefs"}
+	original.Metadata = `{"origin":"chat"}`
+	if err := store.InsertEpisode(ctx, original); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	t.Run("exact content is rejected", func(t *testing.T) {
+		_, res, err := store.InsertEpisodeDeduped(ctx, newEp("the user prefers dark mode", nil), DedupePolicy{Action: DedupeReject})
+		if !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("Expected ErrDuplicate, got %v", err)
+		}
+		if res.Action != "rejected" || res.Match != "exact" || res.ExistingID != original.ID {
+			t.Errorf("Unexpected result %+v", res)
+		}
+	})
+
+	t.Run("a near vector returns the existing episode", func(t *testing.T) {
+		got, res, err := store.InsertEpisodeDeduped(ctx, newEp("user likes dark themes", emb(0.99, 0.05)), DedupePolicy{Action: DedupeReturnExisting})
+		if err != nil {
+			t.Fatalf("InsertEpisodeDeduped failed: %v", err)
+		}
+		if res.Action != "returned_existing" || res.Match != "similar" || res.Similarity == nil || got.ID != original.ID {
+			t.Errorf("Unexpected result %+v, episode %s", res, got.ID)
+		}
+	})
+
+	t.Run("merge folds in tags and metadata", func(t *testing.T) {
+		dup := newEp("the user prefers dark mode", nil)
+		dup.Tags = []string{"prefs", "ui"}
+		dup.Metadata = `{"confidence":0.9}`
+		got, res, err := store.InsertEpisodeDeduped(ctx, dup, DedupePolicy{Action: DedupeMerge})
+		if err != nil {
+			t.Fatalf("InsertEpisodeDeduped failed: %v", err)
+		}
+		if res.Action != "merged" || got.ID != original.ID {
+			t.Fatalf("Unexpected result %+v", res)
+		}
+		if want := []string{"prefs", "ui"}; !reflect.DeepEqual(got.Tags, want) {
+			t.Errorf("Expected tags %v, got %v", want, got.Tags)
+		}
+		if got.Metadata != `{"confidence":0.9,"origin":"chat"}` {
+			t.Errorf("Expected merged metadata, got %s", got.Metadata)
+		}
+	})
+
+	t.Run("distinct episodes are stored", func(t *testing.T) {
+		for name, ep := range map[string]*models.Episode{
+			"below threshold": newEp("the user's laptop is a ThinkPad", emb(0.5, 0.5)),
+			"other group":     {Content: "the user prefers dark mode", Source: "test", GroupID: "other"},
+		} {
+			got, res, err := store.InsertEpisodeDeduped(ctx, ep, DedupePolicy{Action: DedupeReject})
+			if err != nil || res.Action != "created" || got.ID != ep.ID {
+				t.Errorf("%s: expected the episode stored, got %+v, %v", name, res, err)
+			}
+		}
+	})
+
+	if err := (DedupePolicy{Action: "skip"}).Validate(); err == nil {
+		t.Error("Expected an unknown policy to be refused")
+	}
+}
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Deletion says who removed episodes and why, for the audit log
+type Deletion struct {
+	By     string
+	Reason string
+}
+
+// DeletionRecord is one audit-log entry. It identifies the deleted episode
+// and its context but never its content.
+type DeletionRecord struct {
+	EpisodeID        string     `json:"episode_id"`
+	GroupID          string     `json:"group_id,omitempty"`
+	Source           string     `json:"source,omitempty"`
+	EpisodeCreatedAt *time.Time `json:"episode_created_at,omitempty"`
+	DeletedAt        time.Time  `json:"deleted_at"`
+	DeletedBy        string     `json:"deleted_by,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+}
+
+// DeleteFilter selects episodes for bulk deletion. Set fields are ANDed;
+// expired episodes always match. At least one field must be set.
+type DeleteFilter struct {
+	GroupID string     `json:"group_id,omitempty"`
+	Source  string     `json:"source,omitempty"`
+	Tags    []string   `json:"tags,omitempty"` // must carry all
+	Before  *time.Time `json:"before,omitempty"`
+	After   *time.Time `json:"after,omitempty"`
+}
+
+// IsEmpty reports whether f would match every episode
+func (f DeleteFilter) IsEmpty() bool {
+	return f.GroupID == "" && f.Source == "" && len(f.Tags) == 0 && f.Before == nil && f.After == nil
+}
+
+// MatchEpisodeIDs returns the IDs of every episode f selects, expired ones
+// included. An empty filter is refused rather than matching everything.
+func (s *Store) MatchEpisodeIDs(ctx context.Context, f DeleteFilter) ([]string, error) {
+	if f.IsEmpty() {
+		return nil, fmt.Errorf("delete filter is empty; set at least one of group_id, source, tags, before, after")
+	}
+
+	var conditions []string
+	var args []interface{}
+	if f.GroupID != "" {
+		conditions = append(conditions, "group_id = ?")
+		args = append(args, f.GroupID)
+	}
+	if f.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, f.Source)
+	}
+	for _, tag := range f.Tags {
+		conditions = append(conditions, "list_contains(tags, ?)")
+		args = append(args, tag)
+	}
+	if f.Before != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *f.Before)
+	}
+	if f.After != nil {
+		conditions = append(conditions, "created_at > ?")
+		args = append(args, *f.After)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id FROM episodes WHERE "+strings.Join(conditions, " AND ")+" ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match episodes: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan episode id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteEpisodes permanently removes episodes — content and its prior
+// versions, vectors in every embedding space, chunk vectors, and any queued
+// embedding — in one transaction, logging each to deletion_log. IDs that
+// don't exist are ignored. Returns the number deleted.
+//
+// The database is checkpointed afterwards so deleted content does not
+// linger in the write-ahead log. The episode's keyword postings go with it.
+func (s *Store) DeleteEpisodes(ctx context.Context, ids []string, d Deletion) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted := 0
+	for _, id := range ids {
+		// Log first: the INSERT ... SELECT copies identifying columns from
+		// the row and writes nothing when it doesn't exist
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO deletion_log (episode_id, group_id, source, episode_created_at, deleted_at, deleted_by, reason)
+			SELECT id, group_id, source, created_at, CURRENT_TIMESTAMP, ?, ?
+			FROM episodes WHERE id = ?
+		`, nullIfEmpty(d.By), nullIfEmpty(d.Reason), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to log deletion: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		texts, err := s.episodeTexts(ctx, tx, id)
+		if err != nil {
+			return 0, err
+		}
+		if err := forgetCachedEmbeddings(ctx, tx, texts); err != nil {
+			return 0, err
+		}
+		if err := s.deleteSpaceVectors(ctx, tx, id); err != nil {
+			return 0, err
+		}
+		if err := deleteKeywordDoc(ctx, tx, id); err != nil {
+			return 0, err
+		}
+		if err := s.deleteChunks(ctx, tx, id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM episode_versions WHERE episode_id = ?", id); err != nil {
+			return 0, fmt.Errorf("failed to delete episode versions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM embedding_queue WHERE episode_id = ?", id); err != nil {
+			return 0, fmt.Errorf("failed to dequeue embedding: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM episodes WHERE id = ?", id); err != nil {
+			return 0, fmt.Errorf("failed to delete episode: %w", err)
+		}
+		deleted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit delete: %w", err)
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: checkpoint after delete failed: %v\n", err)
+	}
+	return deleted, nil
+}
+
+// episodeTexts returns every text of episode id that may have been embedded:
+// its content, its chunks' and its prior versions'
+func (s *Store) episodeTexts(ctx context.Context, tx *sql.Tx, id string) ([]string, error) {
+	query := "SELECT content FROM episodes WHERE id = ? UNION SELECT content FROM episode_versions WHERE episode_id = ?"
+	args := []interface{}{id, id}
+	for _, table := range s.chunkTables() {
+		query += fmt.Sprintf(" UNION SELECT content FROM %s WHERE episode_id = ?", table)
+		args = append(args, id)
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read episode texts: %w", err)
+	}
+	defer rows.Close()
+	var texts []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, fmt.Errorf("failed to read episode texts: %w", err)
+		}
+		texts = append(texts, text)
+	}
+	return texts, rows.Err()
+}
+
+// ListDeletions returns the most recent audit-log entries, newest first
+func (s *Store) ListDeletions(ctx context.Context, limit int) ([]DeletionRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT episode_id, COALESCE(group_id, ''), COALESCE(source, ''), episode_created_at,
+		       deleted_at, COALESCE(deleted_by, ''), COALESCE(reason, '')
+		FROM deletion_log
+		ORDER BY deleted_at DESC, episode_id
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletions: %w", err)
+	}
+	defer rows.Close()
+
+	records := []DeletionRecord{}
+	for rows.Next() {
+		var r DeletionRecord
+		if err := rows.Scan(&r.EpisodeID, &r.GroupID, &r.Source, &r.EpisodeCreatedAt,
+			&r.DeletedAt, &r.DeletedBy, &r.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan deletion: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// nullIfEmpty maps "" to SQL NULL
+func nullIfEmpty(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestDeleteEpisodes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+
+	keep := &models.Episode{Content: "keep me", Source: "test", GroupID: "g1", Tags: []string{"project"}}
+	secret := &models.Episode{Content: "secret one", Source: "test", GroupID: "g2", Tags: []string{"private"}}
+	expired := &models.Episode{Content: "secret two", Source: "test", GroupID: "g2", Tags: []string{"private"}, ExpiredAt: &past}
+	for _, ep := range []*models.Episode{keep, secret, expired} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	t.Run("empty filter is refused", func(t *testing.T) {
+		if _, err := store.MatchEpisodeIDs(ctx, DeleteFilter{}); err == nil {
+			t.Error("Expected an empty filter to be rejected")
+		}
+	})
+
+	t.Run("filter matches expired episodes too", func(t *testing.T) {
+		ids, err := store.MatchEpisodeIDs(ctx, DeleteFilter{GroupID: "g2", Tags: []string{"private"}})
+		if err != nil {
+			t.Fatalf("MatchEpisodeIDs failed: %v", err)
+		}
+		if len(ids) != 2 {
+			t.Fatalf("Expected 2 matches, got %v", ids)
+		}
+	})
+
+	t.Run("deletes and logs without content", func(t *testing.T) {
+		n, err := store.DeleteEpisodes(ctx, []string{secret.ID, expired.ID, "missing"},
+			Deletion{By: "alice", Reason: "user request"})
+		if err != nil {
+			t.Fatalf("DeleteEpisodes failed: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("Expected 2 deleted, got %d", n)
+		}
+		for _, id := range []string{secret.ID, expired.ID} {
+			if _, err := store.GetEpisode(ctx, id); err == nil {
+				t.Errorf("Episode %s should be gone", id)
+			}
+		}
+		if _, err := store.GetEpisode(ctx, keep.ID); err != nil {
+			t.Errorf("Unmatched episode should survive: %v", err)
+		}
+		if q, _ := store.CountQueuedEmbeddings(ctx); q != 1 {
+			t.Errorf("Expected only the surviving episode queued, got %d", q)
+		}
+
+		log, err := store.ListDeletions(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListDeletions failed: %v", err)
+		}
+		if len(log) != 2 {
+			t.Fatalf("Expected 2 audit entries (missing IDs are not logged), got %d", len(log))
+		}
+		for _, r := range log {
+			if r.DeletedBy != "alice" || r.Reason != "user request" || r.GroupID != "g2" || r.EpisodeCreatedAt == nil {
+				t.Errorf("Unexpected audit entry: %+v", r)
+			}
+		}
+	})
+
+	t.Run("keyword search forgets deleted content", func(t *testing.T) {
+		results, err := store.Search(ctx, models.SearchParams{Query: "secret", SearchMode: "keyword", IncludeExpired: true})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Expected no results for deleted content, got %d", len(results))
+		}
+	})
+}
+
+func TestImportSkipsDeletedEpisodes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	ep := &models.Episode{Content: "erase me", Source: "test"}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert episode: %v", err)
+	}
+	archived, err := store.GetEpisode(ctx, ep.ID)
+	if err != nil {
+		t.Fatalf("GetEpisode failed: %v", err)
+	}
+	if _, err := store.DeleteEpisodes(ctx, []string{ep.ID}, Deletion{By: "alice", Reason: "erasure request"}); err != nil {
+		t.Fatalf("DeleteEpisodes failed: %v", err)
+	}
+
+	result, err := store.ImportEpisodes(ctx, []*models.Episode{archived, {ID: "fresh", Content: "keep me", Source: "test"}})
+	if err != nil {
+		t.Fatalf("ImportEpisodes failed: %v", err)
+	}
+	if result.Inserted != 1 || result.Erased != 1 {
+		t.Errorf("Expected 1 inserted and 1 erased, got %+v", result)
+	}
+	if _, err := store.GetEpisode(ctx, ep.ID); err == nil {
+		t.Error("Expected the deleted episode to stay deleted")
+	}
+}
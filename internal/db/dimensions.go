@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultEmbeddingDimensions is the vector size a new database is created
+// with when none is requested (nomic-embed-text and the rest of the Nomic
+// family produce 768-dimensional embeddings)
+const DefaultEmbeddingDimensions = 768
+
+// resolveDimensions settles s.dims before the schema is created. The size
+// recorded in engram_settings wins; databases that predate the setting are
+// read from the embedding column's declared type. A requested size that
+// disagrees with the stored one is an error — the only safe way to change it
+// is MigrateEmbeddingDimensions.
+func (s *Store) resolveDimensions() error {
+	ctx := context.Background()
+	stored := 0
+
+	value, ok, err := s.getSetting(ctx, settingEmbeddingDimensions)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if stored, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid %s setting %q: %w", settingEmbeddingDimensions, value, err)
+		}
+	} else {
+		// Pre-setting databases: the column type is the record (FLOAT[768])
+		var colType string
+		err := s.db.QueryRow(`
+			SELECT data_type
+			FROM information_schema.columns
+			WHERE table_name = 'episodes' AND column_name = 'embedding'
+		`).Scan(&colType)
+		if err == nil {
+			stored = parseArrayDimensions(colType)
+		}
+	}
+
+	switch {
+	case stored == 0 && s.dims <= 0:
+		s.dims = DefaultEmbeddingDimensions
+	case stored == 0:
+		// New database: adopt the requested size
+	case s.dims <= 0 || s.dims == stored:
+		s.dims = stored
+	default:
+		return fmt.Errorf("database stores %d-dimensional embeddings but %d were requested; "+
+			"convert it with `engram migrate-dimensions --dimensions %d` (all embeddings are cleared and must be re-embedded)",
+			stored, s.dims, s.dims)
+	}
+	return nil
+}
+
+// parseArrayDimensions extracts N from a DuckDB array type name like
+// "FLOAT[768]". Returns 0 when the type is not a fixed-size array.
+func parseArrayDimensions(colType string) int {
+	open := strings.LastIndex(colType, "[")
+	if open < 0 || !strings.HasSuffix(colType, "]") {
+		return 0
+	}
+	n, err := strconv.Atoi(colType[open+1 : len(colType)-1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// EmbeddingDimensions returns the vector size the episodes table stores
+func (s *Store) EmbeddingDimensions() int {
+	return s.dims
+}
+
+// MigrateEmbeddingDimensions converts the store to dims-sized embeddings.
+// Vectors of one size cannot be cast to another, so every stored embedding
+// (and its embedding_model stamp) is cleared, with the chunk vectors beside
+// it, leaving all rows stale for the re-embed pass. The episodes table is
+// rebuilt rather than altered — DuckDB refuses ALTER COLUMN on an indexed
+// table — and the HNSW index is recreated over the new column. Returns the
+// number of episodes whose embedding was cleared.
+func (s *Store) MigrateEmbeddingDimensions(ctx context.Context, dims int) (int, error) {
+	if dims <= 0 {
+		return 0, fmt.Errorf("embedding dimensions must be positive, got %d", dims)
+	}
+	if dims == s.dims {
+		return 0, fmt.Errorf("database already stores %d-dimensional embeddings", dims)
+	}
+
+	var cleared int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM episodes WHERE embedding IS NOT NULL").Scan(&cleared); err != nil {
+		return 0, fmt.Errorf("failed to count embeddings: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Copy every column except the vector and its provenance stamp, which
+	// default to NULL in the new table — exactly the stale state
+	const copyCols = `id, content, name, source, source_model, source_description,
+		group_id, tags, created_at, valid_at, expired_at, metadata, importance, key`
+
+	steps := []string{
+		"DROP INDEX IF EXISTS " + vectorIndexName("episodes"),
+		episodesTableDDL("episodes_new", dims),
+		fmt.Sprintf(`INSERT INTO episodes_new (%s) SELECT %s FROM episodes`, copyCols, copyCols),
+		`DROP TABLE episodes`,
+		`ALTER TABLE episodes_new RENAME TO episodes`,
+		`CREATE INDEX idx_episodes_created_at ON episodes (created_at DESC)`,
+		`CREATE INDEX idx_episodes_group_id ON episodes (group_id)`,
+		`CREATE INDEX idx_episodes_valid_at ON episodes (valid_at)`,
+		`CREATE INDEX idx_episodes_source ON episodes (source)`,
+		`CREATE INDEX idx_episodes_key ON episodes (group_id, key)`,
+		// Chunk vectors of the old size go with the episode vectors
+		"DROP INDEX IF EXISTS " + vectorIndexName(chunkTable("episodes")),
+		"DROP TABLE IF EXISTS " + chunkTable("episodes"),
+		chunkTableDDL("episodes", dims),
+	}
+	for _, stmt := range steps {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("dimension migration failed (%s): %w", stmt, err)
+		}
+	}
+	if err := setSetting(ctx, tx, settingEmbeddingDimensions, strconv.Itoa(dims)); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit dimension migration: %w", err)
+	}
+	s.dims = dims
+
+	// Best-effort like initialize: the VSS index is an accelerator, not a
+	// correctness requirement
+	ensureVectorIndex(ctx, s.db, "episodes")
+	ensureVectorIndex(ctx, s.db, chunkTable("episodes"))
+
+	// Same WAL hazard as startup migrations: never leave table-rebuild DDL
+	// waiting for replay
+	if _, err := s.db.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: post-migration checkpoint failed: %v\n", err)
+	}
+
+	return cleared, nil
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEmbeddingDimensions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("new database defaults to 768", func(t *testing.T) {
+		store := setupTestStore(t)
+		defer store.Close()
+		if got := store.EmbeddingDimensions(); got != DefaultEmbeddingDimensions {
+			t.Errorf("Expected %d dimensions, got %d", DefaultEmbeddingDimensions, got)
+		}
+	})
+
+	t.Run("new database adopts requested size", func(t *testing.T) {
+		store, err := NewStoreWithDimensions(t.TempDir()+"/test.duckdb", 1024)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		defer store.Close()
+
+		emb := make([]float32, 1024)
+		emb[0] = 1
+		ep := &models.Episode{Content: "wide vector", Source: "test", Embedding: emb}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert 1024-dim episode: %v", err)
+		}
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: emb, MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].Similarity == nil {
+			t.Fatalf("Expected 1 semantic result, got %d", len(results))
+		}
+	})
+
+	t.Run("size is recorded and reused on reopen", func(t *testing.T) {
+		path := t.TempDir() + "/test.duckdb"
+		store, err := NewStoreWithDimensions(path, 1536)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		store.Close()
+
+		reopened, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		defer reopened.Close()
+		if got := reopened.EmbeddingDimensions(); got != 1536 {
+			t.Errorf("Expected recorded 1536 dimensions, got %d", got)
+		}
+	})
+
+	t.Run("mismatched size on reopen is refused", func(t *testing.T) {
+		path := t.TempDir() + "/test.duckdb"
+		store, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		store.Close()
+
+		_, err = NewStoreWithDimensions(path, 1024)
+		if err == nil {
+			t.Fatal("Expected error opening a 768-dim database as 1024-dim")
+		}
+		if !strings.Contains(err.Error(), "migrate-dimensions") {
+			t.Errorf("Error should point at the migration command: %v", err)
+		}
+	})
+
+	t.Run("wrong-size query embedding degrades instead of failing", func(t *testing.T) {
+		store := setupTestStore(t)
+		defer store.Close()
+		if err := store.InsertEpisode(ctx, &models.Episode{Content: "x", Source: "test", Embedding: makeEmbedding(1)}); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: make([]float32, 384), MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search should not fail on a mismatched query vector: %v", err)
+		}
+		if len(results) != 1 || results[0].Similarity != nil {
+			t.Errorf("Expected 1 chronological result without similarity, got %d", len(results))
+		}
+	})
+}
+
+func TestMigrateEmbeddingDimensions(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/test.duckdb"
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	embedded := &models.Episode{
+		Content: "embedded", Source: "test", Tags: []string{"keep"},
+		Embedding: makeEmbedding(1), EmbeddingModel: "old-model",
+	}
+	plain := &models.Episode{Content: "never embedded", Source: "test"}
+	for _, ep := range []*models.Episode{embedded, plain} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	cleared, err := store.MigrateEmbeddingDimensions(ctx, 1024)
+	if err != nil {
+		t.Fatalf("MigrateEmbeddingDimensions failed: %v", err)
+	}
+
+	t.Run("reports cleared embeddings", func(t *testing.T) {
+		if cleared != 1 {
+			t.Errorf("Expected 1 cleared embedding, got %d", cleared)
+		}
+		if got := store.EmbeddingDimensions(); got != 1024 {
+			t.Errorf("Expected 1024 dimensions after migration, got %d", got)
+		}
+	})
+
+	t.Run("content survives and every row is stale", func(t *testing.T) {
+		got, err := store.GetEpisode(ctx, embedded.ID)
+		if err != nil {
+			t.Fatalf("Episode lost in migration: %v", err)
+		}
+		if got.Content != "embedded" || len(got.Tags) != 1 || got.Tags[0] != "keep" {
+			t.Errorf("Episode fields not preserved: %+v", got)
+		}
+		counts, err := store.CountReembedTargets(ctx, "old-model", false)
+		if err != nil {
+			t.Fatalf("CountReembedTargets failed: %v", err)
+		}
+		if counts.Episodes != 2 {
+			t.Errorf("Expected both episodes stale after migration, got %d", counts.Episodes)
+		}
+	})
+
+	t.Run("new size accepts new vectors", func(t *testing.T) {
+		emb := make([]float32, 1024)
+		emb[0] = 1
+		if err := store.UpdateEpisodeEmbedding(ctx, embedded.ID, emb, "big-model"); err != nil {
+			t.Fatalf("Failed to store 1024-dim embedding: %v", err)
+		}
+	})
+
+	t.Run("same size is rejected", func(t *testing.T) {
+		if _, err := store.MigrateEmbeddingDimensions(ctx, 1024); err == nil {
+			t.Error("Expected error migrating to the current size")
+		}
+	})
+
+	store.Close()
+
+	t.Run("migrated size persists", func(t *testing.T) {
+		reopened, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("Failed to reopen migrated database: %v", err)
+		}
+		defer reopened.Close()
+		if got := reopened.EmbeddingDimensions(); got != 1024 {
+			t.Errorf("Expected 1024 dimensions after reopen, got %d", got)
+		}
+	})
+}
+
+func TestParseArrayDimensions(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"FLOAT[768]", 768},
+		{"FLOAT[1536]", 1536},
+		{"FLOAT[]", 0},
+		{"VARCHAR", 0},
+	}
+	for _, tt := range tests {
+		if got := parseArrayDimensions(tt.in); got != tt.want {
+			t.Errorf("parseArrayDimensions(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
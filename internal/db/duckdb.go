@@ -4,33 +4,56 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/google/uuid"
+	"github.com/oscillatelabsllc/engram/internal/keyword"
 	"github.com/oscillatelabsllc/engram/internal/models"
 )
 
 // Store wraps DuckDB operations
 type Store struct {
-	db           *sql.DB
-	ftsStale     bool
-	ftsAvailable bool
-	ftsMu        sync.Mutex
+	db   *sql.DB
+	dims int // embedding vector size, recorded in engram_settings
+
+	// Registered embedding spaces and the one Search queries (see spaces.go)
+	spacesMu    sync.RWMutex
+	spaces      map[string]EmbeddingSpace
+	activeModel string
+
+	// Serializes transactions that write the keyword index: they all
+	// update the one keyword_stats row, and DuckDB fails concurrent
+	// updates of a row rather than waiting (see keyword.go). Space changes
+	// hold it too, so no episode is written between their checks and
+	// their effect (see spaces.go).
+	indexMu sync.Mutex
 }
 
-// NewStore creates a new DuckDB store
+// NewStore creates a new DuckDB store. A new database is created with
+// DefaultEmbeddingDimensions; an existing one keeps whatever it recorded.
 func NewStore(dbPath string) (*Store, error) {
+	return NewStoreWithDimensions(dbPath, 0)
+}
+
+// NewStoreWithDimensions creates a new DuckDB store whose embedding column
+// holds dims-sized vectors. dims only shapes a brand-new database; opening an
+// existing database with a different nonzero dims fails rather than silently
+// mixing vector sizes (see MigrateEmbeddingDimensions). dims <= 0 adopts the
+// recorded size, or DefaultEmbeddingDimensions for a new database.
+func NewStoreWithDimensions(dbPath string, dims int) (*Store, error) {
 	db, err := sql.Open("duckdb", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	store := &Store{db: db}
+	store := &Store{db: db, dims: dims}
 	if err := store.initialize(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
@@ -42,8 +65,8 @@ func NewStore(dbPath string) (*Store, error) {
 // initialize sets up the database schema and extensions
 func (s *Store) initialize() error {
 	// Install and load VSS extension as separate calls so the download
-	// completes before LOAD attempts to use the file. Like FTS below, LOAD
-	// can race the INSTALL download flush (and concurrent test processes
+	// completes before LOAD attempts to use the file. LOAD can race the
+	// INSTALL download flush (observed in CI, and concurrent test processes
 	// share the extensions dir), so retry once after a brief pause.
 	if _, err := s.db.Exec("INSTALL vss"); err != nil {
 		return fmt.Errorf("failed to install VSS extension: %w", err)
@@ -58,24 +81,34 @@ func (s *Store) initialize() error {
 		}
 	}
 
+	// HNSW indexes on a file-backed database are gated behind this flag.
+	// Without it the vector indexes silently fail to build and every vector
+	// search scans the table. DuckDB flushes index changes at checkpoints,
+	// which the store forces after DDL and on close.
+	if _, err := s.db.Exec("SET GLOBAL hnsw_enable_experimental_persistence = true"); err != nil {
+		return fmt.Errorf("failed to enable HNSW persistence: %w", err)
+	}
+
+	// Store-level settings live in the database so the file describes its
+	// own shape (embedding dimensions) no matter which binary opens it
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS engram_settings (
+			key VARCHAR PRIMARY KEY,
+			value VARCHAR NOT NULL,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create settings table: %w", err)
+	}
+
+	// Settle the embedding dimension before any DDL that depends on it
+	if err := s.resolveDimensions(); err != nil {
+		return err
+	}
+
 	schema := `
 		-- Create episodes table if it doesn't exist
-		CREATE TABLE IF NOT EXISTS episodes (
-			id VARCHAR PRIMARY KEY,
-			content TEXT NOT NULL,
-			name VARCHAR,
-			source VARCHAR NOT NULL,
-			source_model VARCHAR,
-			source_description TEXT,
-			group_id VARCHAR DEFAULT 'default',
-			tags VARCHAR[],
-			embedding FLOAT[768],
-			embedding_model VARCHAR,
-			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
-			valid_at TIMESTAMPTZ,
-			expired_at TIMESTAMPTZ,
-			metadata JSON
-		);
+		` + episodesTableDDL("IF NOT EXISTS episodes", s.dims) + `;
 
 		-- Create indices if they don't exist
 		CREATE INDEX IF NOT EXISTS idx_episodes_created_at ON episodes (created_at DESC);
@@ -90,28 +123,97 @@ func (s *Store) initialize() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	if err := setSetting(context.Background(), s.db, settingEmbeddingDimensions, strconv.Itoa(s.dims)); err != nil {
+		return err
+	}
+
 	// Run migrations for existing databases
 	if err := s.migrate(); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	// Try to create HNSW index (will fail if already exists, which is fine)
-	// Note: VSS extension syntax may vary, this is a placeholder
-	_, _ = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_episodes_embedding ON episodes USING HNSW (embedding)")
+	// Registry of per-model vector tables (see spaces.go)
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS embedding_spaces (
+			id INTEGER PRIMARY KEY,
+			model VARCHAR NOT NULL UNIQUE,
+			table_name VARCHAR NOT NULL,
+			dimensions INTEGER NOT NULL,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create embedding space registry: %w", err)
+	}
+	if err := s.loadEmbeddingSpaces(context.Background()); err != nil {
+		return err
+	}
 
-	// Install and load FTS extension for full-text search.
-	// INSTALL downloads the extension; LOAD may fail if the file isn't flushed yet
-	// (observed in CI), so we retry LOAD once after a brief pause.
-	// Non-fatal: keyword/hybrid search degrade gracefully if FTS is unavailable.
-	if _, err := s.db.Exec("INSTALL fts"); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: FTS extension install failed (keyword/hybrid search unavailable): %v\n", err)
-	} else if err := s.loadFTSWithRetry(); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: FTS extension load failed (keyword/hybrid search unavailable): %v\n", err)
-	} else {
-		s.ftsAvailable = true
-		s.ftsStale = true
+	// Episodes stored without a vector, awaiting background embedding (see
+	// queue.go). No index beyond the key: the queue is drained, not searched.
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS embedding_queue (
+			episode_id VARCHAR PRIMARY KEY,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			last_error VARCHAR,
+			enqueued_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create embedding queue: %w", err)
+	}
+
+	// Content vectors by text, for the optional persistent embedding cache
+	// (see embeddingcache.go)
+	if _, err := s.db.Exec(embeddingCacheDDL); err != nil {
+		return fmt.Errorf("failed to create embedding cache: %w", err)
 	}
 
+	// Chunk vectors of long episodes, beside each vector table (see
+	// chunks.go)
+	if err := s.ensureChunkTables(context.Background()); err != nil {
+		return err
+	}
+
+	// Prior content and names of edited episodes (see versions.go)
+	if _, err := s.db.Exec(episodeVersionsDDL); err != nil {
+		return fmt.Errorf("failed to create episode versions: %w", err)
+	}
+
+	// Audit trail of permanent deletions (see deletion.go). Records who,
+	// when and why — never the deleted content.
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS deletion_log (
+			episode_id VARCHAR NOT NULL,
+			group_id VARCHAR,
+			source VARCHAR,
+			episode_created_at TIMESTAMPTZ,
+			deleted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			deleted_by VARCHAR,
+			reason VARCHAR
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create deletion log: %w", err)
+	}
+
+	// HNSW indexes for vector search's candidate phase (see vector.go)
+	ensureVectorIndex(context.Background(), s.db, "episodes")
+	ensureVectorIndex(context.Background(), s.db, chunkTable("episodes"))
+	for _, sp := range s.spaces {
+		ensureVectorIndex(context.Background(), s.db, sp.table)
+		ensureVectorIndex(context.Background(), s.db, chunkTable(sp.table))
+	}
+
+	// Inverted index for keyword/hybrid search, maintained on every write
+	// (see keyword.go). It replaced DuckDB's FTS extension, whose index had
+	// to be rebuilt over the whole table after each write.
+	if _, err := s.db.Exec(keywordIndexDDL); err != nil {
+		return fmt.Errorf("failed to create keyword index: %w", err)
+	}
+	if err := s.ensureKeywordIndex(context.Background()); err != nil {
+		return err
+	}
+	dropLegacyFTSIndex(s.db)
+
 	// Flush startup DDL (schema creation, migrations) out of the WAL.
 	// Leaving ALTER TABLE entries in the WAL risks bricking the database:
 	// DuckDB's WAL replay of ALTERs on tables with function defaults
@@ -124,17 +226,28 @@ func (s *Store) initialize() error {
 	return nil
 }
 
-// loadFTSWithRetry attempts to LOAD the FTS extension, retrying once after a
-// short pause if the first attempt fails. This handles a race condition where
-// INSTALL downloads the file but it isn't flushed to disk before LOAD runs.
-func (s *Store) loadFTSWithRetry() error {
-	_, err := s.db.Exec("LOAD fts")
-	if err == nil {
-		return nil
-	}
-	time.Sleep(100 * time.Millisecond)
-	_, err = s.db.Exec("LOAD fts")
-	return err
+// episodesTableDDL returns the CREATE TABLE statement for the current
+// episodes schema. target is the table clause ("IF NOT EXISTS episodes",
+// "episodes_new"); dims sizes the embedding column.
+func episodesTableDDL(target string, dims int) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+			id VARCHAR PRIMARY KEY,
+			content TEXT NOT NULL,
+			name VARCHAR,
+			source VARCHAR NOT NULL,
+			source_model VARCHAR,
+			source_description TEXT,
+			group_id VARCHAR DEFAULT 'default',
+			tags VARCHAR[],
+			embedding FLOAT[%d],
+			embedding_model VARCHAR,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			valid_at TIMESTAMPTZ,
+			expired_at TIMESTAMPTZ,
+			metadata JSON,
+			importance DOUBLE,
+			key VARCHAR
+		)`, target, dims)
 }
 
 // migrate handles schema migrations for existing databases
@@ -169,7 +282,7 @@ func (s *Store) migrate() error {
 				source_description TEXT,
 				group_id VARCHAR DEFAULT 'default',
 				tags VARCHAR[],
-				embedding FLOAT[768],
+				embedding FLOAT[` + strconv.Itoa(s.dims) + `],
 				created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
 				valid_at TIMESTAMPTZ,
 				expired_at TIMESTAMPTZ,
@@ -219,6 +332,22 @@ func (s *Store) migrate() error {
 	// destructive: the knowledge graph is retired, not migrated forward.
 	s.dropKnowledgeGraph()
 
+	// Migration 4: per-episode importance, an optional ranking signal
+	if _, err := s.db.Exec(`ALTER TABLE episodes ADD COLUMN IF NOT EXISTS importance DOUBLE`); err != nil {
+		return fmt.Errorf("migration failed (importance): %w", err)
+	}
+
+	// Migration 5: optional keys for upserted values (see keys.go)
+	keys := []string{
+		`ALTER TABLE episodes ADD COLUMN IF NOT EXISTS key VARCHAR`,
+		`CREATE INDEX IF NOT EXISTS idx_episodes_key ON episodes (group_id, key)`,
+	}
+	for _, stmt := range keys {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("migration failed (%s): %w", stmt, err)
+		}
+	}
+
 	return nil
 }
 
@@ -264,6 +393,56 @@ func (s *Store) dropKnowledgeGraph() {
 
 // InsertEpisode adds a new episode to the store
 func (s *Store) InsertEpisode(ctx context.Context, ep *models.Episode) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.insertEpisode(ctx, tx, ep); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit episode: %w", err)
+	}
+
+	return nil
+}
+
+// InsertEpisodes adds episodes in a single transaction: either all are
+// stored or none are. Episodes without a vector are queued for background
+// embedding, as with InsertEpisode.
+func (s *Store) InsertEpisodes(ctx context.Context, eps []*models.Episode) error {
+	if len(eps) == 0 {
+		return nil
+	}
+	if err := checkBatchKeys(eps); err != nil {
+		return err
+	}
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, ep := range eps {
+		if err := s.insertEpisode(ctx, tx, ep); err != nil {
+			return fmt.Errorf("episode %d: %w", i, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit episodes: %w", err)
+	}
+
+	return nil
+}
+
+// insertEpisode writes ep, and its space vector or queue entry, within tx
+func (s *Store) insertEpisode(ctx context.Context, tx *sql.Tx, ep *models.Episode) error {
 	if ep.ID == "" {
 		ep.ID = uuid.New().String()
 	}
@@ -300,66 +479,134 @@ func (s *Store) InsertEpisode(ctx context.Context, ep *models.Episode) error {
 		metadataJSON = nil
 	}
 
+	// A vector from a model with its own embedding space goes there instead
+	// of the primary column. A wrong-sized one is dropped rather than failing
+	// the write: the episode is stored, and the re-embed pass backfills it.
+	space, inSpace := s.lookupSpace(ep.EmbeddingModel)
+	var spaceVector string
+	if inSpace && embeddingJSON != nil {
+		if len(ep.Embedding) == space.Dimensions {
+			spaceVector = embeddingJSON.(string)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s embedding has %d dimensions, its space stores %d; storing episode without it\n",
+				ep.EmbeddingModel, len(ep.Embedding), space.Dimensions)
+		}
+		embeddingJSON = nil
+	}
+
 	// Only stamp provenance when a vector is actually stored
 	var embeddingModel interface{}
 	if embeddingJSON != nil && ep.EmbeddingModel != "" {
 		embeddingModel = ep.EmbeddingModel
 	}
 
+	// A live keyed episode replaces the one holding its key
+	if ep.Key != "" && !ep.IsExpired(time.Now()) {
+		if err := supersedeKey(ctx, tx, ep); err != nil {
+			return err
+		}
+	}
+
 	query := `
 		INSERT INTO episodes (
 			id, content, name, source, source_model, source_description,
-			group_id, tags, embedding, embedding_model, created_at, valid_at, expired_at, metadata
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			group_id, tags, embedding, embedding_model, created_at, valid_at, expired_at, metadata, importance, key
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-
-	_, err := s.db.ExecContext(ctx, query,
+	args := []interface{}{
 		ep.ID, ep.Content, ep.Name, ep.Source, ep.SourceModel, ep.SourceDescription,
 		ep.GroupID, tagsJSON, embeddingJSON, embeddingModel, ep.CreatedAt, ep.ValidAt, ep.ExpiredAt, metadataJSON,
-	)
+		ep.Importance, nullIfEmpty(ep.Key),
+	}
 
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
 		return fmt.Errorf("failed to insert episode: %w", err)
 	}
-
-	s.ftsMu.Lock()
-	s.ftsStale = true
-	s.ftsMu.Unlock()
-
+	if err := indexKeywords(ctx, tx, ep.ID, ep.Name, ep.Content); err != nil {
+		return err
+	}
+	switch {
+	case spaceVector != "":
+		if err := upsertSpaceVector(ctx, tx, space, ep.ID, spaceVector); err != nil {
+			return err
+		}
+	case embeddingJSON == nil:
+		// Stored without a vector (embedding endpoint down, or a wrong-sized
+		// vector dropped above): queue it in the same transaction so the
+		// background worker embeds it once the endpoint is healthy
+		if err := enqueueEmbedding(ctx, tx, ep.ID, time.Now()); err != nil {
+			return err
+		}
+	}
+	// A long episode's chunk vectors go with its stored vector
+	if (spaceVector != "" || embeddingJSON != nil) && len(ep.Chunks) > 0 && ep.EmbeddingModel != "" {
+		if err := s.writeChunks(ctx, tx, ep.ID, ep.EmbeddingModel, ep.Chunks); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // episodeCols is the standard column list for episode queries.
 const episodeCols = `id, content, name, source, source_model, source_description,
-	group_id, tags, created_at, valid_at, expired_at, metadata`
+	group_id, tags, created_at, valid_at, expired_at, metadata, importance, COALESCE(key, '') AS key`
 
-// Search finds episodes matching the given parameters
+// Search finds episodes matching the given parameters. It returns a single
+// page; use SearchPage to continue past it.
 func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]models.Episode, error) {
+	page, err := s.SearchPage(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return page.Episodes, nil
+}
+
+// rrfK is the rank offset of reciprocal rank fusion. 60 is the value from
+// the original paper (Cormack et al., 2009) and the usual default.
+const rrfK = 60
+
+// defaultRecencyHalfLifeDays is the recency half-life when a search weights
+// recency without choosing one
+const defaultRecencyHalfLifeDays = 30
+
+// defaultImportance is the importance of an episode written without one
+const defaultImportance = 0.5
+
+// SearchPage finds one page of episodes matching the given parameters.
+// params.Cursor continues from a previous page's NextCursor; it is rejected
+// with ErrInvalidCursor if the other parameters differ. Chronological
+// listings page by (created_at, id); scored searches page by (relevance, id)
+// over the episodes that existed when the first page was read.
+func (s *Store) SearchPage(ctx context.Context, params models.SearchParams) (*models.SearchPage, error) {
+	var cur *cursor
+	if params.Cursor != "" {
+		var err error
+		if cur, err = decodeCursor(params.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// A search seeded from an episode uses its stored vector or content
+	askedMode := params.SearchMode
+	if params.SimilarTo != "" {
+		var err error
+		if params, err = s.seedSearch(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+
 	// Determine effective search mode
 	mode := params.SearchMode
 	if mode == "" {
 		mode = "vector"
 	}
 
-	needsFTS := mode == "keyword" || mode == "hybrid"
-
-	// If FTS is needed but unavailable, degrade gracefully
-	if needsFTS && !s.ftsAvailable {
-		fmt.Fprintf(os.Stderr, "Warning: %s search requested but FTS extension unavailable, falling back to vector mode\n", mode)
-		mode = "vector"
-		needsFTS = false
-	}
+	needsKeyword := mode == "keyword" || mode == "hybrid"
 
 	// Warn if min_similarity is set but won't be applied
-	if params.MinSimilarity > 0 && mode != "vector" && mode != "" {
-		fmt.Fprintf(os.Stderr, "Warning: min_similarity is ignored in %q search mode (only applies to vector mode)\n", mode)
-	}
-
-	// Rebuild FTS index if needed for keyword/hybrid modes
-	if needsFTS && params.Query != "" {
-		if err := s.ensureFTSIndex(); err != nil {
-			return nil, fmt.Errorf("failed to ensure FTS index: %w", err)
-		}
+	var warnings searchWarnings
+	if params.MinSimilarity > 0 && mode != "vector" {
+		warnings.ignored("min_similarity", fmt.Sprintf("only applies to vector mode, not %q", mode))
 	}
 
 	var conditions []string
@@ -367,13 +614,38 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 	argIdx := 1
 	hasSemantic := len(params.QueryEmbedding) > 0
 
-	var embeddingJSON []byte
+	// Why there is no semantic ranking, should the mode need one
+	noSemantic := "no query embedding"
+	if params.EmbeddingError != nil {
+		noSemantic = fmt.Sprintf("query embedding failed: %v", params.EmbeddingError)
+	}
+
+	// Vectors come from the active embedding space: the primary column, or
+	// a per-model table joined in as v
+	vecCol, vecFrom, dims := "embedding", "episodes", s.dims
+	source := vectorSource{table: "episodes", id: "id"}
+	if space, ok := s.activeSpace(); ok {
+		vecCol = "v.embedding"
+		vecFrom = fmt.Sprintf("episodes LEFT JOIN %s v ON v.episode_id = episodes.id", space.table)
+		dims = space.Dimensions
+		source = vectorSource{table: space.table, id: "episode_id"}
+	}
+
+	// A vector of the wrong size cannot be cast to the column type; treat it
+	// like a failed embedding rather than failing the whole search
+	if hasSemantic && len(params.QueryEmbedding) != dims {
+		noSemantic = fmt.Sprintf("query embedding has %d dimensions, store expects %d", len(params.QueryEmbedding), dims)
+		hasSemantic = false
+	}
+
+	var queryVec string
 	if hasSemantic {
-		var err error
-		embeddingJSON, err = json.Marshal(params.QueryEmbedding)
+		embeddingJSON, err := json.Marshal(params.QueryEmbedding)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to marshal query embedding: %v\n", err)
+			noSemantic = fmt.Sprintf("failed to marshal query embedding: %v", err)
 			hasSemantic = false
+		} else {
+			queryVec = fmt.Sprintf("%s::FLOAT[%d]", embeddingJSON, dims)
 		}
 	}
 
@@ -382,6 +654,78 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		hasSemantic = false
 	}
 
+	// A query the requested mode can't rank as asked falls back to a weaker
+	// mode, unless the search is strict
+	switch {
+	case params.SimilarTo != "" && mode == "keyword" && askedMode != "keyword":
+		requested := askedMode
+		if requested == "" {
+			requested = "vector"
+		}
+		warnings.degraded(requested, "keyword", fmt.Sprintf("similar_to episode %s has no embedding", params.SimilarTo))
+	case mode == "vector" && params.Query != "" && !hasSemantic:
+		warnings.degraded("vector", "chronological", noSemantic)
+	case mode == "hybrid" && params.Query != "" && !hasSemantic:
+		warnings.degraded("hybrid", "keyword", noSemantic)
+	}
+	if params.Strict {
+		if err := warnings.strictError(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Scored results are ordered by relevance, everything else newest first
+	hasBM25 := needsKeyword && params.Query != ""
+	ranked := hasSemantic || hasBM25
+	fingerprint := searchFingerprint(params, hasSemantic)
+	kind := cursorTime
+	if ranked {
+		kind = cursorRank
+	}
+
+	// Diversity re-ranks by the stored vectors, so it needs semantic ranking
+	diverse := params.Diversity > 0 && hasSemantic
+	if params.Diversity > 0 && !diverse {
+		warnings.ignored("diversity", fmt.Sprintf("needs semantic ranking, not %q search mode", mode))
+	}
+	if diverse {
+		kind = cursorDiverse
+	}
+	if cur != nil && cur.Kind == cursorFallback {
+		if err := cur.check(cursorFallback, fingerprint); err != nil {
+			return nil, err
+		}
+		return s.contentFallbackSearch(ctx, params, fingerprint, cur, warnings)
+	}
+	if cur != nil {
+		if err := cur.check(kind, fingerprint); err != nil {
+			return nil, err
+		}
+	}
+	until := time.Now()
+	if cur != nil && cur.Until != nil {
+		until = *cur.Until
+	}
+
+	limit := params.MaxResults
+	if limit <= 0 {
+		limit = 10
+	}
+
+	// Semantic ranking considers only the pool nearest vectors (see
+	// vector.go). Later pages keep the first page's pool and strategy so
+	// the ranking they continue doesn't change under them.
+	pool, exact := candidatePool(limit), false
+	if cur != nil && cur.Pool > 0 {
+		pool, exact = cur.Pool, cur.Exact
+	}
+
+	// Diversified pages are slices of one re-ranked window (see mmr.go)
+	window, offset := mmrWindow(limit), 0
+	if cur != nil && cur.Window > 0 {
+		window, offset = cur.Window, cur.Offset
+	}
+
 	// Handle tag boost: build computed column with bind params before other conditions
 	hasTagBoost := len(params.Tags) > 0 && params.TagBoost > 0
 	var tagBoostExpr string
@@ -395,24 +739,39 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		tagBoostExpr = fmt.Sprintf("(%s) * 1.0 / %d.0", strings.Join(tagChecks, " + "), len(params.Tags))
 	}
 
+	// Score keyword matches from the keyword index. A query with no
+	// indexable terms (only digits or stop words) matches nothing here and
+	// is left to the substring fallback below.
+	var keywordFrom string
+	bm25Col := "NULL"
+	if hasBM25 {
+		if terms := keyword.QueryTerms(params.Query); len(terms) > 0 {
+			kwSQL, kwArgs := keywordScores(terms, argIdx)
+			args = append(args, kwArgs...)
+			argIdx += len(kwArgs)
+			keywordFrom = " LEFT JOIN " + kwSQL + " kw ON kw.episode_id = episodes.id"
+			bm25Col = "kw.score"
+		}
+	}
+
 	// Build the computed columns (similarity, bm25) based on mode
-	hasBM25 := needsFTS && params.Query != ""
 	var computedCols string
 	switch {
 	case hasSemantic && hasBM25:
+		// Keyword matches outside the candidate pool get their similarity
+		// computed directly; there are only as many as the query's terms hit
 		computedCols = fmt.Sprintf(`,
-			array_cosine_similarity(embedding, %s::FLOAT[768]) AS similarity,
-			fts_main_episodes.match_bm25(id, '%s', fields := 'content,name') AS bm25_score`,
-			string(embeddingJSON), sanitizeFTSQuery(params.Query))
+			COALESCE(1 - cand.distance, array_cosine_similarity(%s, %s)) AS similarity,
+			%s AS bm25_score,
+			cand.cand_id IS NOT NULL AS vec_hit`,
+			vecCol, queryVec, bm25Col)
 	case hasSemantic:
-		computedCols = fmt.Sprintf(`,
-			array_cosine_similarity(embedding, %s::FLOAT[768]) AS similarity`,
-			string(embeddingJSON))
+		computedCols = `,
+			1 - cand.distance AS similarity`
 	case hasBM25:
 		computedCols = fmt.Sprintf(`,
 			NULL AS similarity,
-			fts_main_episodes.match_bm25(id, '%s', fields := 'content,name') AS bm25_score`,
-			sanitizeFTSQuery(params.Query))
+			%s AS bm25_score`, bm25Col)
 	default:
 		computedCols = `,
 			NULL AS similarity`
@@ -423,11 +782,24 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		computedCols += fmt.Sprintf(", %s AS tag_match_ratio", tagBoostExpr)
 	}
 
-	innerSelect := fmt.Sprintf("SELECT %s%s FROM episodes WHERE 1=1", episodeCols, computedCols)
+	// Only join a space table and the vector candidates when we're actually
+	// doing semantic ranking. Hybrid search ranks candidates and keyword
+	// matches alike, so it keeps episodes that are either.
+	from := "episodes"
+	candidateJoin := ""
+	if hasSemantic {
+		from = vecFrom
+		candidateJoin = " JOIN candidates cand ON cand.cand_id = episodes.id"
+		if keywordFrom != "" {
+			candidateJoin = " LEFT JOIN candidates cand ON cand.cand_id = episodes.id"
+		}
+	}
+	from += candidateJoin + keywordFrom
+	innerSelect := fmt.Sprintf("SELECT %s%s FROM %s WHERE 1=1", episodeCols, computedCols, from)
 
 	// Only filter out NULL embeddings when we're actually doing semantic ranking
 	if hasSemantic {
-		conditions = append(conditions, "embedding IS NOT NULL")
+		conditions = append(conditions, vecCol+" IS NOT NULL")
 	}
 
 	// Group filter
@@ -449,10 +821,11 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		argIdx++
 	}
 
-	// Expired filter
-	if !params.IncludeExpired {
-		conditions = append(conditions, "(expired_at IS NULL OR expired_at > CURRENT_TIMESTAMP)")
-	}
+	// Expiry, as-of, and valid-time filters
+	validity, validityArgs := validityConditions(params, argIdx)
+	conditions = append(conditions, validity...)
+	args = append(args, validityArgs...)
+	argIdx += len(validityArgs)
 
 	// Source filter
 	if params.Source != "" {
@@ -470,20 +843,132 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		}
 	}
 
+	// Filter expression
+	if params.Filter != nil {
+		filterSQL, filterArgs, err := params.Filter.Compile(argIdx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		conditions = append(conditions, filterSQL)
+		args = append(args, filterArgs...)
+		argIdx += len(filterArgs)
+	}
+
+	// A seeded search never returns its seed
+	if params.SimilarTo != "" {
+		conditions = append(conditions, fmt.Sprintf("episodes.id <> $%d", argIdx))
+		args = append(args, params.SimilarTo)
+		argIdx++
+	}
+
+	// Pagination: a chronological page starts after the cursor's row; a
+	// ranked one is cut in the outer query below, once relevance is known,
+	// and sees only episodes that existed when the first page was read
+	switch {
+	case cur == nil:
+	case ranked:
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", argIdx))
+		args = append(args, until)
+		argIdx++
+	default:
+		conditions = append(conditions, fmt.Sprintf("(created_at < $%d OR (created_at = $%d AND id < $%d))", argIdx, argIdx, argIdx+1))
+		args = append(args, cur.CreatedAt, cur.ID)
+		argIdx += 2
+	}
+
+	// Exact candidates are found among the episodes these filters keep
+	filters := strings.Join(conditions, " AND ")
+	if hasSemantic && keywordFrom != "" {
+		conditions = append(conditions, "(cand.cand_id IS NOT NULL OR kw.episode_id IS NOT NULL)")
+	}
+
 	// Add conditions to inner query
 	if len(conditions) > 0 {
 		innerSelect += " AND " + strings.Join(conditions, " AND ")
 	}
 
-	// Tag boost addend for ORDER BY / relevance computation
-	tagBoostAddend := ""
+	// Ranking addends for ORDER BY / relevance computation: tag boost,
+	// recency, and importance. Each is kept whole so explain can report it.
+	tagTerm, recencyTerm, importanceTerm := "NULL", "NULL", "NULL"
 	if hasTagBoost {
-		tagBoostAddend = fmt.Sprintf(" + %f * COALESCE(s.tag_match_ratio, 0.0)", params.TagBoost)
+		tagTerm = fmt.Sprintf("%f * COALESCE(s.tag_match_ratio, 0.0)", params.TagBoost)
 	}
 
+	// Recency decays by half every half-life, measured from the as-of point
+	// or else from when the first page was read, so later pages rank the
+	// same way
+	if params.RecencyWeight > 0 {
+		now := until
+		if params.AsOf != nil {
+			now = *params.AsOf
+		}
+		halfLife := params.RecencyHalfLifeDays
+		if halfLife <= 0 {
+			halfLife = defaultRecencyHalfLifeDays
+		}
+		ts := "s.created_at"
+		if params.RecencyField == "valid_at" {
+			ts = "COALESCE(s.valid_at, s.created_at)"
+		}
+		recencyTerm = fmt.Sprintf("%f * pow(0.5, GREATEST(epoch($%d) - epoch(%s), 0) / %f)",
+			params.RecencyWeight, argIdx, ts, halfLife*86400)
+		args = append(args, now)
+		argIdx++
+	}
+
+	// Episodes written without an importance rank as middling
+	if params.ImportanceWeight > 0 {
+		importanceTerm = fmt.Sprintf("%f * COALESCE(s.importance, %f)", params.ImportanceWeight, defaultImportance)
+	}
+
+	rankAddend := ""
+	for _, term := range []string{tagTerm, recencyTerm, importanceTerm} {
+		if term != "NULL" {
+			rankAddend += " + " + term
+		}
+	}
+
+	// scoreCols renders a branch's similarity and relevance columns, and
+	// with explain the parts relevance was built from
+	bm25Raw, tagRatio := "NULL", "NULL"
+	if hasBM25 {
+		bm25Raw = "s.bm25_score"
+	}
+	if hasTagBoost {
+		tagRatio = "s.tag_match_ratio"
+	}
+	scoreCols := func(sc scoreParts) string {
+		if sc.base == "" {
+			// Unranked: nothing to explain
+			return "s.similarity, NULL AS relevance" + strings.Repeat(", NULL", explainColumns(params.Explain))
+		}
+		cols := fmt.Sprintf("s.similarity, %s%s AS relevance", sc.base, rankAddend)
+		if params.Explain {
+			cols += fmt.Sprintf(`,
+				%s AS x_bm25, %s AS x_sim_norm, %s AS x_bm25_norm, %s AS x_alpha,
+				%s AS x_tag_ratio, %s AS x_tag_boost, %s AS x_recency, %s AS x_importance,
+				%s AS x_vec_rank, %s AS x_kw_rank`,
+				bm25Raw, orNull(sc.simNorm), orNull(sc.bm25Norm), orNull(sc.alpha),
+				tagRatio, tagTerm, recencyTerm, importanceTerm,
+				orNull(sc.vecRank), orNull(sc.kwRank))
+		}
+		return cols
+	}
+
+	// Normalized BM25 over the keyword matches, as keyword ranking scores it
+	keywordNorm := `CASE WHEN b.max_bm25 = b.min_bm25 THEN 1.0
+		            WHEN s.bm25_score IS NOT NULL THEN
+		                (s.bm25_score - b.min_bm25) / (b.max_bm25 - b.min_bm25)
+		            ELSE 0.0 END`
+
 	// Build the final query based on mode
-	// All paths return: episodeCols, similarity, relevance (14 columns for scanEpisodes)
+	// All paths return: episodeCols, similarity, relevance (16 columns for
+	// scanEpisodes), then the explain columns when asked for
 	var query string
+	effective, fallback := mode, ""
+	if params.SimilarTo != "" && mode == "keyword" && askedMode != "keyword" {
+		fallback = "similar_to_keyword"
+	}
 	switch {
 	case mode == "keyword" && hasBM25:
 		query = fmt.Sprintf(`WITH scored AS (%s),
@@ -491,15 +976,10 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 				SELECT MIN(bm25_score) AS min_bm25, MAX(bm25_score) AS max_bm25
 				FROM scored WHERE bm25_score IS NOT NULL
 			)
-			SELECT %s, s.similarity,
-			       CASE WHEN b.max_bm25 = b.min_bm25 THEN 1.0
-			            WHEN s.bm25_score IS NOT NULL THEN
-			                (s.bm25_score - b.min_bm25) / (b.max_bm25 - b.min_bm25)
-			            ELSE 0.0 END%s AS relevance
+			SELECT %s, %s
 			FROM scored s, bm25_stats b
-			WHERE s.bm25_score IS NOT NULL
-			ORDER BY relevance DESC`,
-			innerSelect, episodeCols, tagBoostAddend)
+			WHERE s.bm25_score IS NOT NULL`,
+			innerSelect, episodeCols, scoreCols(scoreParts{base: keywordNorm, bm25Norm: keywordNorm}))
 
 	case mode == "hybrid" && hasBM25:
 		// Default alpha to 0.7 when not explicitly set (Go zero-value).
@@ -509,119 +989,246 @@ func (s *Store) Search(ctx context.Context, params models.SearchParams) ([]model
 		}
 
 		if hasSemantic {
-			query = fmt.Sprintf(`WITH scored AS (%s),
-				bm25_stats AS (
-					SELECT MIN(bm25_score) AS min_bm25, MAX(bm25_score) AS max_bm25
-					FROM scored WHERE bm25_score IS NOT NULL
-				),
-				cosine_stats AS (
-					SELECT MIN(similarity) AS min_cos, MAX(similarity) AS max_cos
-					FROM scored WHERE similarity IS NOT NULL
-				),
-				hybrid AS (
+			// Each retriever contributes its own top results: the vector
+			// candidates, and the best pool keyword matches. Those two lists
+			// are fused, each scored only against its own members.
+			lists := fmt.Sprintf(`WITH scored AS (%s),
+				lists AS (
 					SELECT s.*,
-					       CASE
-					           WHEN b.max_bm25 = b.min_bm25 THEN
-					               CASE WHEN s.bm25_score IS NOT NULL THEN 1.0 ELSE 0.0 END
-					           WHEN s.bm25_score IS NOT NULL THEN
-					               (s.bm25_score - b.min_bm25) / (b.max_bm25 - b.min_bm25)
-					           ELSE 0.0
-					       END AS norm_bm25,
-					       CASE
-					           WHEN c.max_cos = c.min_cos THEN
-					               CASE WHEN s.similarity IS NOT NULL THEN 1.0 ELSE 0.0 END
-					           WHEN s.similarity IS NOT NULL THEN
-					               (s.similarity - c.min_cos) / (c.max_cos - c.min_cos)
-					           ELSE 0.0
-					       END AS norm_cosine
-					FROM scored s, bm25_stats b, cosine_stats c
-					WHERE s.bm25_score IS NOT NULL OR s.similarity IS NOT NULL
-				)
-				SELECT %s, similarity,
-				       (%f * norm_cosine + %f * norm_bm25)%s AS relevance
-				FROM hybrid s
-				ORDER BY relevance DESC`,
-				innerSelect, episodeCols, alpha, 1.0-alpha, tagBoostAddend)
+					       CASE WHEN s.vec_hit THEN
+					           row_number() OVER (PARTITION BY s.vec_hit ORDER BY s.similarity DESC, s.id) END AS vec_rank,
+					       CASE WHEN s.bm25_score IS NOT NULL THEN
+					           row_number() OVER (PARTITION BY s.bm25_score IS NOT NULL ORDER BY s.bm25_score DESC, s.id) END AS kw_rank
+					FROM scored s
+				),
+				fused AS (
+					SELECT * REPLACE (CASE WHEN kw_rank <= %d THEN kw_rank END AS kw_rank)
+					FROM lists
+					WHERE vec_rank IS NOT NULL OR kw_rank <= %d
+				)`, innerSelect, pool, pool)
+
+			if params.Fusion == "rrf" {
+				// Reciprocal rank fusion, scaled so ranking first in both
+				// lists scores 1.0
+				rrf := fmt.Sprintf(`(COALESCE(1.0 / (%d + s.vec_rank), 0.0) + COALESCE(1.0 / (%d + s.kw_rank), 0.0))
+					           * %d / 2.0`, rrfK, rrfK, rrfK+1)
+				query = fmt.Sprintf(`%s
+					SELECT %s, %s
+					FROM fused s`,
+					lists, episodeCols, scoreCols(scoreParts{base: rrf, vecRank: "s.vec_rank", kwRank: "s.kw_rank"}))
+			} else {
+				simNorm := `CASE WHEN s.vec_rank IS NULL THEN 0.0
+					                  WHEN f.max_cos = f.min_cos THEN 1.0
+					                  ELSE (s.similarity - f.min_cos) / (f.max_cos - f.min_cos) END`
+				bm25Norm := `CASE WHEN s.kw_rank IS NULL THEN 0.0
+					                  WHEN f.max_bm25 = f.min_bm25 THEN 1.0
+					                  ELSE (s.bm25_score - f.min_bm25) / (f.max_bm25 - f.min_bm25) END`
+				query = fmt.Sprintf(`%s,
+					fused_stats AS (
+						SELECT MIN(similarity) FILTER (WHERE vec_rank IS NOT NULL) AS min_cos,
+						       MAX(similarity) FILTER (WHERE vec_rank IS NOT NULL) AS max_cos,
+						       MIN(bm25_score) FILTER (WHERE kw_rank IS NOT NULL) AS min_bm25,
+						       MAX(bm25_score) FILTER (WHERE kw_rank IS NOT NULL) AS max_bm25
+						FROM fused
+					)
+					SELECT %s, %s
+					FROM fused s, fused_stats f`,
+					lists, episodeCols, scoreCols(scoreParts{
+						base:     fmt.Sprintf("(%f * %s + %f * %s)", alpha, simNorm, 1.0-alpha, bm25Norm),
+						simNorm:  simNorm,
+						bm25Norm: bm25Norm,
+						alpha:    fmt.Sprintf("%f", alpha),
+						vecRank:  "s.vec_rank",
+						kwRank:   "s.kw_rank",
+					}))
+			}
 		} else {
 			// No embedding — hybrid degrades to keyword
+			effective, fallback = "keyword", "hybrid_to_keyword"
 			query = fmt.Sprintf(`WITH scored AS (%s),
 				bm25_stats AS (
 					SELECT MIN(bm25_score) AS min_bm25, MAX(bm25_score) AS max_bm25
 					FROM scored WHERE bm25_score IS NOT NULL
 				)
-				SELECT %s, s.similarity,
-				       CASE WHEN b.max_bm25 = b.min_bm25 THEN 1.0
-				            WHEN s.bm25_score IS NOT NULL THEN
-				                (s.bm25_score - b.min_bm25) / (b.max_bm25 - b.min_bm25)
-				            ELSE 0.0 END%s AS relevance
+				SELECT %s, %s
 				FROM scored s, bm25_stats b
-				WHERE s.bm25_score IS NOT NULL
-				ORDER BY relevance DESC`,
-				innerSelect, episodeCols, tagBoostAddend)
+				WHERE s.bm25_score IS NOT NULL`,
+				innerSelect, episodeCols, scoreCols(scoreParts{base: keywordNorm, bm25Norm: keywordNorm}))
 		}
 
 	default: // vector mode, or any mode without a query
 		if hasSemantic {
+			effective = "vector"
+			simNorm := `CASE WHEN c.max_cos = c.min_cos THEN
+				               CASE WHEN s.similarity IS NOT NULL THEN 1.0 ELSE NULL END
+				            WHEN s.similarity IS NOT NULL THEN
+				               (s.similarity - c.min_cos) / (c.max_cos - c.min_cos)
+				            ELSE NULL END`
 			query = fmt.Sprintf(`WITH scored AS (%s),
 				cosine_stats AS (
 					SELECT MIN(similarity) AS min_cos, MAX(similarity) AS max_cos
 					FROM scored WHERE similarity IS NOT NULL
 				)
-				SELECT %s, s.similarity,
-				       CASE WHEN c.max_cos = c.min_cos THEN
-				               CASE WHEN s.similarity IS NOT NULL THEN 1.0 ELSE NULL END
-				            WHEN s.similarity IS NOT NULL THEN
-				               (s.similarity - c.min_cos) / (c.max_cos - c.min_cos)
-				            ELSE NULL END%s AS relevance
+				SELECT %s, %s
 				FROM scored s, cosine_stats c`,
-				innerSelect, episodeCols, tagBoostAddend)
+				innerSelect, episodeCols, scoreCols(scoreParts{base: simNorm, simNorm: simNorm}))
 
 			if params.MinSimilarity > 0 {
 				query += fmt.Sprintf(" WHERE s.similarity >= %f", params.MinSimilarity)
 			}
-			query += " ORDER BY relevance DESC"
 		} else {
-			query = fmt.Sprintf("WITH scored AS (%s) SELECT %s, s.similarity, NULL AS relevance FROM scored s ORDER BY s.created_at DESC",
-				innerSelect, episodeCols)
+			// Without an embedding a vector search lists newest first
+			effective = "chronological"
+			if mode == "vector" && params.Query != "" {
+				fallback = "vector_to_chronological"
+			}
+			query = fmt.Sprintf("WITH scored AS (%s) SELECT %s, %s FROM scored s",
+				innerSelect, episodeCols, scoreCols(scoreParts{}))
 		}
 	}
 
-	// Apply LIMIT
-	if params.MaxResults > 0 {
-		query += fmt.Sprintf(" LIMIT %d", params.MaxResults)
+	// Order and cut the page in an outer query, where relevance is a plain
+	// column. One extra row tells whether there is a next page; a diversified
+	// search fetches its whole window instead.
+	fetch := limit + 1
+	if diverse {
+		fetch = window
+	}
+	query = fmt.Sprintf("SELECT * FROM (%s) page", query)
+	if ranked {
+		if cur != nil && !diverse {
+			query += fmt.Sprintf(" WHERE COALESCE(relevance, -1) < $%d OR (COALESCE(relevance, -1) = $%d AND id > $%d)",
+				argIdx, argIdx, argIdx+1)
+			args = append(args, cur.Relevance, cur.ID)
+		}
+		query += " ORDER BY COALESCE(relevance, -1) DESC, id"
 	} else {
-		query += " LIMIT 10"
+		query += " ORDER BY created_at DESC, id DESC"
+	}
+	query += fmt.Sprintf(" LIMIT %d", fetch)
+
+	// Chunked episodes are candidates by their nearest chunks too, each at
+	// the nearer of its own vector and its best chunk (see chunks.go)
+	chunks := newChunkSource(source)
+	candidateCTEs := func() string {
+		candidates := source.approximateCandidates(queryVec, pool)
+		chunkHits := chunks.approximateHits(queryVec, pool)
+		if exact {
+			candidates = exactCandidates(vecCol, vecFrom, filters, queryVec, pool)
+			chunkHits = chunks.exactHits(queryVec,
+				fmt.Sprintf("SELECT episodes.id FROM %s WHERE %s", vecFrom, filters), pool)
+		}
+		return fmt.Sprintf(`WITH vector_hits AS (%s), chunk_hits AS (%s),
+			candidates AS (SELECT cand_id, MIN(distance) AS distance
+				FROM (SELECT * FROM vector_hits
+					UNION ALL SELECT c.episode_id, c.distance FROM chunk_hits c WHERE %s) GROUP BY cand_id)`,
+			candidates, chunkHits, chunks.live())
+	}
+	run := func() ([]models.Episode, error) {
+		q := query
+		if hasSemantic {
+			q = candidateCTEs() + " " + query
+		}
+		rows, err := s.db.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute search query: %w", err)
+		}
+		defer rows.Close()
+		return s.scanEpisodes(rows, params.Explain)
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	episodes, err := run()
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute search query: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	episodes, err := s.scanEpisodes(rows)
-	if err != nil {
-		return nil, err
+	// A short first page may mean the filters discarded most of the pool;
+	// if they discarded any of a full pool, look again among only the
+	// episodes they keep. The page is counted too, only so the statement
+	// binds every argument.
+	if hasSemantic && !exact && cur == nil && len(episodes) < fetch {
+		var vectorHits, chunkHits, candidates, kept, paged int
+		err := s.db.QueryRowContext(ctx, fmt.Sprintf(`%s, page AS (%s)
+			SELECT (SELECT COUNT(*) FROM vector_hits), (SELECT COUNT(*) FROM chunk_hits),
+			       (SELECT COUNT(*) FROM candidates),
+			       (SELECT COUNT(*) FROM %s JOIN candidates cand ON cand.cand_id = episodes.id WHERE %s),
+			       (SELECT COUNT(*) FROM page)`,
+			candidateCTEs(), query, vecFrom, filters), args...).Scan(&vectorHits, &chunkHits, &candidates, &kept, &paged)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count search candidates: %w", err)
+		}
+		if kept < candidates && (vectorHits >= pool || chunkHits >= pool) {
+			exact = true
+			exactSearches.Add(1)
+			if episodes, err = run(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// Keyword fallback: BM25 cannot index pure numeric tokens (DuckDB FTS limitation).
-	// When keyword mode returns no results and the query is non-empty, fall back to
-	// ILIKE content search to catch account IDs, ticket numbers, and other identifiers.
-	if len(episodes) == 0 && mode == "keyword" && params.Query != "" {
-		episodes, err = s.contentFallbackSearch(ctx, params)
+	if diverse {
+		vectors, err := s.searchVectors(ctx, episodes)
 		if err != nil {
 			return nil, err
 		}
+		episodes = diversify(episodes, vectors, params.Diversity)
+		if offset >= len(episodes) {
+			episodes = nil
+		} else {
+			episodes = episodes[offset:]
+		}
 	}
 
-	return episodes, nil
+	if hasSemantic {
+		if err := s.attachSnippets(ctx, chunks, queryVec, episodes); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keyword fallback: the keyword index has no terms for pure numeric tokens.
+	// When keyword mode returns no results and the query is non-empty, fall back to
+	// ILIKE content search to catch account IDs, ticket numbers, and other identifiers.
+	// Only a first page falls back; later pages carry a fallback cursor, and
+	// a seeded search's whole-content query has nothing to gain from it.
+	if len(episodes) == 0 && mode == "keyword" && params.Query != "" && cur == nil && params.SimilarTo == "" {
+		return s.contentFallbackSearch(ctx, params, fingerprint, nil, warnings)
+	}
+
+	if fallback != "" {
+		for _, ep := range episodes {
+			if ep.Explanation != nil {
+				ep.Explanation.Fallback = fallback
+			}
+		}
+	}
+
+	page := paginate(episodes, limit, func(last models.Episode) cursor {
+		next := cursor{Kind: kind, Search: fingerprint, ID: last.ID, CreatedAt: last.CreatedAt}
+		if ranked {
+			next.Relevance = -1
+			if last.Relevance != nil {
+				next.Relevance = *last.Relevance
+			}
+			next.Until = &until
+		}
+		if hasSemantic {
+			next.Pool, next.Exact = pool, exact
+		}
+		if diverse {
+			next.Window, next.Offset = window, offset+limit
+		}
+		return next
+	})
+	page.SearchModeEffective = effective
+	page.Warnings = warnings.list()
+	return page, nil
 }
 
 // contentFallbackSearch performs an ILIKE content search as a fallback when BM25
 // cannot match the query (e.g. pure numeric tokens). Returns results with similarity
 // nil and relevance hardcoded to 1.0 — ILIKE is a binary match with no ranking signal,
-// so all fallback results are treated as equally relevant.
-func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchParams) ([]models.Episode, error) {
+// so all fallback results are treated as equally relevant, newest first.
+func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchParams, fingerprint string, cur *cursor, warnings searchWarnings) (*models.SearchPage, error) {
 	var conditions []string
 	var args []interface{}
 	argIdx := 1
@@ -647,9 +1254,10 @@ func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchP
 		args = append(args, *params.After)
 		argIdx++
 	}
-	if !params.IncludeExpired {
-		conditions = append(conditions, "(expired_at IS NULL OR expired_at > CURRENT_TIMESTAMP)")
-	}
+	validity, validityArgs := validityConditions(params, argIdx)
+	conditions = append(conditions, validity...)
+	args = append(args, validityArgs...)
+	argIdx += len(validityArgs)
 	if params.Source != "" {
 		conditions = append(conditions, fmt.Sprintf("source = $%d", argIdx))
 		args = append(args, params.Source)
@@ -662,6 +1270,19 @@ func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchP
 			argIdx++
 		}
 	}
+	if params.Filter != nil {
+		filterSQL, filterArgs, err := params.Filter.Compile(argIdx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		conditions = append(conditions, filterSQL)
+		args = append(args, filterArgs...)
+		argIdx += len(filterArgs)
+	}
+	if cur != nil {
+		conditions = append(conditions, fmt.Sprintf("(created_at < $%d OR (created_at = $%d AND id < $%d))", argIdx, argIdx, argIdx+1))
+		args = append(args, cur.CreatedAt, cur.ID)
+	}
 
 	where := strings.Join(conditions, " AND ")
 	limit := params.MaxResults
@@ -670,8 +1291,8 @@ func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchP
 	}
 
 	query := fmt.Sprintf(
-		"SELECT %s, NULL AS similarity, 1.0 AS relevance FROM episodes WHERE %s ORDER BY created_at DESC LIMIT %d",
-		episodeCols, where, limit,
+		"SELECT %s, NULL AS similarity, 1.0 AS relevance FROM episodes WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d",
+		episodeCols, where, limit+1,
 	)
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -679,14 +1300,28 @@ func (s *Store) contentFallbackSearch(ctx context.Context, params models.SearchP
 		return nil, fmt.Errorf("failed to execute fallback search: %w", err)
 	}
 	defer rows.Close()
-	return s.scanEpisodes(rows)
+	episodes, err := s.scanEpisodes(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	if params.Explain {
+		for i := range episodes {
+			episodes[i].Explanation = &models.Explanation{Fallback: "ilike"}
+		}
+	}
+	page := paginate(episodes, limit, func(last models.Episode) cursor {
+		return cursor{Kind: cursorFallback, Search: fingerprint, ID: last.ID, CreatedAt: last.CreatedAt}
+	})
+	page.SearchModeEffective = "substring"
+	page.Warnings = warnings.list()
+	return page, nil
 }
 
 // GetEpisode retrieves a single episode by ID
 func (s *Store) GetEpisode(ctx context.Context, id string) (*models.Episode, error) {
 	query := `
 		SELECT id, content, name, source, source_model, source_description,
-		       group_id, tags, created_at, valid_at, expired_at, metadata
+		       group_id, tags, created_at, valid_at, expired_at, metadata, importance, COALESCE(key, '')
 		FROM episodes
 		WHERE id = ?
 	`
@@ -694,7 +1329,7 @@ func (s *Store) GetEpisode(ctx context.Context, id string) (*models.Episode, err
 	row := s.db.QueryRowContext(ctx, query, id)
 	ep, err := s.scanEpisode(row)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("episode not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrEpisodeNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get episode: %w", err)
@@ -703,7 +1338,58 @@ func (s *Store) GetEpisode(ctx context.Context, id string) (*models.Episode, err
 	return ep, nil
 }
 
-// UpdateEpisode modifies an existing episode
+// GetEpisodesByID retrieves episodes by ID, expired ones included, in the
+// order requested. IDs that don't exist are left out. With withEmbedding,
+// each episode carries the vector search uses and the model that produced it.
+func (s *Store) GetEpisodesByID(ctx context.Context, ids []string, withEmbedding bool) ([]*models.Episode, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	vec, model, from := s.exportVector()
+	vecCols := "NULL, NULL"
+	if withEmbedding {
+		vecCols = fmt.Sprintf("CAST(to_json(%s) AS VARCHAR), %s", vec, model)
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE episodes.id IN (%s)",
+		exportColumns, vecCols, from, strings.Join(placeholders, ", "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episodes: %w", err)
+	}
+	found, err := scanExported(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episodes: %w", err)
+	}
+
+	byID := make(map[string]*models.Episode, len(found))
+	for _, ep := range found {
+		byID[ep.ID] = ep
+	}
+	episodes := make([]*models.Episode, 0, len(found))
+	for _, id := range ids {
+		if ep, ok := byID[id]; ok {
+			episodes = append(episodes, ep)
+			delete(byID, id) // a repeated ID is returned once
+		}
+	}
+	return episodes, nil
+}
+
+// ErrEpisodeNotFound is returned when the episode to read, update or delete
+// does not exist
+var ErrEpisodeNotFound = errors.New("episode not found")
+
+// UpdateEpisode modifies an existing episode in one transaction. A content
+// or name edit records the prior text in episode_versions (see versions.go).
 func (s *Store) UpdateEpisode(ctx context.Context, id string, params models.UpdateParams) error {
 	var updates []string
 	var args []interface{}
@@ -725,31 +1411,57 @@ func (s *Store) UpdateEpisode(ctx context.Context, id string, params models.Upda
 		args = append(args, *params.Metadata)
 	}
 
-	if len(updates) == 0 {
+	edit := params.Content != nil || params.Name != nil
+	if len(updates) == 0 && !edit {
 		return fmt.Errorf("no updates provided")
 	}
 
-	args = append(args, id)
-	query := fmt.Sprintf("UPDATE episodes SET %s WHERE id = ?", strings.Join(updates, ", "))
-
-	result, err := s.db.ExecContext(ctx, query, args...)
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update episode: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	if params.ExpiredAt != nil && params.ExpiredAt.After(time.Now()) {
+		if err := checkKeyRevival(ctx, tx, id); err != nil {
+			return err
+		}
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("episode not found: %s", id)
+	if edit {
+		set, setArgs, err := s.editEpisode(ctx, tx, id, params)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, set...)
+		args = append(args, setArgs...)
 	}
 
-	s.ftsMu.Lock()
-	s.ftsStale = true
-	s.ftsMu.Unlock()
+	// An edit that changes nothing has already found the episode
+	if len(updates) > 0 {
+		args = append(args, id)
+		query := fmt.Sprintf("UPDATE episodes SET %s WHERE id = ?", strings.Join(updates, ", "))
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to update episode: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return fmt.Errorf("%w: %s", ErrEpisodeNotFound, id)
+		}
+	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update: %w", err)
+	}
 	return nil
 }
 
@@ -765,58 +1477,16 @@ func (s *Store) CountEpisodes(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// DeleteEpisode removes an episode from the store
+// DeleteEpisode permanently removes an episode and its vectors in every
+// embedding space, recording the deletion in the audit log
 func (s *Store) DeleteEpisode(ctx context.Context, id string) error {
-	result, err := s.db.ExecContext(ctx, "DELETE FROM episodes WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("failed to delete episode: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
+	n, err := s.DeleteEpisodes(ctx, []string{id}, Deletion{})
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rows == 0 {
-		return fmt.Errorf("episode not found: %s", id)
-	}
-
-	s.ftsMu.Lock()
-	s.ftsStale = true
-	s.ftsMu.Unlock()
-
-	return nil
-}
-
-// rebuildFTSIndex rebuilds the full-text search index on the episodes table.
-// Must be called with ftsMu held.
-//
-// Scaling note: This does a full rebuild (drop + re-index) because DuckDB FTS
-// doesn't support incremental updates. Cost is O(rows × avg_text_length):
-//   - <1K episodes: sub-second
-//   - 1K–10K: 1–5s (noticeable on first search after a write)
-//   - 10K+: consider alternative indexing strategy
-func (s *Store) rebuildFTSIndex() error {
-	_, err := s.db.Exec("PRAGMA create_fts_index('episodes', 'id', 'content', 'name', overwrite=1)")
-	if err != nil {
-		return fmt.Errorf("failed to rebuild FTS index: %w", err)
-	}
-	return nil
-}
-
-// ensureFTSIndex rebuilds the FTS index if it is stale.
-func (s *Store) ensureFTSIndex() error {
-	s.ftsMu.Lock()
-	defer s.ftsMu.Unlock()
-
-	if !s.ftsStale {
-		return nil
-	}
-
-	if err := s.rebuildFTSIndex(); err != nil {
 		return err
 	}
-	s.ftsStale = false
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrEpisodeNotFound, id)
+	}
 	return nil
 }
 
@@ -827,34 +1497,6 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
-// sanitizeFTSQuery escapes a user query for safe use in DuckDB's match_bm25().
-// The result is interpolated into a SQL string literal ('...'), so single quotes
-// are escaped. FTS operators and SQL metacharacters are stripped as defense-in-depth.
-//
-// Note: single-quote escaping (” inside a SQL string) is the primary injection
-// defense — it prevents breaking out of the string literal. The other stripping
-// is belt-and-suspenders.
-func sanitizeFTSQuery(s string) string {
-	// Remove multi-char SQL metacharacters first (before individual char stripping)
-	s = strings.ReplaceAll(s, "--", "")
-	s = strings.ReplaceAll(s, "/*", "")
-	s = strings.ReplaceAll(s, "*/", "")
-	// Strip FTS query syntax and SQL metacharacters
-	replacer := strings.NewReplacer(
-		`\`, ``,
-		`"`, ``,
-		`+`, ``,
-		`-`, ``,
-		`*`, ``,
-		`(`, ``,
-		`)`, ``,
-		`;`, ``,
-	)
-	s = replacer.Replace(s)
-	// Escape single quotes for SQL string literal safety
-	return strings.ReplaceAll(s, "'", "''")
-}
-
 // Helper functions for scanning rows
 
 func (s *Store) scanEpisode(row *sql.Row) (*models.Episode, error) {
@@ -863,7 +1505,7 @@ func (s *Store) scanEpisode(row *sql.Row) (*models.Episode, error) {
 
 	err := row.Scan(
 		&ep.ID, &ep.Content, &ep.Name, &ep.Source, &ep.SourceModel, &ep.SourceDescription,
-		&ep.GroupID, &tagsRaw, &ep.CreatedAt, &ep.ValidAt, &ep.ExpiredAt, &metadataRaw,
+		&ep.GroupID, &tagsRaw, &ep.CreatedAt, &ep.ValidAt, &ep.ExpiredAt, &metadataRaw, &ep.Importance, &ep.Key,
 	)
 	if err != nil {
 		return nil, err
@@ -899,7 +1541,9 @@ func (s *Store) scanEpisode(row *sql.Row) (*models.Episode, error) {
 	return &ep, nil
 }
 
-func (s *Store) scanEpisodes(rows *sql.Rows) ([]models.Episode, error) {
+// scanEpisodes reads search results; with explain, each row carries the
+// explain columns after relevance
+func (s *Store) scanEpisodes(rows *sql.Rows, explain bool) ([]models.Episode, error) {
 	var episodes []models.Episode
 
 	for rows.Next() {
@@ -907,12 +1551,17 @@ func (s *Store) scanEpisodes(rows *sql.Rows) ([]models.Episode, error) {
 		var tagsRaw, metadataRaw interface{}
 		var similarity, relevance sql.NullFloat64
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&ep.ID, &ep.Content, &ep.Name, &ep.Source, &ep.SourceModel, &ep.SourceDescription,
-			&ep.GroupID, &tagsRaw, &ep.CreatedAt, &ep.ValidAt, &ep.ExpiredAt, &metadataRaw,
+			&ep.GroupID, &tagsRaw, &ep.CreatedAt, &ep.ValidAt, &ep.ExpiredAt, &metadataRaw, &ep.Importance, &ep.Key,
 			&similarity, &relevance,
-		)
-		if err != nil {
+		}
+		var x explainRow
+		if explain {
+			x = newExplainRow()
+			dest = append(dest, x.targets()...)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 		if similarity.Valid {
@@ -921,6 +1570,9 @@ func (s *Store) scanEpisodes(rows *sql.Rows) ([]models.Episode, error) {
 		if relevance.Valid {
 			ep.Relevance = &relevance.Float64
 		}
+		if explain {
+			ep.Explanation = x.explanation(ep.Similarity)
+		}
 
 		// Parse tags - DuckDB returns VARCHAR[] as []interface{}
 		if tagsRaw != nil {
@@ -2,11 +2,13 @@ package db
 
 import (
 	"context"
+	"math"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/oscillatelabsllc/engram/internal/filter"
 	"github.com/oscillatelabsllc/engram/internal/models"
 )
 
@@ -140,6 +142,50 @@ func TestInsertEpisode(t *testing.T) {
 	})
 }
 
+func TestInsertEpisodes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	t.Run("stores all episodes and queues them for embedding", func(t *testing.T) {
+		eps := []*models.Episode{
+			{Content: "one", Source: "import"},
+			{Content: "two", Source: "import"},
+		}
+		if err := store.InsertEpisodes(ctx, eps); err != nil {
+			t.Fatalf("InsertEpisodes failed: %v", err)
+		}
+		for _, ep := range eps {
+			if _, err := store.GetEpisode(ctx, ep.ID); err != nil {
+				t.Errorf("Episode %s not stored: %v", ep.ID, err)
+			}
+		}
+		if n, _ := store.CountQueuedEmbeddings(ctx); n != 2 {
+			t.Errorf("Expected 2 queued embeddings, got %d", n)
+		}
+	})
+
+	t.Run("a failing episode rolls back the whole batch", func(t *testing.T) {
+		before, _ := store.CountEpisodes(ctx)
+		dup := &models.Episode{Content: "dup", Source: "import"}
+		if err := store.InsertEpisode(ctx, dup); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		eps := []*models.Episode{
+			{Content: "fresh", Source: "import"},
+			{ID: dup.ID, Content: "dup again", Source: "import"},
+		}
+		if err := store.InsertEpisodes(ctx, eps); err == nil {
+			t.Fatal("Expected duplicate ID to fail the batch")
+		}
+		after, _ := store.CountEpisodes(ctx)
+		if after != before+1 {
+			t.Errorf("Expected only the separately inserted episode to remain, got %d -> %d", before, after)
+		}
+	})
+}
+
 func TestGetEpisode(t *testing.T) {
 	store := setupTestStore(t)
 	defer store.Close()
@@ -154,6 +200,50 @@ func TestGetEpisode(t *testing.T) {
 	})
 }
 
+func TestGetEpisodesByID(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	emb := make([]float32, 768)
+	emb[0] = 1
+
+	live := &models.Episode{Content: "live", Source: "test", Embedding: emb, EmbeddingModel: "test-model"}
+	expired := &models.Episode{Content: "expired", Source: "test", ExpiredAt: &past}
+	for _, ep := range []*models.Episode{live, expired} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	t.Run("keeps request order and includes expired", func(t *testing.T) {
+		got, err := store.GetEpisodesByID(ctx, []string{expired.ID, "missing", live.ID, expired.ID}, false)
+		if err != nil {
+			t.Fatalf("GetEpisodesByID failed: %v", err)
+		}
+		if len(got) != 2 || got[0].ID != expired.ID || got[1].ID != live.ID {
+			t.Fatalf("Expected [expired, live], got %+v", got)
+		}
+		if !got[0].IsExpired(time.Now()) || got[1].IsExpired(time.Now()) {
+			t.Error("Expiry not reported correctly")
+		}
+		if got[1].Embedding != nil {
+			t.Error("Embedding should be omitted unless requested")
+		}
+	})
+
+	t.Run("includes embedding on request", func(t *testing.T) {
+		got, err := store.GetEpisodesByID(ctx, []string{live.ID}, true)
+		if err != nil {
+			t.Fatalf("GetEpisodesByID failed: %v", err)
+		}
+		if len(got) != 1 || len(got[0].Embedding) != 768 || got[0].Embedding[0] != 1 || got[0].EmbeddingModel != "test-model" {
+			t.Errorf("Expected the stored vector, got %+v", got)
+		}
+	})
+}
+
 func TestSearch(t *testing.T) {
 	store := setupTestStore(t)
 	defer store.Close()
@@ -665,7 +755,7 @@ func TestSearchKeywordMode(t *testing.T) {
 		}
 	}
 
-	t.Run("keyword search returns FTS results", func(t *testing.T) {
+	t.Run("keyword search returns BM25 results", func(t *testing.T) {
 		results, err := store.Search(ctx, models.SearchParams{
 			Query:      "lazy",
 			SearchMode: "keyword",
@@ -834,6 +924,37 @@ func TestSearchHybridMode(t *testing.T) {
 			t.Errorf("Expected cat episode first with alpha=1.0, got %q", results[0].Content)
 		}
 	})
+
+	t.Run("rrf fuses ranks", func(t *testing.T) {
+		queryEmbed := make([]float32, 768)
+		queryEmbed[0] = 1.0
+
+		results, err := store.Search(ctx, models.SearchParams{
+			Query:          "fox",
+			QueryEmbedding: queryEmbed,
+			SearchMode:     "hybrid",
+			Fusion:         "rrf",
+			MaxResults:     10,
+		})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected all 3 episodes, got %d", len(results))
+		}
+
+		// Both fox episodes rank 1st and 2nd across the two lists, in some
+		// order; the cat ranks 3rd for meaning and not at all for words
+		want := []float64{(1.0/61 + 1.0/62) * 61 / 2, (1.0/61 + 1.0/62) * 61 / 2, 1.0 / 63 * 61 / 2}
+		for i, r := range results {
+			if r.Relevance == nil || math.Abs(*r.Relevance-want[i]) > 1e-6 {
+				t.Errorf("Result %d (%q): expected relevance %.4f, got %v", i, r.Content, want[i], r.Relevance)
+			}
+		}
+		if !containsWord(results[2].Content, "cat") {
+			t.Errorf("Expected cat episode last, got %q", results[2].Content)
+		}
+	})
 }
 
 func TestSearchDefaultModeUnchanged(t *testing.T) {
@@ -876,7 +997,7 @@ func TestSearchDefaultModeUnchanged(t *testing.T) {
 	}
 }
 
-func TestFTSIndexLazyRebuild(t *testing.T) {
+func TestKeywordIndexTracksInserts(t *testing.T) {
 	store := setupTestStore(t)
 	defer store.Close()
 
@@ -891,7 +1012,7 @@ func TestFTSIndexLazyRebuild(t *testing.T) {
 		t.Fatalf("Failed to insert: %v", err)
 	}
 
-	// Search with keyword mode — should find it (triggers initial FTS index build)
+	// Search with keyword mode — should find it
 	results, err := store.Search(ctx, models.SearchParams{
 		Query:      "databases",
 		SearchMode: "keyword",
@@ -904,7 +1025,7 @@ func TestFTSIndexLazyRebuild(t *testing.T) {
 		t.Fatalf("Expected 1 result after first insert, got %d", len(results))
 	}
 
-	// Insert second episode — should mark FTS as stale
+	// Insert second episode — indexed in the same transaction
 	ep2 := &models.Episode{
 		Content: "New content about databases and caching",
 		Source:  "test",
@@ -913,7 +1034,7 @@ func TestFTSIndexLazyRebuild(t *testing.T) {
 		t.Fatalf("Failed to insert: %v", err)
 	}
 
-	// Search again — should rebuild index and find both
+	// Search again — should find both without any rebuild
 	results, err = store.Search(ctx, models.SearchParams{
 		Query:      "databases",
 		SearchMode: "keyword",
@@ -923,7 +1044,7 @@ func TestFTSIndexLazyRebuild(t *testing.T) {
 		t.Fatalf("Search failed: %v", err)
 	}
 	if len(results) != 2 {
-		t.Fatalf("Expected 2 results after second insert and rebuild, got %d", len(results))
+		t.Fatalf("Expected 2 results after second insert, got %d", len(results))
 	}
 }
 
@@ -976,34 +1097,63 @@ func TestSearchKeywordWithFilters(t *testing.T) {
 	})
 }
 
-func TestSanitizeFTSQuery(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
+func TestSearchFilterExpression(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	episodes := []*models.Episode{
+		{Content: "Alpha rollout plan", Source: "cli", GroupID: "work", Tags: []string{"deploy"}, Metadata: `{"priority": 3, "ticket": {"status": "open"}}`},
+		{Content: "Alpha postmortem", Source: "cli", GroupID: "work", Tags: []string{"incident", "deploy"}, Metadata: `{"priority": 1}`},
+		{Content: "Alpha reading list", Source: "web", GroupID: "home", Tags: []string{"books"}},
+	}
+	for _, ep := range episodes {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	cases := []struct {
+		filter string
+		want   []int // indexes into episodes
 	}{
-		{"plain text", "hello world", "hello world"},
-		{"single quotes escaped", "it's a test", "it''s a test"},
-		{"FTS operators stripped", "+foo -bar", "foo bar"},
-		{"wildcards stripped", "test*", "test"},
-		{"double quotes stripped", `"exact phrase"`, "exact phrase"},
-		{"parentheses stripped", "(group) test", "group test"},
-		{"backslashes stripped", `path\to\file`, "pathtofile"},
-		{"semicolons stripped", "test; DROP TABLE", "test DROP TABLE"},
-		{"SQL comments stripped", "test -- comment", "test  comment"},
-		{"block comments stripped", "test /* comment */ end", "test  comment  end"},
-		{"injection attempt", "'; DROP TABLE episodes; --", "'' DROP TABLE episodes "},
-		{"empty string", "", ""},
-		{"only special chars", `+-*"();`, ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := sanitizeFTSQuery(tt.input)
-			if got != tt.expected {
-				t.Errorf("sanitizeFTSQuery(%q) = %q, want %q", tt.input, got, tt.expected)
+		{`source = cli AND NOT tags any [incident]`, []int{0}},
+		{`group = home OR tags all [incident, deploy]`, []int{2, 1}},
+		{`tags none [deploy]`, []int{2}},
+		{`metadata.priority >= 2`, []int{0}},
+		{`metadata.ticket.status in [open, blocked]`, []int{0}},
+		{`NOT metadata.priority exists`, []int{2}},
+		{`metadata.priority != 1`, []int{2, 0}},
+	}
+	for _, tc := range cases {
+		expr, err := filter.Parse(tc.filter)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tc.filter, err)
+		}
+		for _, mode := range []string{"", "keyword"} {
+			params := models.SearchParams{Filter: expr, MaxResults: 10}
+			if mode == "keyword" {
+				params.Query, params.SearchMode = "alpha", mode
 			}
-		})
+			results, err := store.Search(ctx, params)
+			if err != nil {
+				t.Fatalf("Search(%q) failed: %v", tc.filter, err)
+			}
+			got := make(map[string]bool)
+			for _, ep := range results {
+				got[ep.ID] = true
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("%q (%s): expected %d results, got %d", tc.filter, mode, len(tc.want), len(got))
+				continue
+			}
+			for _, i := range tc.want {
+				if !got[episodes[i].ID] {
+					t.Errorf("%q (%s): expected %q", tc.filter, mode, episodes[i].Content)
+				}
+			}
+		}
 	}
 }
 
@@ -1397,6 +1547,80 @@ func TestRelevanceMonotonic(t *testing.T) {
 	})
 }
 
+func TestSearchRecencyAndImportance(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Two equally similar episodes: one old and important, one new
+	embed := make([]float32, 768)
+	embed[0] = 1.0
+	important := 1.0
+	yearAgo := time.Now().AddDate(-1, 0, 0)
+	old := &models.Episode{
+		Content:    "Deploys need a second reviewer",
+		Source:     "test",
+		Embedding:  embed,
+		CreatedAt:  yearAgo,
+		Importance: &important,
+	}
+	recent := &models.Episode{
+		Content:   "Deploys need a green build",
+		Source:    "test",
+		Embedding: embed,
+		ValidAt:   &yearAgo,
+	}
+	for _, ep := range []*models.Episode{old, recent} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	search := func(p models.SearchParams) []models.Episode {
+		t.Helper()
+		p.QueryEmbedding, p.MaxResults = embed, 10
+		results, err := store.Search(ctx, p)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		return results
+	}
+
+	t.Run("recency favors the newer episode", func(t *testing.T) {
+		results := search(models.SearchParams{RecencyWeight: 0.5})
+		if results[0].ID != recent.ID {
+			t.Errorf("Expected the recent episode first, got %q", results[0].Content)
+		}
+		if got := *results[0].Relevance; math.Abs(got-1.5) > 0.01 {
+			t.Errorf("Expected relevance near 1.5 for a fresh episode, got %f", got)
+		}
+	})
+
+	t.Run("recency can decay from valid_at", func(t *testing.T) {
+		results := search(models.SearchParams{RecencyWeight: 0.5, RecencyField: "valid_at", RecencyHalfLifeDays: 7})
+		if d := *results[0].Relevance - *results[1].Relevance; math.Abs(d) > 0.01 {
+			t.Errorf("Expected equal relevance when both are valid from a year ago, got %v", d)
+		}
+	})
+
+	t.Run("importance favors the important episode", func(t *testing.T) {
+		results := search(models.SearchParams{ImportanceWeight: 0.5})
+		if results[0].ID != old.ID {
+			t.Errorf("Expected the important episode first, got %q", results[0].Content)
+		}
+		if results[0].Importance == nil || *results[0].Importance != 1.0 {
+			t.Errorf("Expected importance to round-trip, got %v", results[0].Importance)
+		}
+		if got := *results[1].Relevance; math.Abs(got-1.25) > 0.01 {
+			t.Errorf("Expected an unset importance to count as 0.5, got relevance %f", got)
+		}
+	})
+}
+
 func TestSearchKeywordNumericFallback(t *testing.T) {
 	store := setupTestStore(t)
 	defer store.Close()
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DuplicateCluster is a set of live episodes in one group linked by vector
+// similarity at or above a threshold: each member is that similar to at
+// least one other
+type DuplicateCluster struct {
+	GroupID       string            `json:"group_id"`
+	CanonicalID   string            `json:"canonical_id"`   // Suggested survivor: the oldest member
+	MinSimilarity float64           `json:"min_similarity"` // Weakest link found between members
+	Episodes      []DuplicateMember `json:"episodes"`       // Oldest first
+}
+
+// DuplicateMember identifies one episode of a cluster
+type DuplicateMember struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Preview   string    `json:"preview"` // Start of the content
+}
+
+// duplicatePreviewLen bounds a member's content preview, in characters
+const duplicatePreviewLen = 120
+
+// DuplicateGroups returns the groups holding live episodes with vectors in
+// the searched space, in name order
+func (s *Store) DuplicateGroups(ctx context.Context) ([]string, error) {
+	vec, _, from := s.exportVector()
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT DISTINCT episodes.group_id FROM %s WHERE %s IS NOT NULL AND %s ORDER BY 1",
+		from, vec, livePredicate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	defer rows.Close()
+	var groups []string
+	for rows.Next() {
+		var g string
+		if err := rows.Scan(&g); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// DuplicateClusters compares every pair of live episodes in group by the
+// vectors search uses — only vectors of the same model — and returns the
+// clusters of those at least threshold similar, largest first
+func (s *Store) DuplicateClusters(ctx context.Context, group string, threshold float64) ([]DuplicateCluster, error) {
+	vec, model, from := s.exportVector()
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		WITH e AS (
+			SELECT episodes.id, %s AS vec, %s AS model
+			FROM %s
+			WHERE episodes.group_id = ? AND %s IS NOT NULL AND %s
+		)
+		SELECT a.id, b.id, array_cosine_similarity(a.vec, b.vec) AS sim
+		FROM e a JOIN e b ON a.model IS NOT DISTINCT FROM b.model AND a.id < b.id
+		WHERE array_cosine_similarity(a.vec, b.vec) >= ?
+	`, vec, model, from, vec, livePredicate), group, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s episodes: %w", group, err)
+	}
+
+	// Link the pairs into clusters (union-find), tracking each one's
+	// weakest link
+	parent := map[string]string{}
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok {
+			parent[id] = id
+			return id
+		}
+		if p != id {
+			parent[id] = find(p)
+		}
+		return parent[id]
+	}
+	type pair struct {
+		a   string
+		sim float64
+	}
+	var pairs []pair
+	for rows.Next() {
+		var a, b string
+		var sim float64
+		if err := rows.Scan(&a, &b, &sim); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan duplicate pair: %w", err)
+		}
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[ra] = rb
+		}
+		pairs = append(pairs, pair{a, sim})
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s episodes: %w", group, err)
+	}
+	if len(parent) == 0 {
+		return nil, nil
+	}
+
+	byRoot := map[string]*DuplicateCluster{}
+	ids := make([]string, 0, len(parent))
+	for id := range parent {
+		ids = append(ids, id)
+		root := find(id)
+		if byRoot[root] == nil {
+			byRoot[root] = &DuplicateCluster{GroupID: group, MinSimilarity: 1}
+		}
+	}
+	for _, p := range pairs {
+		c := byRoot[find(p.a)]
+		c.MinSimilarity = min(c.MinSimilarity, p.sim)
+	}
+
+	members, err := s.duplicateMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		c := byRoot[find(m.ID)]
+		c.Episodes = append(c.Episodes, m)
+	}
+
+	clusters := make([]DuplicateCluster, 0, len(byRoot))
+	for _, c := range byRoot {
+		if len(c.Episodes) < 2 {
+			continue // a member expired or was deleted mid-scan
+		}
+		c.CanonicalID = c.Episodes[0].ID
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Episodes) != len(clusters[j].Episodes) {
+			return len(clusters[i].Episodes) > len(clusters[j].Episodes)
+		}
+		return clusters[i].CanonicalID < clusters[j].CanonicalID
+	})
+	return clusters, nil
+}
+
+// duplicateMembers describes ids, oldest first
+func (s *Store) duplicateMembers(ctx context.Context, ids []string) ([]DuplicateMember, error) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT id, created_at, left(content, %d) FROM episodes WHERE id IN (%s) ORDER BY created_at, id",
+		duplicatePreviewLen, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read duplicate episodes: %w", err)
+	}
+	defer rows.Close()
+	var members []DuplicateMember
+	for rows.Next() {
+		var m DuplicateMember
+		if err := rows.Scan(&m.ID, &m.CreatedAt, &m.Preview); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate episode: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SupersedeEpisodes expires each of ids that is still live, recording
+// canonicalID as superseded_by in its metadata, in one transaction. The
+// canonical episode must be live, and is never expired itself. Returns the
+// number expired.
+func (s *Store) SupersedeEpisodes(ctx context.Context, canonicalID string, ids []string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var live int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM episodes WHERE id = ? AND "+livePredicate, canonicalID).Scan(&live); err != nil {
+		return 0, fmt.Errorf("failed to look up episode: %w", err)
+	}
+	if live == 0 {
+		return 0, fmt.Errorf("canonical episode not found or expired: %s", canonicalID)
+	}
+
+	marker, _ := json.Marshal(map[string]string{"superseded_by": canonicalID})
+	now := time.Now()
+	expired := 0
+	for _, id := range ids {
+		if id == canonicalID {
+			continue
+		}
+		var metadata *string
+		err := tx.QueryRowContext(ctx,
+			"SELECT CAST(metadata AS VARCHAR) FROM episodes WHERE id = ? AND "+livePredicate, id).Scan(&metadata)
+		if err != nil {
+			continue // gone or already expired
+		}
+		existing := ""
+		if metadata != nil {
+			existing = *metadata
+		}
+		merged, err := mergeMetadata(existing, string(marker))
+		if err != nil {
+			return 0, fmt.Errorf("cannot supersede episode %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE episodes SET expired_at = ?, metadata = ? WHERE id = ?", now, merged, id); err != nil {
+			return 0, fmt.Errorf("failed to supersede episode %s: %w", id, err)
+		}
+		expired++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit consolidation: %w", err)
+	}
+	return expired, nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestDuplicateClusters(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	base := time.Now().Add(-time.Hour)
+	insert := func(content, group string, vec []float32, age int) *models.Episode {
+		t.Helper()
+		ep := &models.Episode{
+			Content: content, Source: "test", GroupID: group, Embedding: vec, EmbeddingModel: "test-model",
+			CreatedAt: base.Add(time.Duration(age) * time.Minute), Metadata: `{"origin":"chat"}`,
+		}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		return ep
+	}
+	// a~b and b~c chain into one cluster even though a and c are further apart
+	a := insert("dark mode", "default", emb(1, 0), 0)
+	b := insert("prefers dark mode", "default", emb(1, 0.2), 1)
+	c := insert("likes dark themes", "default", emb(1, 0.4), 2)
+	insert("uses a ThinkPad", "default", emb(0, 1), 3)
+	insert("dark mode", "other", emb(1, 0), 4)
+
+	groups, err := store.DuplicateGroups(ctx)
+	if err != nil || len(groups) != 2 {
+		t.Fatalf("Expected both groups, got %v, %v", groups, err)
+	}
+
+	clusters, err := store.DuplicateClusters(ctx, "default", 0.97)
+	if err != nil {
+		t.Fatalf("DuplicateClusters failed: %v", err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Episodes) != 3 {
+		t.Fatalf("Expected one cluster of three, got %+v", clusters)
+	}
+	cl := clusters[0]
+	if cl.CanonicalID != a.ID || cl.Episodes[1].ID != b.ID || cl.Episodes[2].ID != c.ID {
+		t.Errorf("Expected the members oldest first, got %+v", cl.Episodes)
+	}
+	if cl.MinSimilarity < 0.97 || cl.MinSimilarity > 0.99 {
+		t.Errorf("Expected the weakest link's similarity, got %v", cl.MinSimilarity)
+	}
+
+	t.Run("superseding expires the rest", func(t *testing.T) {
+		n, err := store.SupersedeEpisodes(ctx, b.ID, []string{a.ID, b.ID, c.ID})
+		if err != nil || n != 2 {
+			t.Fatalf("Expected two episodes expired, got %d, %v", n, err)
+		}
+		for _, id := range []string{a.ID, c.ID} {
+			ep, err := store.GetEpisode(ctx, id)
+			if err != nil {
+				t.Fatalf("GetEpisode failed: %v", err)
+			}
+			if ep.ExpiredAt == nil {
+				t.Errorf("Expected %s expired", id)
+			}
+			if want := `{"origin":"chat","superseded_by":"` + b.ID + `"}`; ep.Metadata != want {
+				t.Errorf("Expected metadata %s, got %s", want, ep.Metadata)
+			}
+		}
+		if ep, _ := store.GetEpisode(ctx, b.ID); ep.ExpiredAt != nil {
+			t.Error("Expected the canonical episode to stay live")
+		}
+
+		clusters, err := store.DuplicateClusters(ctx, "default", 0.97)
+		if err != nil || len(clusters) != 0 {
+			t.Errorf("Expected no clusters among live episodes, got %+v, %v", clusters, err)
+		}
+	})
+
+	t.Run("an expired canonical is refused", func(t *testing.T) {
+		if _, err := store.SupersedeEpisodes(ctx, a.ID, []string{b.ID}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
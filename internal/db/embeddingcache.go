@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/embedding"
+)
+
+// The embedding cache table keeps content vectors by (model, text hash), so
+// re-embedding identical text is a lookup (see embedding.Cache). It is
+// derived data: purging it only costs embedding calls. It holds at most
+// embeddingCacheMaxRows vectors, the oldest evicted first, and a hard delete
+// erases the vectors of the deleted text.
+const embeddingCacheDDL = `
+	CREATE TABLE IF NOT EXISTS embedding_cache (
+		model VARCHAR NOT NULL,
+		text_hash VARCHAR NOT NULL,
+		embedding FLOAT[] NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (model, text_hash)
+	)
+`
+
+// embeddingCacheMaxRows bounds the embedding cache table
+var embeddingCacheMaxRows = 100000
+
+// CachedEmbeddings returns the cached vectors of model for those of hashes
+// that have one, by hash
+func (s *Store) CachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return vectors, nil
+	}
+	placeholders := make([]string, len(hashes))
+	args := make([]interface{}, 0, len(hashes)+1)
+	args = append(args, model)
+	for i, h := range hashes {
+		placeholders[i] = "?"
+		args = append(args, h)
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT text_hash, CAST(to_json(embedding) AS VARCHAR) FROM embedding_cache WHERE model = ? AND text_hash IN (%s)",
+		strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash, raw string
+		if err := rows.Scan(&hash, &raw); err != nil {
+			return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+		}
+		var v []float32
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse cached embedding %s: %w", hash, err)
+		}
+		vectors[hash] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+	return vectors, nil
+}
+
+// CacheEmbeddings stores vectors of model by text hash. A hash already
+// cached keeps its vector. Beyond embeddingCacheMaxRows, the oldest vectors
+// are evicted.
+func (s *Store) CacheEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(vectors))
+	args := make([]interface{}, 0, 3*len(vectors))
+	for hash, v := range vectors {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode embedding: %w", err)
+		}
+		values = append(values, "(?, ?, CAST(? AS FLOAT[]))")
+		args = append(args, model, hash, string(data))
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"INSERT INTO embedding_cache (model, text_hash, embedding) VALUES "+strings.Join(values, ", ")+
+			" ON CONFLICT (model, text_hash) DO NOTHING", args...); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+
+	var rows int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM embedding_cache").Scan(&rows); err != nil {
+		return fmt.Errorf("failed to count embedding cache: %w", err)
+	}
+	if rows > embeddingCacheMaxRows {
+		if _, err := s.db.ExecContext(ctx, `
+			DELETE FROM embedding_cache WHERE rowid IN (
+				SELECT rowid FROM embedding_cache ORDER BY created_at DESC, rowid DESC OFFSET ?
+			)`, embeddingCacheMaxRows); err != nil {
+			return fmt.Errorf("failed to evict embedding cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// forgetCachedEmbeddings deletes the cached vectors of texts, of every
+// model, within a hard delete's transaction
+func forgetCachedEmbeddings(ctx context.Context, ex execer, texts []string) error {
+	if len(texts) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(texts))
+	args := make([]interface{}, len(texts))
+	for i, text := range texts {
+		placeholders[i] = "?"
+		args[i] = embedding.TextHash(text)
+	}
+	if _, err := ex.ExecContext(ctx, fmt.Sprintf(
+		"DELETE FROM embedding_cache WHERE text_hash IN (%s)", strings.Join(placeholders, ", ")), args...); err != nil {
+		return fmt.Errorf("failed to delete cached embeddings: %w", err)
+	}
+	return nil
+}
+
+// PurgeEmbeddingCache deletes every cached vector not produced by keep
+func (s *Store) PurgeEmbeddingCache(ctx context.Context, keep string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM embedding_cache WHERE model <> ?", keep); err != nil {
+		return fmt.Errorf("failed to purge embedding cache: %w", err)
+	}
+	return nil
+}
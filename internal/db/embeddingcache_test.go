@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/embedding"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEmbeddingCacheTable(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.CacheEmbeddings(ctx, "m1", map[string][]float32{"h1": {0.5, 1}, "h2": {2}}); err != nil {
+		t.Fatalf("CacheEmbeddings failed: %v", err)
+	}
+	// An existing hash keeps its vector
+	if err := store.CacheEmbeddings(ctx, "m1", map[string][]float32{"h1": {9}}); err != nil {
+		t.Fatalf("CacheEmbeddings failed: %v", err)
+	}
+	if err := store.CacheEmbeddings(ctx, "m2", map[string][]float32{"h1": {3}}); err != nil {
+		t.Fatalf("CacheEmbeddings failed: %v", err)
+	}
+
+	got, err := store.CachedEmbeddings(ctx, "m1", []string{"h1", "missing"})
+	if err != nil {
+		t.Fatalf("CachedEmbeddings failed: %v", err)
+	}
+	if want := map[string][]float32{"h1": {0.5, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if err := store.PurgeEmbeddingCache(ctx, "m2"); err != nil {
+		t.Fatalf("PurgeEmbeddingCache failed: %v", err)
+	}
+	if got, _ := store.CachedEmbeddings(ctx, "m1", []string{"h1", "h2"}); len(got) != 0 {
+		t.Errorf("Expected m1's vectors purged, got %v", got)
+	}
+	if got, _ := store.CachedEmbeddings(ctx, "m2", []string{"h1"}); len(got) != 1 {
+		t.Errorf("Expected m2's vector kept, got %v", got)
+	}
+}
+
+func TestEmbeddingCacheEviction(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	defer func(n int) { embeddingCacheMaxRows = n }(embeddingCacheMaxRows)
+	embeddingCacheMaxRows = 3
+
+	for i := 0; i < 5; i++ {
+		if err := store.CacheEmbeddings(ctx, "m1", map[string][]float32{fmt.Sprintf("h%d", i): {1}}); err != nil {
+			t.Fatalf("CacheEmbeddings failed: %v", err)
+		}
+	}
+	var rows int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM embedding_cache").Scan(&rows); err != nil {
+		t.Fatalf("Failed to count cache: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("Expected the cache capped at 3 rows, got %d", rows)
+	}
+	if got, _ := store.CachedEmbeddings(ctx, "m1", []string{"h4"}); len(got) != 1 {
+		t.Errorf("Expected the newest vector kept, got %v", got)
+	}
+}
+
+func TestDeleteForgetsCachedEmbeddings(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	secret := &models.Episode{Content: "secret text", Source: "test", GroupID: "g1"}
+	keep := &models.Episode{Content: "kept text", Source: "test", GroupID: "g1"}
+	for _, ep := range []*models.Episode{secret, keep} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+	secretHash, keepHash := embedding.TextHash(secret.Content), embedding.TextHash(keep.Content)
+	for _, model := range []string{"m1", "m2"} {
+		if err := store.CacheEmbeddings(ctx, model, map[string][]float32{secretHash: {1}, keepHash: {2}}); err != nil {
+			t.Fatalf("CacheEmbeddings failed: %v", err)
+		}
+	}
+
+	if _, err := store.DeleteEpisodes(ctx, []string{secret.ID}, Deletion{By: "alice"}); err != nil {
+		t.Fatalf("DeleteEpisodes failed: %v", err)
+	}
+	for _, model := range []string{"m1", "m2"} {
+		got, err := store.CachedEmbeddings(ctx, model, []string{secretHash, keepHash})
+		if err != nil {
+			t.Fatalf("CachedEmbeddings failed: %v", err)
+		}
+		if _, ok := got[secretHash]; ok {
+			t.Errorf("Expected the deleted content's %s vector erased", model)
+		}
+		if _, ok := got[keepHash]; !ok {
+			t.Errorf("Expected the kept content's %s vector to survive", model)
+		}
+	}
+}
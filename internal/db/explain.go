@@ -0,0 +1,89 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// scoreParts are the SQL expressions a ranked search branch builds relevance
+// from. Empty parts don't apply to the branch, and an empty base means the
+// branch doesn't rank at all.
+type scoreParts struct {
+	base              string // relevance before the tag, recency, and importance addends
+	simNorm, bm25Norm string // normalized similarity and BM25
+	alpha             string // hybrid linear fusion's weight on similarity
+	vecRank, kwRank   string // ranks in hybrid's two lists
+}
+
+// orNull renders an absent part as NULL
+func orNull(expr string) string {
+	if expr == "" {
+		return "NULL"
+	}
+	return expr
+}
+
+// explainColumns is how many columns an explained search adds after
+// relevance
+func explainColumns(explain bool) int {
+	if !explain {
+		return 0
+	}
+	return len(explainRow{}.targets())
+}
+
+// explainRow receives a result's explain columns, in the order search
+// selects them
+type explainRow struct {
+	bm25, simNorm, bm25Norm, alpha          *sql.NullFloat64
+	tagRatio, tagBoost, recency, importance *sql.NullFloat64
+	vecRank, kwRank                         *sql.NullInt64
+}
+
+func newExplainRow() explainRow {
+	return explainRow{
+		new(sql.NullFloat64), new(sql.NullFloat64), new(sql.NullFloat64), new(sql.NullFloat64),
+		new(sql.NullFloat64), new(sql.NullFloat64), new(sql.NullFloat64), new(sql.NullFloat64),
+		new(sql.NullInt64), new(sql.NullInt64),
+	}
+}
+
+func (r explainRow) targets() []interface{} {
+	return []interface{}{
+		r.bm25, r.simNorm, r.bm25Norm, r.alpha,
+		r.tagRatio, r.tagBoost, r.recency, r.importance,
+		r.vecRank, r.kwRank,
+	}
+}
+
+// explanation assembles the scanned columns, with the result's similarity
+func (r explainRow) explanation(similarity *float64) *models.Explanation {
+	float := func(v *sql.NullFloat64) *float64 {
+		if !v.Valid {
+			return nil
+		}
+		f := v.Float64
+		return &f
+	}
+	rank := func(v *sql.NullInt64) *int {
+		if !v.Valid {
+			return nil
+		}
+		n := int(v.Int64)
+		return &n
+	}
+	return &models.Explanation{
+		BM25Score:            float(r.bm25),
+		Similarity:           similarity,
+		BM25Normalized:       float(r.bm25Norm),
+		SimilarityNormalized: float(r.simNorm),
+		Alpha:                float(r.alpha),
+		VectorRank:           rank(r.vecRank),
+		KeywordRank:          rank(r.kwRank),
+		TagMatchRatio:        float(r.tagRatio),
+		TagBoost:             float(r.tagBoost),
+		Recency:              float(r.recency),
+		Importance:           float(r.importance),
+	}
+}
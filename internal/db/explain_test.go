@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestSearchExplain(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	for _, ep := range []*models.Episode{
+		{Content: "rollbacks use the previous image tag", Source: "test", Tags: []string{"deploy"}, Embedding: emb(1, 0)},
+		{Content: "rollbacks of rollbacks need approval", Source: "test", Embedding: emb(0.5, 0.5)},
+		{Content: "ticket 48213 tracks the outage", Source: "test", Embedding: emb(0, 1)},
+	} {
+		ep.EmbeddingModel = "test-model"
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	t.Run("hybrid linear parts add up to relevance", func(t *testing.T) {
+		page, err := store.SearchPage(ctx, models.SearchParams{
+			Query: "rollbacks", QueryEmbedding: emb(1, 0), SearchMode: "hybrid", SearchAlpha: 0.6,
+			Tags: []string{"deploy"}, TagBoost: 0.5, ImportanceWeight: 0.2, MaxResults: 10, Explain: true,
+		})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if page.SearchModeEffective != "hybrid" {
+			t.Errorf("Expected effective mode hybrid, got %q", page.SearchModeEffective)
+		}
+		if len(page.Episodes) == 0 {
+			t.Fatal("Expected results")
+		}
+		for _, ep := range page.Episodes {
+			x := ep.Explanation
+			if x == nil || x.Alpha == nil || x.SimilarityNormalized == nil || x.BM25Normalized == nil ||
+				x.TagBoost == nil || x.Importance == nil || x.VectorRank == nil {
+				t.Fatalf("Expected a full hybrid explanation, got %+v", x)
+			}
+			if math.Abs(*x.Alpha-0.6) > 1e-9 {
+				t.Errorf("Expected alpha 0.6, got %f", *x.Alpha)
+			}
+			if x.Recency != nil || x.Fallback != "" {
+				t.Errorf("Expected no recency term or fallback, got %+v", x)
+			}
+			sum := *x.Alpha**x.SimilarityNormalized + (1-*x.Alpha)**x.BM25Normalized + *x.TagBoost + *x.Importance
+			if math.Abs(sum-*ep.Relevance) > 1e-6 {
+				t.Errorf("Parts sum to %f, relevance is %f", sum, *ep.Relevance)
+			}
+		}
+	})
+
+	t.Run("keyword reports BM25 only", func(t *testing.T) {
+		page, err := store.SearchPage(ctx, models.SearchParams{Query: "rollbacks", SearchMode: "keyword", MaxResults: 10, Explain: true})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if page.SearchModeEffective != "keyword" || len(page.Episodes) != 2 {
+			t.Fatalf("Expected 2 keyword results, got %q %v", page.SearchModeEffective, page.Episodes)
+		}
+		x := page.Episodes[0].Explanation
+		if x == nil || x.BM25Score == nil || x.BM25Normalized == nil || *x.BM25Normalized != *page.Episodes[0].Relevance {
+			t.Fatalf("Expected the normalized BM25 to be the relevance, got %+v", x)
+		}
+		if x.Similarity != nil || x.Alpha != nil || x.VectorRank != nil {
+			t.Errorf("Expected no vector parts, got %+v", x)
+		}
+	})
+
+	t.Run("fallbacks", func(t *testing.T) {
+		cases := []struct {
+			name      string
+			params    models.SearchParams
+			effective string
+			fallback  string
+		}{
+			{"hybrid without embedding", models.SearchParams{Query: "rollbacks", SearchMode: "hybrid"}, "keyword", "hybrid_to_keyword"},
+			{"numeric keyword", models.SearchParams{Query: "48213", SearchMode: "keyword"}, "substring", "ilike"},
+			{"vector without embedding", models.SearchParams{Query: "rollbacks"}, "chronological", "vector_to_chronological"},
+		}
+		for _, tc := range cases {
+			tc.params.Explain = true
+			page, err := store.SearchPage(ctx, tc.params)
+			if err != nil {
+				t.Fatalf("%s: search failed: %v", tc.name, err)
+			}
+			if page.SearchModeEffective != tc.effective {
+				t.Errorf("%s: expected effective mode %q, got %q", tc.name, tc.effective, page.SearchModeEffective)
+			}
+			if len(page.Episodes) == 0 || page.Episodes[0].Explanation == nil || page.Episodes[0].Explanation.Fallback != tc.fallback {
+				t.Errorf("%s: expected fallback %q on results, got %v", tc.name, tc.fallback, page.Episodes)
+			}
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		page, err := store.SearchPage(ctx, models.SearchParams{Query: "rollbacks", SearchMode: "keyword"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		for _, ep := range page.Episodes {
+			if ep.Explanation != nil {
+				t.Errorf("Expected no explanation without explain, got %+v", ep.Explanation)
+			}
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// exportPageSize is how many episodes ExportEpisodes reads per keyset page
+const exportPageSize = 500
+
+// ImportResult counts the outcome of an import
+type ImportResult struct {
+	Inserted int `json:"inserted"`
+	Skipped  int `json:"skipped"` // ID already present
+	Erased   int `json:"erased"`  // ID permanently deleted (see deletion_log), not restored
+}
+
+// exportVector returns the expression for the vector to export and its model
+// stamp, plus the FROM clause that makes them available. The searched space is
+// exported: the active embedding space when one is set, else the primary
+// column.
+func (s *Store) exportVector() (vec, model, from string) {
+	if space, ok := s.activeSpace(); ok {
+		return "v.embedding",
+			fmt.Sprintf("CASE WHEN v.embedding IS NULL THEN NULL ELSE '%s' END", strings.ReplaceAll(space.Model, "'", "''")),
+			fmt.Sprintf("episodes LEFT JOIN %s v ON v.episode_id = episodes.id", space.table)
+	}
+	return "episodes.embedding", "episodes.embedding_model", "episodes"
+}
+
+// exportColumns is the episode column list of an export query, in the order
+// scanExportedRow reads it
+const exportColumns = `episodes.id, episodes.content, episodes.name, episodes.source,
+	episodes.source_model, episodes.source_description, episodes.group_id, episodes.tags,
+	episodes.created_at, episodes.valid_at, episodes.expired_at, CAST(episodes.metadata AS VARCHAR),
+	episodes.importance, COALESCE(episodes.key, '')`
+
+// ExportEpisodes calls fn for every episode — expired ones included — in ID
+// order. With withEmbeddings, each episode carries the vector search uses
+// and the model that produced it.
+func (s *Store) ExportEpisodes(ctx context.Context, withEmbeddings bool, fn func(*models.Episode) error) error {
+	vec, model, from := s.exportVector()
+	vecCols := "NULL, NULL"
+	if withEmbeddings {
+		vecCols = fmt.Sprintf("CAST(to_json(%s) AS VARCHAR), %s", vec, model)
+	}
+	query := fmt.Sprintf(`
+		SELECT %s, %s
+		FROM %s
+		WHERE episodes.id > ?
+		ORDER BY episodes.id
+		LIMIT ?`, exportColumns, vecCols, from)
+
+	afterID := ""
+	for {
+		rows, err := s.db.QueryContext(ctx, query, afterID, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to export episodes: %w", err)
+		}
+		page, err := scanExported(rows)
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to export episodes: %w", err)
+		}
+		for _, ep := range page {
+			if err := fn(ep); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// ExportParquet writes every episode to a Parquet file at path using
+// DuckDB's native writer. Embeddings are written as FLOAT[] lists.
+func (s *Store) ExportParquet(ctx context.Context, path string, withEmbeddings bool) (int, error) {
+	vec, model, from := s.exportVector()
+	vecCols := "NULL::FLOAT[] AS embedding, NULL::VARCHAR AS embedding_model"
+	if withEmbeddings {
+		vecCols = fmt.Sprintf("%s::FLOAT[] AS embedding, %s AS embedding_model", vec, model)
+	}
+	query := fmt.Sprintf(`
+		COPY (
+			SELECT episodes.id, episodes.content, episodes.name, episodes.source,
+			       episodes.source_model, episodes.source_description, episodes.group_id, episodes.tags,
+			       episodes.created_at, episodes.valid_at, episodes.expired_at,
+			       CAST(episodes.metadata AS VARCHAR) AS metadata, episodes.importance, episodes.key, %s
+			FROM %s
+			ORDER BY episodes.id
+		) TO '%s' (FORMAT PARQUET)`, vecCols, from, strings.ReplaceAll(path, "'", "''"))
+
+	res, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export parquet: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ReadParquetEpisodes calls fn for each episode in a Parquet file written by
+// ExportParquet. Nothing is stored; pass the episodes to ImportEpisodes.
+func (s *Store) ReadParquetEpisodes(ctx context.Context, path string, fn func(*models.Episode) error) error {
+	file := strings.ReplaceAll(path, "'", "''")
+
+	// Exports from before importance or keys existed lack the column
+	importance, key := "NULL::DOUBLE", "''"
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT name FROM parquet_schema('%s') WHERE name IN ('importance', 'key')", file))
+	if err != nil {
+		return fmt.Errorf("failed to read parquet schema: %w", err)
+	}
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read parquet schema: %w", err)
+		}
+		switch col {
+		case "importance":
+			importance = "importance"
+		case "key":
+			key = "COALESCE(key, '')"
+		}
+	}
+	rows.Close()
+
+	query := fmt.Sprintf(`
+		SELECT id, content, COALESCE(name, ''), COALESCE(source, ''),
+		       COALESCE(source_model, ''), COALESCE(source_description, ''), COALESCE(group_id, ''), tags,
+		       created_at, valid_at, expired_at, CAST(metadata AS VARCHAR), %s, %s,
+		       CAST(to_json(embedding) AS VARCHAR), embedding_model
+		FROM read_parquet('%s')`, importance, key, file)
+
+	rows, err = s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to read parquet: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ep, err := scanExportedRow(rows)
+		if err != nil {
+			return fmt.Errorf("failed to read parquet: %w", err)
+		}
+		if err := fn(ep); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportEpisodes stores exported episodes in one transaction, keeping their
+// IDs and timestamps. Episodes whose ID already exists are skipped, so
+// re-running an import is harmless, as are permanently deleted ones. A
+// vector that doesn't fit where its model's vectors are stored is dropped
+// and the episode queued for embedding, as are episodes exported without
+// one. A keyed episode older than its key's live value is imported expired
+// (see yieldToNewerKey).
+func (s *Store) ImportEpisodes(ctx context.Context, eps []*models.Episode) (ImportResult, error) {
+	var result ImportResult
+	if len(eps) == 0 {
+		return result, nil
+	}
+
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, ep := range eps {
+		if ep.ID == "" {
+			return result, fmt.Errorf("episode without id")
+		}
+		var present, erased int
+		if err := tx.QueryRowContext(ctx,
+			"SELECT (SELECT COUNT(*) FROM episodes WHERE id = ?), (SELECT COUNT(*) FROM deletion_log WHERE episode_id = ?)",
+			ep.ID, ep.ID).Scan(&present, &erased); err != nil {
+			return result, fmt.Errorf("failed to look up episode: %w", err)
+		}
+		if present > 0 {
+			result.Skipped++
+			continue
+		}
+		// A permanent deletion is not undone by an older export
+		if erased > 0 {
+			result.Erased++
+			continue
+		}
+		if len(ep.Embedding) > 0 && len(ep.Embedding) != s.SpaceDimensions(ep.EmbeddingModel) {
+			ep.Embedding = nil
+		}
+		if err := yieldToNewerKey(ctx, tx, ep); err != nil {
+			return result, fmt.Errorf("episode %s: %w", ep.ID, err)
+		}
+		if err := s.insertEpisode(ctx, tx, ep); err != nil {
+			return result, fmt.Errorf("episode %s: %w", ep.ID, err)
+		}
+		result.Inserted++
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return result, nil
+}
+
+// scanExported reads every row of an export query
+func scanExported(rows *sql.Rows) ([]*models.Episode, error) {
+	var eps []*models.Episode
+	for rows.Next() {
+		ep, err := scanExportedRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		eps = append(eps, ep)
+	}
+	return eps, rows.Err()
+}
+
+// scanExportedRow reads one row laid out as exportColumns followed by the
+// vector as JSON text and its model
+func scanExportedRow(rows *sql.Rows) (*models.Episode, error) {
+	var ep models.Episode
+	var tagsRaw interface{}
+	var metadata, embeddingJSON, embeddingModel sql.NullString
+
+	err := rows.Scan(
+		&ep.ID, &ep.Content, &ep.Name, &ep.Source, &ep.SourceModel, &ep.SourceDescription,
+		&ep.GroupID, &tagsRaw, &ep.CreatedAt, &ep.ValidAt, &ep.ExpiredAt, &metadata, &ep.Importance, &ep.Key,
+		&embeddingJSON, &embeddingModel,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tagsRaw.(type) {
+	case []interface{}:
+		ep.Tags = make([]string, len(v))
+		for i, tag := range v {
+			if s, ok := tag.(string); ok {
+				ep.Tags[i] = s
+			}
+		}
+	case []string:
+		ep.Tags = v
+	}
+	ep.Metadata = metadata.String
+
+	if embeddingJSON.Valid && embeddingJSON.String != "" {
+		if err := json.Unmarshal([]byte(embeddingJSON.String), &ep.Embedding); err != nil {
+			return nil, fmt.Errorf("episode %s: invalid embedding: %w", ep.ID, err)
+		}
+		ep.EmbeddingModel = embeddingModel.String
+	}
+	return &ep, nil
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// A key names a value — a preference, a setting — that has one current
+// episode per group. Writing a live episode with a key expires the live
+// episode holding it, linked to its replacement by superseded_by in its
+// metadata, so the key's history stays searchable with include_expired.
+// Uniqueness is kept by the write path, not a constraint: expired episodes
+// keep their key.
+
+// supersedeKey expires the live episodes in ep's group holding ep's key,
+// recording ep as their superseded_by, within the transaction that inserts
+// ep. Sets ep.Supersedes.
+func supersedeKey(ctx context.Context, tx *sql.Tx, ep *models.Episode) error {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, CAST(metadata AS VARCHAR) FROM episodes WHERE group_id = ? AND key = ? AND "+livePredicate+" ORDER BY created_at",
+		ep.GroupID, ep.Key)
+	if err != nil {
+		return fmt.Errorf("failed to look up key %q: %w", ep.Key, err)
+	}
+	type holder struct {
+		id       string
+		metadata sql.NullString
+	}
+	var holders []holder
+	for rows.Next() {
+		var h holder
+		if err := rows.Scan(&h.id, &h.metadata); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to look up key %q: %w", ep.Key, err)
+		}
+		holders = append(holders, h)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to look up key %q: %w", ep.Key, err)
+	}
+
+	marker, _ := json.Marshal(map[string]string{"superseded_by": ep.ID})
+	now := time.Now()
+	for _, h := range holders {
+		// Metadata that isn't an object can't carry the link; the episode
+		// is still expired rather than failing the write
+		metadata := nullIfEmpty(h.metadata.String)
+		if merged, err := mergeMetadata(h.metadata.String, string(marker)); err == nil {
+			metadata = merged
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: cannot link superseded episode %s: %v\n", h.id, err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE episodes SET expired_at = ?, metadata = ? WHERE id = ?", now, metadata, h.id); err != nil {
+			return fmt.Errorf("failed to supersede episode %s: %w", h.id, err)
+		}
+		ep.Supersedes = h.id
+	}
+	return nil
+}
+
+// ErrKeyHeld is returned when an update would make a keyed episode live
+// while another live episode holds its key
+var ErrKeyHeld = errors.New("key is held by another live episode")
+
+// checkKeyRevival refuses to make episode id live again, through a future
+// expired_at, while another live episode in its group holds its key: a key
+// has one live episode. Expire the current holder first.
+func checkKeyRevival(ctx context.Context, tx *sql.Tx, id string) error {
+	var holder string
+	err := tx.QueryRowContext(ctx, `
+		SELECT id FROM episodes
+		WHERE group_id = (SELECT group_id FROM episodes WHERE id = ?)
+		  AND key = (SELECT key FROM episodes WHERE id = ?)
+		  AND id <> ? AND `+livePredicate+`
+		LIMIT 1`, id, id, id).Scan(&holder)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up key holder: %w", err)
+	}
+	return fmt.Errorf("%w: %s", ErrKeyHeld, holder)
+}
+
+// yieldToNewerKey expires ep, an imported keyed episode, when a live episode
+// created after it holds its key, linking ep to that value by superseded_by:
+// importing an old export must not replace a newer local value. An ep newer
+// than every holder is left live and supersedes them on insert.
+func yieldToNewerKey(ctx context.Context, tx *sql.Tx, ep *models.Episode) error {
+	now := time.Now()
+	if ep.Key == "" || ep.IsExpired(now) || ep.CreatedAt.IsZero() {
+		return nil
+	}
+	group := ep.GroupID
+	if group == "" {
+		group = "default"
+	}
+	var newer string
+	err := tx.QueryRowContext(ctx,
+		"SELECT id FROM episodes WHERE group_id = ? AND key = ? AND created_at > ? AND "+livePredicate+" ORDER BY created_at DESC LIMIT 1",
+		group, ep.Key, ep.CreatedAt).Scan(&newer)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up key %q: %w", ep.Key, err)
+	}
+
+	marker, _ := json.Marshal(map[string]string{"superseded_by": newer})
+	if merged, err := mergeMetadata(ep.Metadata, string(marker)); err == nil {
+		ep.Metadata = merged
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: cannot link superseded episode %s: %v\n", ep.ID, err)
+	}
+	ep.ExpiredAt = &now
+	return nil
+}
+
+// checkBatchKeys refuses a batch that writes one key twice in a group: only
+// one of them could be current
+func checkBatchKeys(eps []*models.Episode) error {
+	seen := make(map[[2]string]int)
+	for i, ep := range eps {
+		if ep.Key == "" {
+			continue
+		}
+		group := ep.GroupID
+		if group == "" {
+			group = "default"
+		}
+		k := [2]string{group, ep.Key}
+		if j, ok := seen[k]; ok {
+			return fmt.Errorf("episodes %d and %d both write key %q", j, i, ep.Key)
+		}
+		seen[k] = i
+	}
+	return nil
+}
+
+// GetEpisodeByKey returns the live episode holding key in group, or nil
+// when there is none
+func (s *Store) GetEpisodeByKey(ctx context.Context, group, key string) (*models.Episode, error) {
+	if group == "" {
+		group = "default"
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, content, name, source, source_model, source_description,
+		       group_id, tags, created_at, valid_at, expired_at, metadata, importance, COALESCE(key, '')
+		FROM episodes
+		WHERE group_id = ? AND key = ? AND `+livePredicate+`
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, group, key)
+	ep, err := s.scanEpisode(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episode by key: %w", err)
+	}
+	return ep, nil
+}
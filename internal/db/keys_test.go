@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestKeyedEpisodes(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	first := &models.Episode{Content: "editor: vim", Source: "test", Key: "editor", Metadata: `{"origin":"chat"}`}
+	if err := store.InsertEpisode(ctx, first); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if first.Supersedes != "" {
+		t.Errorf("Expected the first write to supersede nothing, got %s", first.Supersedes)
+	}
+
+	t.Run("a write to a held key supersedes the live value", func(t *testing.T) {
+		second := &models.Episode{Content: "editor: helix", Source: "test", Key: "editor"}
+		if err := store.InsertEpisode(ctx, second); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if second.Supersedes != first.ID {
+			t.Errorf("Expected supersedes %s, got %q", first.ID, second.Supersedes)
+		}
+
+		old, err := store.GetEpisode(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("GetEpisode failed: %v", err)
+		}
+		if !old.IsExpired(time.Now()) || old.Key != "editor" {
+			t.Errorf("Expected the old value expired with its key kept, got %+v", old)
+		}
+		var meta map[string]string
+		if err := json.Unmarshal([]byte(old.Metadata), &meta); err != nil {
+			t.Fatalf("Bad metadata %q: %v", old.Metadata, err)
+		}
+		if meta["superseded_by"] != second.ID || meta["origin"] != "chat" {
+			t.Errorf("Expected superseded_by merged into the metadata, got %v", meta)
+		}
+
+		got, err := store.GetEpisodeByKey(ctx, "", "editor")
+		if err != nil || got == nil || got.ID != second.ID {
+			t.Fatalf("Expected the new value by key, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("keys are per group", func(t *testing.T) {
+		other := &models.Episode{Content: "editor: emacs", Source: "test", Key: "editor", GroupID: "other"}
+		if err := store.InsertEpisode(ctx, other); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		if other.Supersedes != "" {
+			t.Errorf("Expected another group's key left alone, got supersedes %s", other.Supersedes)
+		}
+		got, err := store.GetEpisodeByKey(ctx, "default", "editor")
+		if err != nil || got == nil || got.Content != "editor: helix" {
+			t.Errorf("Expected the default group's value unchanged, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("an unheld key reads as nil", func(t *testing.T) {
+		got, err := store.GetEpisodeByKey(ctx, "", "shell")
+		if err != nil || got != nil {
+			t.Errorf("Expected nil, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("a batch writing one key twice is refused", func(t *testing.T) {
+		err := store.InsertEpisodes(ctx, []*models.Episode{
+			{Content: "shell: zsh", Source: "test", Key: "shell"},
+			{Content: "shell: fish", Source: "test", Key: "shell"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "both write key") {
+			t.Fatalf("Expected the batch refused, got %v", err)
+		}
+		if got, _ := store.GetEpisodeByKey(ctx, "", "shell"); got != nil {
+			t.Errorf("Expected nothing stored, got %+v", got)
+		}
+	})
+
+	t.Run("a batch supersedes stored values", func(t *testing.T) {
+		eps := []*models.Episode{
+			{Content: "editor: zed", Source: "test", Key: "editor"},
+			{Content: "shell: fish", Source: "test", Key: "shell"},
+		}
+		if err := store.InsertEpisodes(ctx, eps); err != nil {
+			t.Fatalf("InsertEpisodes failed: %v", err)
+		}
+		if eps[0].Supersedes == "" || eps[1].Supersedes != "" {
+			t.Errorf("Expected only the editor write to supersede, got %q and %q", eps[0].Supersedes, eps[1].Supersedes)
+		}
+		if got, _ := store.GetEpisodeByKey(ctx, "", "editor"); got == nil || got.ID != eps[0].ID {
+			t.Errorf("Expected the batch's editor value current, got %+v", got)
+		}
+	})
+
+	t.Run("an import older than the live value doesn't replace it", func(t *testing.T) {
+		current, err := store.GetEpisodeByKey(ctx, "", "editor")
+		if err != nil || current == nil {
+			t.Fatalf("Expected a live editor value, got %+v, %v", current, err)
+		}
+		older := &models.Episode{ID: "imported-old", Content: "editor: nano", Source: "test", Key: "editor",
+			CreatedAt: current.CreatedAt.Add(-time.Hour)}
+		if _, err := store.ImportEpisodes(ctx, []*models.Episode{older}); err != nil {
+			t.Fatalf("ImportEpisodes failed: %v", err)
+		}
+		if got, _ := store.GetEpisodeByKey(ctx, "", "editor"); got == nil || got.ID != current.ID {
+			t.Errorf("Expected %s still current, got %+v", current.ID, got)
+		}
+		imported, err := store.GetEpisode(ctx, older.ID)
+		if err != nil || !imported.IsExpired(time.Now()) || !strings.Contains(imported.Metadata, current.ID) {
+			t.Errorf("Expected the import expired and superseded by %s, got %+v, %v", current.ID, imported, err)
+		}
+
+		newer := &models.Episode{ID: "imported-new", Content: "editor: kakoune", Source: "test", Key: "editor",
+			CreatedAt: time.Now().Add(time.Minute)}
+		if _, err := store.ImportEpisodes(ctx, []*models.Episode{newer}); err != nil {
+			t.Fatalf("ImportEpisodes failed: %v", err)
+		}
+		if got, _ := store.GetEpisodeByKey(ctx, "", "editor"); got == nil || got.ID != newer.ID {
+			t.Errorf("Expected the newer import current, got %+v", got)
+		}
+	})
+
+	t.Run("a superseded value can't be made live beside its successor", func(t *testing.T) {
+		current, err := store.GetEpisodeByKey(ctx, "", "editor")
+		if err != nil || current == nil {
+			t.Fatalf("Expected a live editor value, got %+v, %v", current, err)
+		}
+		future := time.Now().Add(time.Hour)
+		err = store.UpdateEpisode(ctx, first.ID, models.UpdateParams{ExpiredAt: &future})
+		if !errors.Is(err, ErrKeyHeld) {
+			t.Fatalf("Expected ErrKeyHeld, got %v", err)
+		}
+		if old, _ := store.GetEpisode(ctx, first.ID); !old.IsExpired(time.Now()) {
+			t.Error("Expected the superseded value to stay expired")
+		}
+
+		past := time.Now().Add(-time.Minute)
+		if err := store.UpdateEpisode(ctx, current.ID, models.UpdateParams{ExpiredAt: &past}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		if err := store.UpdateEpisode(ctx, first.ID, models.UpdateParams{ExpiredAt: &future}); err != nil {
+			t.Errorf("Expected reviving an unheld key allowed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/keyword"
+)
+
+// The keyword index is an inverted index maintained alongside episodes:
+// keyword_postings holds each (term, episode) pair with its frequency and
+// keyword_docs each episode's length in terms, and keyword_stats, one row,
+// the number of documents and their total length. Writes update it in the
+// same transaction as the episode, and BM25 is computed from it at query
+// time, so keyword search never waits on an index rebuild nor aggregates
+// every document. Name and content are indexed together as one document.
+
+// settingKeywordIndex records the keyword.Version the index was built with
+const settingKeywordIndex = "keyword_index_version"
+
+// BM25 parameters, as DuckDB's FTS extension used
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// keywordIndexDDL creates the index tables. The postings index serves the
+// per-term lookups of a query and the per-episode deletes of a write.
+const keywordIndexDDL = `
+	CREATE TABLE IF NOT EXISTS keyword_postings (
+		term VARCHAR NOT NULL,
+		episode_id VARCHAR NOT NULL,
+		tf INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_keyword_postings_term ON keyword_postings (term);
+	CREATE INDEX IF NOT EXISTS idx_keyword_postings_episode ON keyword_postings (episode_id);
+	CREATE TABLE IF NOT EXISTS keyword_docs (
+		episode_id VARCHAR PRIMARY KEY,
+		length INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS keyword_stats (
+		docs BIGINT NOT NULL,
+		total_length BIGINT NOT NULL
+	);
+`
+
+// Adjust keyword_stats for one document being (re)indexed or dropped, given
+// its id (twice) and, when indexing, its new length (first). Run before
+// keyword_docs changes: the subqueries read the document's previous row.
+const (
+	keywordStatsIndex = `
+		UPDATE keyword_stats SET
+			docs = docs + 1 - (SELECT COUNT(*) FROM keyword_docs WHERE episode_id = ?),
+			total_length = total_length + ? - COALESCE((SELECT length FROM keyword_docs WHERE episode_id = ?), 0)`
+	keywordStatsDrop = `
+		UPDATE keyword_stats SET
+			docs = docs - (SELECT COUNT(*) FROM keyword_docs WHERE episode_id = ?),
+			total_length = total_length - COALESCE((SELECT length FROM keyword_docs WHERE episode_id = ?), 0)`
+)
+
+// indexKeywords (re)indexes one episode's name and content
+func indexKeywords(ctx context.Context, ex execer, id, name, content string) error {
+	if err := unindexKeywords(ctx, ex, id); err != nil {
+		return err
+	}
+	freq, length := keyword.Terms(name + " " + content)
+	if len(freq) > 0 {
+		values := make([]string, 0, len(freq))
+		args := make([]interface{}, 0, 3*len(freq))
+		for term, tf := range freq {
+			values = append(values, "(?, ?, ?)")
+			args = append(args, term, id, tf)
+		}
+		if _, err := ex.ExecContext(ctx,
+			"INSERT INTO keyword_postings (term, episode_id, tf) VALUES "+strings.Join(values, ", "), args...); err != nil {
+			return fmt.Errorf("failed to index keywords: %w", err)
+		}
+	}
+	if _, err := ex.ExecContext(ctx, keywordStatsIndex, id, length, id); err != nil {
+		return fmt.Errorf("failed to index keywords: %w", err)
+	}
+	if _, err := ex.ExecContext(ctx, `
+		INSERT INTO keyword_docs (episode_id, length) VALUES (?, ?)
+		ON CONFLICT (episode_id) DO UPDATE SET length = excluded.length
+	`, id, length); err != nil {
+		return fmt.Errorf("failed to index keywords: %w", err)
+	}
+	return nil
+}
+
+// unindexKeywords removes an episode's postings. Its keyword_docs row is
+// left for indexKeywords to overwrite, or removed by deleteKeywordDoc.
+func unindexKeywords(ctx context.Context, ex execer, id string) error {
+	if _, err := ex.ExecContext(ctx, "DELETE FROM keyword_postings WHERE episode_id = ?", id); err != nil {
+		return fmt.Errorf("failed to unindex keywords: %w", err)
+	}
+	return nil
+}
+
+// deleteKeywordDoc drops an episode from the keyword index entirely
+func deleteKeywordDoc(ctx context.Context, ex execer, id string) error {
+	if err := unindexKeywords(ctx, ex, id); err != nil {
+		return err
+	}
+	if _, err := ex.ExecContext(ctx, keywordStatsDrop, id, id); err != nil {
+		return fmt.Errorf("failed to unindex keywords: %w", err)
+	}
+	if _, err := ex.ExecContext(ctx, "DELETE FROM keyword_docs WHERE episode_id = ?", id); err != nil {
+		return fmt.Errorf("failed to unindex keywords: %w", err)
+	}
+	return nil
+}
+
+// keywordScores returns a subquery yielding (episode_id, score) — the BM25
+// score of every episode containing at least one of terms — and its args.
+// Placeholders are numbered from argIdx, as Search builds its query.
+// Collection statistics cover every indexed episode, expired ones included.
+func keywordScores(terms []string, argIdx int) (string, []interface{}) {
+	placeholders := make([]string, len(terms))
+	args := make([]interface{}, len(terms))
+	for i, term := range terms {
+		placeholders[i] = fmt.Sprintf("$%d", argIdx+i)
+		args[i] = term
+	}
+	in := strings.Join(placeholders, ", ")
+
+	return fmt.Sprintf(`(
+		SELECT p.episode_id,
+		       SUM(ln(1 + (st.n - df.df + 0.5) / (df.df + 0.5))
+		           * p.tf * %[2]g / (p.tf + %[1]g * (1 - %[3]g + %[3]g * d.length / st.avgdl))) AS score
+		FROM keyword_postings p
+		JOIN (SELECT term, COUNT(*) AS df FROM keyword_postings WHERE term IN (%[4]s) GROUP BY term) df
+		  ON df.term = p.term
+		JOIN keyword_docs d ON d.episode_id = p.episode_id
+		CROSS JOIN (SELECT docs AS n, total_length / GREATEST(docs, 1) AS avgdl FROM keyword_stats) st
+		WHERE p.term IN (%[4]s)
+		GROUP BY p.episode_id
+	)`, bm25K1, bm25K1+1, bm25B, in), args
+}
+
+// ensureKeywordIndex builds the keyword index from scratch when it was built
+// with a different analysis (or never, for a database that predates it).
+// This is a one-off cost at startup; afterwards writes keep it current.
+func (s *Store) ensureKeywordIndex(ctx context.Context) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	// An index that predates keyword_stats is counted once
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO keyword_stats (docs, total_length)
+		SELECT * FROM (SELECT COUNT(*), COALESCE(SUM(length), 0) FROM keyword_docs)
+		WHERE NOT EXISTS (SELECT 1 FROM keyword_stats)
+	`); err != nil {
+		return fmt.Errorf("failed to count keyword index: %w", err)
+	}
+
+	version, _, err := s.getSetting(ctx, settingKeywordIndex)
+	if err != nil {
+		return err
+	}
+	if version == keyword.Version {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		"DELETE FROM keyword_postings",
+		"DELETE FROM keyword_docs",
+		"UPDATE keyword_stats SET docs = 0, total_length = 0",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to reset keyword index: %w", err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, COALESCE(name, ''), content FROM episodes")
+	if err != nil {
+		return fmt.Errorf("failed to read episodes for keyword index: %w", err)
+	}
+	type doc struct{ id, name, content string }
+	var docs []doc
+	for rows.Next() {
+		var d doc
+		if err := rows.Scan(&d.id, &d.name, &d.content); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read episodes for keyword index: %w", err)
+		}
+		docs = append(docs, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read episodes for keyword index: %w", err)
+	}
+
+	for _, d := range docs {
+		if err := indexKeywords(ctx, tx, d.id, d.name, d.content); err != nil {
+			return err
+		}
+	}
+	if err := setSetting(ctx, tx, settingKeywordIndex, keyword.Version); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit keyword index: %w", err)
+	}
+	if len(docs) > 0 {
+		fmt.Fprintf(os.Stderr, "Migration: built keyword index for %d episodes\n", len(docs))
+	}
+	return nil
+}
+
+// dropLegacyFTSIndex removes the index DuckDB's FTS extension left behind
+// before the keyword index replaced it. Best-effort: it is unused either way.
+func dropLegacyFTSIndex(db *sql.DB) {
+	if _, err := db.Exec("DROP SCHEMA IF EXISTS fts_main_episodes CASCADE"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to drop legacy FTS index: %v\n", err)
+	}
+}
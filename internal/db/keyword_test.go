@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestKeywordIndex(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	keywordSearch := func(query string) []models.Episode {
+		t.Helper()
+		results, err := store.Search(ctx, models.SearchParams{Query: query, SearchMode: "keyword", MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		return results
+	}
+	countRows := func(table, id string) int {
+		t.Helper()
+		var n int
+		if err := store.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE episode_id = ?", id).Scan(&n); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		return n
+	}
+
+	ep := &models.Episode{Name: "Caching notes", Content: "Databases love caches", Source: "test"}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	t.Run("stemmed terms match", func(t *testing.T) {
+		if got := keywordSearch("cached database"); len(got) != 1 || got[0].ID != ep.ID {
+			t.Errorf("Expected the episode by stem, got %d results", len(got))
+		}
+		if got := keywordSearch("notes"); len(got) != 1 {
+			t.Errorf("Expected the name to be indexed, got %d results", len(got))
+		}
+	})
+
+	t.Run("query syntax is inert", func(t *testing.T) {
+		keywordSearch("'; DROP TABLE episodes; --")
+		if got := keywordSearch("databases"); len(got) != 1 {
+			t.Errorf("Expected the episode to survive, got %d results", len(got))
+		}
+	})
+
+	t.Run("rebuilt when the analysis changes", func(t *testing.T) {
+		if err := setSetting(ctx, store.db, settingKeywordIndex, "stale"); err != nil {
+			t.Fatalf("Failed to set version: %v", err)
+		}
+		if _, err := store.db.Exec("DELETE FROM keyword_postings"); err != nil {
+			t.Fatalf("Failed to clear postings: %v", err)
+		}
+		if err := store.ensureKeywordIndex(ctx); err != nil {
+			t.Fatalf("ensureKeywordIndex failed: %v", err)
+		}
+		if got := keywordSearch("caching"); len(got) != 1 {
+			t.Errorf("Expected the rebuilt index to match, got %d results", len(got))
+		}
+	})
+
+	t.Run("stats follow writes", func(t *testing.T) {
+		other := &models.Episode{Content: "Queues retry with backoff", Source: "test"}
+		if err := store.InsertEpisode(ctx, other); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+		content := "Queues retry"
+		if err := store.UpdateEpisode(ctx, other.ID, models.UpdateParams{Content: &content}); err != nil {
+			t.Fatalf("Failed to edit: %v", err)
+		}
+		if err := store.DeleteEpisode(ctx, other.ID); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+
+		var docs, total, wantDocs, wantTotal int
+		if err := store.db.QueryRow("SELECT docs, total_length FROM keyword_stats").Scan(&docs, &total); err != nil {
+			t.Fatalf("Failed to read stats: %v", err)
+		}
+		if err := store.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(length), 0) FROM keyword_docs").Scan(&wantDocs, &wantTotal); err != nil {
+			t.Fatalf("Failed to count docs: %v", err)
+		}
+		if docs != wantDocs || total != wantTotal {
+			t.Errorf("Expected stats %d docs of total length %d, got %d and %d", wantDocs, wantTotal, docs, total)
+		}
+	})
+
+	t.Run("delete removes postings", func(t *testing.T) {
+		if err := store.DeleteEpisode(ctx, ep.ID); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+		if n := countRows("keyword_postings", ep.ID) + countRows("keyword_docs", ep.ID); n != 0 {
+			t.Errorf("Expected no index rows after delete, got %d", n)
+		}
+		if got := keywordSearch("caching"); len(got) != 0 {
+			t.Errorf("Expected no results after delete, got %d", len(got))
+		}
+	})
+}
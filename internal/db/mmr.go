@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Diversified search re-ranks by maximal marginal relevance (Carbonell &
+// Goldstein, 1998): results are picked one at a time, each time taking the
+// episode that best balances its relevance against its similarity to the
+// episodes already picked. SearchParams.Diversity is the weight on that
+// redundancy penalty, so MMR's λ is 1 - Diversity.
+//
+// The re-ranking runs over a window of the best results by relevance, fixed
+// on the first page; later pages continue the same ordering by offset.
+
+// mmrWindowMin is the smallest window diversified search re-ranks
+const mmrWindowMin = 50
+
+// mmrWindow sizes the window for a first page of limit results
+func mmrWindow(limit int) int {
+	return max(mmrWindowMin, 5*limit)
+}
+
+// diversify reorders episodes, best first by relevance, by maximal marginal
+// relevance. Episodes without a vector in vectors are never penalised as
+// redundant.
+func diversify(episodes []models.Episode, vectors map[string][]float32, diversity float64) []models.Episode {
+	lambda := 1 - diversity
+	remaining := append([]models.Episode(nil), episodes...)
+	picked := make([]models.Episode, 0, len(episodes))
+
+	// redundancy[i] is remaining[i]'s highest similarity to a picked episode
+	redundancy := make([]float64, len(remaining))
+	for len(remaining) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for i, ep := range remaining {
+			relevance := 0.0
+			if ep.Relevance != nil {
+				relevance = *ep.Relevance
+			}
+			if score := lambda*relevance - diversity*redundancy[i]; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		chosen := remaining[best]
+		picked = append(picked, chosen)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		redundancy = append(redundancy[:best], redundancy[best+1:]...)
+
+		chosenVec := vectors[chosen.ID]
+		for i, ep := range remaining {
+			if sim := cosine(chosenVec, vectors[ep.ID]); sim > redundancy[i] {
+				redundancy[i] = sim
+			}
+		}
+	}
+	return picked
+}
+
+// cosine returns the cosine similarity of a and b, or 0 when either is
+// missing, zero, or of a different size
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// searchVectors returns the searched vectors of the given episodes, keyed by
+// episode ID. Episodes without one are left out.
+func (s *Store) searchVectors(ctx context.Context, episodes []models.Episode) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(episodes))
+	if len(episodes) == 0 {
+		return vectors, nil
+	}
+
+	vec, _, from := s.exportVector()
+	placeholders := make([]string, len(episodes))
+	args := make([]interface{}, len(episodes))
+	for i, ep := range episodes {
+		placeholders[i] = "?"
+		args[i] = ep.ID
+	}
+	query := fmt.Sprintf("SELECT episodes.id, CAST(to_json(%s) AS VARCHAR) FROM %s WHERE episodes.id IN (%s) AND %s IS NOT NULL",
+		vec, from, strings.Join(placeholders, ", "), vec)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vectors: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, fmt.Errorf("failed to read vectors: %w", err)
+		}
+		var v []float32
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, fmt.Errorf("failed to parse vector for %s: %w", id, err)
+		}
+		vectors[id] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vectors: %w", err)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestDiversify(t *testing.T) {
+	rel := func(v float64) *float64 { return &v }
+	episodes := []models.Episode{
+		{ID: "a", Relevance: rel(1.0)},
+		{ID: "a-copy", Relevance: rel(0.99)},
+		{ID: "b", Relevance: rel(0.8)},
+		{ID: "no-vector", Relevance: rel(0.1)},
+	}
+	vectors := map[string][]float32{
+		"a":      {1, 0},
+		"a-copy": {1, 0.01},
+		"b":      {0, 1},
+	}
+	ids := func(eps []models.Episode) []string {
+		out := make([]string, len(eps))
+		for i, ep := range eps {
+			out[i] = ep.ID
+		}
+		return out
+	}
+
+	cases := []struct {
+		diversity float64
+		want      []string
+	}{
+		// No penalty: relevance order
+		{0, []string{"a", "a-copy", "b", "no-vector"}},
+		// The copy is nearly identical to a, so b overtakes it
+		{0.3, []string{"a", "b", "a-copy", "no-vector"}},
+		// Diversity only: the unpenalised episode without a vector wins
+		{1, []string{"a", "b", "no-vector", "a-copy"}},
+	}
+	for _, tc := range cases {
+		got := ids(diversify(episodes, vectors, tc.diversity))
+		for i := range tc.want {
+			if got[i] != tc.want[i] {
+				t.Errorf("diversity %v: got %v, want %v", tc.diversity, got, tc.want)
+				break
+			}
+		}
+	}
+	if episodes[1].ID != "a-copy" {
+		t.Error("diversify must not reorder its input")
+	}
+}
+
+func TestSearchDiversity(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	// Three saves of one fact, a different and slightly less relevant one,
+	// and an unrelated one that spreads the normalised relevance
+	emb := func(x, y, z float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1], v[2] = x, y, z
+		return v
+	}
+	episodes := []*models.Episode{
+		{Content: "deploys go through CI", Source: "test", Embedding: emb(0.9, 0.436, 0)},
+		{Content: "deploys go through CI (again)", Source: "test", Embedding: emb(0.9, 0.44, 0)},
+		{Content: "deploys go through CI (once more)", Source: "test", Embedding: emb(0.9, 0.445, 0)},
+		{Content: "staging mirrors production", Source: "test", Embedding: emb(0.89, -0.456, 0)},
+		{Content: "the office plant needs water", Source: "test", Embedding: emb(0, 0, 1)},
+	}
+	for _, ep := range episodes {
+		ep.EmbeddingModel = "test-model"
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	params := models.SearchParams{QueryEmbedding: emb(1, 0, 0), MaxResults: 2}
+
+	plain, err := store.Search(ctx, params)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if plain[1].ID == episodes[3].ID {
+		t.Fatal("Expected the duplicates to crowd the first page without diversity")
+	}
+
+	params.Diversity = 0.5
+	pages := walkPages(t, store, params)
+	if len(pages) != 3 || len(pages[0]) != 2 {
+		t.Fatalf("Expected pages of 2, 2, 1, got %d pages", len(pages))
+	}
+	if pages[0][0].ID != episodes[0].ID || pages[0][1].ID != episodes[3].ID {
+		t.Errorf("Expected the best match then the distinct one, got %q, %q", pages[0][0].Content, pages[0][1].Content)
+	}
+}
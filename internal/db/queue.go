@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueuedEmbedding is an episode waiting for its embedding to be generated
+type QueuedEmbedding struct {
+	ID       string
+	Text     string
+	Attempts int
+}
+
+// enqueueEmbedding schedules id for embedding. An existing entry keeps its
+// schedule and attempt count, so re-queuing never resets backoff.
+func enqueueEmbedding(ctx context.Context, ex execer, id string, at time.Time) error {
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO embedding_queue (episode_id, attempts, next_attempt_at, enqueued_at)
+		VALUES (?, 0, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (episode_id) DO NOTHING
+	`, id, at)
+	if err != nil {
+		return fmt.Errorf("failed to queue embedding: %w", err)
+	}
+	return nil
+}
+
+// EnqueueEmbedding schedules an episode for background embedding, due now.
+// InsertEpisode already queues every episode stored without a vector; this is
+// for callers that discover a missing vector later.
+func (s *Store) EnqueueEmbedding(ctx context.Context, id string) error {
+	return enqueueEmbedding(ctx, s.db, id, time.Now())
+}
+
+// DueEmbeddings returns up to limit queued episodes whose next attempt is due
+// at now and that still lack a current embedding for model, oldest schedule
+// first. Expired episodes are skipped (see livePredicate).
+func (s *Store) DueEmbeddings(ctx context.Context, model string, now time.Time, limit int) ([]QueuedEmbedding, error) {
+	stale, staleArgs := s.stalePredicateFor(model)
+	query := `
+		SELECT q.episode_id, episodes.content, q.attempts
+		FROM embedding_queue q JOIN episodes ON episodes.id = q.episode_id
+		WHERE q.next_attempt_at <= ? AND ` + livePredicate + ` AND ` + stale + `
+		ORDER BY q.next_attempt_at, q.episode_id
+		LIMIT ?`
+	args := append([]interface{}{now}, staleArgs...)
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var items []QueuedEmbedding
+	for rows.Next() {
+		var it QueuedEmbedding
+		if err := rows.Scan(&it.ID, &it.Text, &it.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan queued embedding: %w", err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// CompleteEmbedding removes id from the queue
+func (s *Store) CompleteEmbedding(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM embedding_queue WHERE episode_id = ?", id); err != nil {
+		return fmt.Errorf("failed to dequeue embedding: %w", err)
+	}
+	return nil
+}
+
+// RetryEmbeddingLater records a failed attempt and reschedules id for next
+func (s *Store) RetryEmbeddingLater(ctx context.Context, id string, next time.Time, reason string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE embedding_queue
+		SET attempts = attempts + 1, next_attempt_at = ?, last_error = ?
+		WHERE episode_id = ?
+	`, next, reason, id)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule embedding: %w", err)
+	}
+	return nil
+}
+
+// ResetEmbeddingBackoff makes every queued entry due immediately. Called when
+// the embedding endpoint recovers: entries backed off during the outage
+// failed for a reason that no longer holds.
+func (s *Store) ResetEmbeddingBackoff(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "UPDATE embedding_queue SET next_attempt_at = ?", time.Now()); err != nil {
+		return fmt.Errorf("failed to reset embedding backoff: %w", err)
+	}
+	return nil
+}
+
+// PruneEmbeddingQueue drops entries that no longer need work for model: the
+// episode was deleted or expired, or it already has a current embedding
+// (e.g. the re-embed pass got there first). Returns the number removed.
+func (s *Store) PruneEmbeddingQueue(ctx context.Context, model string) (int, error) {
+	stale, staleArgs := s.stalePredicateFor(model)
+	query := `
+		DELETE FROM embedding_queue
+		WHERE episode_id NOT IN (
+			SELECT id FROM episodes WHERE ` + livePredicate + ` AND ` + stale + `
+		)`
+	res, err := s.db.ExecContext(ctx, query, staleArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune embedding queue: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// CountQueuedEmbeddings returns the number of episodes waiting for an embedding
+func (s *Store) CountQueuedEmbeddings(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM embedding_queue").Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count queued embeddings: %w", err)
+	}
+	return n, nil
+}
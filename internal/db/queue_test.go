@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEmbeddingQueue(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Now()
+
+	pending := &models.Episode{Content: "stored while offline", Source: "test"}
+	embedded := &models.Episode{Content: "stored online", Source: "test", Embedding: makeEmbedding(0), EmbeddingModel: "m"}
+	for _, ep := range []*models.Episode{pending, embedded} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	t.Run("insert without a vector enqueues", func(t *testing.T) {
+		n, err := store.CountQueuedEmbeddings(ctx)
+		if err != nil {
+			t.Fatalf("CountQueuedEmbeddings failed: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("Expected 1 queued embedding, got %d", n)
+		}
+		due, err := store.DueEmbeddings(ctx, "m", now.Add(time.Second), 10)
+		if err != nil {
+			t.Fatalf("DueEmbeddings failed: %v", err)
+		}
+		if len(due) != 1 || due[0].ID != pending.ID || due[0].Text != pending.Content {
+			t.Errorf("Expected the vectorless episode due, got %+v", due)
+		}
+	})
+
+	t.Run("failed attempt backs off", func(t *testing.T) {
+		if err := store.RetryEmbeddingLater(ctx, pending.ID, now.Add(time.Hour), "connection refused"); err != nil {
+			t.Fatalf("RetryEmbeddingLater failed: %v", err)
+		}
+		due, _ := store.DueEmbeddings(ctx, "m", now.Add(time.Second), 10)
+		if len(due) != 0 {
+			t.Errorf("Expected nothing due during backoff, got %+v", due)
+		}
+		due, _ = store.DueEmbeddings(ctx, "m", now.Add(2*time.Hour), 10)
+		if len(due) != 1 || due[0].Attempts != 1 {
+			t.Errorf("Expected the entry due after backoff with 1 attempt, got %+v", due)
+		}
+	})
+
+	t.Run("re-queuing keeps the schedule", func(t *testing.T) {
+		if err := store.EnqueueEmbedding(ctx, pending.ID); err != nil {
+			t.Fatalf("EnqueueEmbedding failed: %v", err)
+		}
+		due, _ := store.DueEmbeddings(ctx, "m", now.Add(time.Second), 10)
+		if len(due) != 0 {
+			t.Errorf("Re-queuing should not reset backoff, got %+v", due)
+		}
+	})
+
+	t.Run("reset makes backed-off entries due", func(t *testing.T) {
+		if err := store.ResetEmbeddingBackoff(ctx); err != nil {
+			t.Fatalf("ResetEmbeddingBackoff failed: %v", err)
+		}
+		due, _ := store.DueEmbeddings(ctx, "m", time.Now().Add(time.Second), 10)
+		if len(due) != 1 {
+			t.Errorf("Expected the entry due after reset, got %+v", due)
+		}
+	})
+
+	t.Run("prune drops entries that no longer need work", func(t *testing.T) {
+		if err := store.UpdateEpisodeEmbedding(ctx, pending.ID, makeEmbedding(0), "m"); err != nil {
+			t.Fatalf("UpdateEpisodeEmbedding failed: %v", err)
+		}
+		removed, err := store.PruneEmbeddingQueue(ctx, "m")
+		if err != nil {
+			t.Fatalf("PruneEmbeddingQueue failed: %v", err)
+		}
+		if removed != 1 {
+			t.Errorf("Expected 1 entry pruned, got %d", removed)
+		}
+	})
+
+	t.Run("delete removes the queue entry", func(t *testing.T) {
+		ep := &models.Episode{Content: "deleted before embedding", Source: "test"}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+		if err := store.DeleteEpisode(ctx, ep.ID); err != nil {
+			t.Fatalf("DeleteEpisode failed: %v", err)
+		}
+		if n, _ := store.CountQueuedEmbeddings(ctx); n != 0 {
+			t.Errorf("Expected empty queue after delete, got %d", n)
+		}
+	})
+}
@@ -30,12 +30,24 @@ func (c StaleEmbeddingCounts) Total() int {
 const stalePredicate = "(embedding IS NULL OR embedding_model IS DISTINCT FROM ?)"
 
 // livePredicate excludes expired rows: re-embedding them wastes work, and an
-// expired row that can never embed (e.g. content over the model's context
-// window) would otherwise keep the stale count from reaching zero.
+// expired row that can never embed (e.g. content the model rejects, with
+// chunking off) would otherwise keep the stale count from reaching zero.
 const livePredicate = "(expired_at IS NULL OR expired_at > CURRENT_TIMESTAMP)"
 
+// stalePredicateFor returns the staleness test for model's vectors. A model
+// with its own embedding space is stale wherever the space has no row — every
+// vector in a space was produced by its model, so there is no stamp to check.
+// Other models are checked against the primary column (see stalePredicate).
+func (s *Store) stalePredicateFor(model string) (string, []interface{}) {
+	if sp, ok := s.lookupSpace(model); ok {
+		return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s sv WHERE sv.episode_id = episodes.id)", sp.table), nil
+	}
+	return stalePredicate, []interface{}{model}
+}
+
 // CountReembedTargets counts rows the re-embed pass would touch. With force,
-// every live row counts; otherwise only live stale rows (see stalePredicate).
+// every live row counts; otherwise only live stale rows (see
+// stalePredicateFor).
 func (s *Store) CountReembedTargets(ctx context.Context, model string, force bool) (StaleEmbeddingCounts, error) {
 	var counts StaleEmbeddingCounts
 	for _, t := range []struct {
@@ -51,8 +63,9 @@ func (s *Store) CountReembedTargets(ctx context.Context, model string, force boo
 			conds = append(conds, t.live)
 		}
 		if !force {
-			conds = append(conds, stalePredicate)
-			args = append(args, model)
+			stale, staleArgs := s.stalePredicateFor(model)
+			conds = append(conds, stale)
+			args = append(args, staleArgs...)
 		}
 		query := fmt.Sprintf("SELECT COUNT(*) FROM %s", t.table)
 		if len(conds) > 0 {
@@ -68,13 +81,7 @@ func (s *Store) CountReembedTargets(ctx context.Context, model string, force boo
 // listForReembed pages through re-embed targets using keyset pagination on id
 // so rows re-stamped mid-run (or rows that keep failing) are never revisited
 // within a single pass.
-func (s *Store) listForReembed(ctx context.Context, query string, model string, afterID string, limit int, force bool) ([]ReembedItem, error) {
-	args := []interface{}{afterID}
-	if !force {
-		args = append(args, model)
-	}
-	args = append(args, limit)
-
+func (s *Store) listForReembed(ctx context.Context, query string, args []interface{}) ([]ReembedItem, error) {
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
@@ -96,11 +103,15 @@ func (s *Store) listForReembed(ctx context.Context, query string, model string,
 // ordered by id, starting after afterID. The Text field is the episode content.
 func (s *Store) ListEpisodesForReembed(ctx context.Context, model string, afterID string, limit int, force bool) ([]ReembedItem, error) {
 	query := "SELECT id, content FROM episodes WHERE id > ? AND " + livePredicate
+	args := []interface{}{afterID}
 	if !force {
-		query += " AND " + stalePredicate
+		stale, staleArgs := s.stalePredicateFor(model)
+		query += " AND " + stale
+		args = append(args, staleArgs...)
 	}
 	query += " ORDER BY id LIMIT ?"
-	items, err := s.listForReembed(ctx, query, model, afterID, limit, force)
+	args = append(args, limit)
+	items, err := s.listForReembed(ctx, query, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list episodes for re-embed: %w", err)
 	}
@@ -125,7 +136,28 @@ func (s *Store) updateEmbedding(ctx context.Context, table, id string, embedding
 	return nil
 }
 
-// UpdateEpisodeEmbedding replaces an episode's embedding and provenance stamp
+// UpdateEpisodeEmbedding replaces an episode's embedding for model: in the
+// model's embedding space when one is registered, otherwise in the primary
+// column along with its provenance stamp
 func (s *Store) UpdateEpisodeEmbedding(ctx context.Context, id string, embedding []float32, model string) error {
-	return s.updateEmbedding(ctx, "episodes", id, embedding, model)
+	sp, ok := s.lookupSpace(model)
+	if !ok {
+		return s.updateEmbedding(ctx, "episodes", id, embedding, model)
+	}
+	if len(embedding) != sp.Dimensions {
+		return fmt.Errorf("%s embedding has %d dimensions, its space stores %d", model, len(embedding), sp.Dimensions)
+	}
+	// Space tables carry no foreign key; refuse orphans explicitly
+	exists, err := s.episodeExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("episodes row not found: %s", id)
+	}
+	embJSON, err := json.Marshal(embedding)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding: %w", err)
+	}
+	return upsertSpaceVector(ctx, s.db, sp, id, string(embJSON))
 }
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store-level settings recorded in the database itself, so a file carries
+// its own configuration wherever it is opened
+const (
+	settingEmbeddingDimensions  = "embedding_dimensions"
+	settingActiveEmbeddingModel = "active_embedding_model" // "" = primary column
+)
+
+// execer is the subset of *sql.DB and *sql.Tx used by helpers that must run
+// either standalone or inside a caller's transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// getSetting reads a setting. ok is false when the key has never been set.
+func (s *Store) getSetting(ctx context.Context, key string) (value string, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx, "SELECT value FROM engram_settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read setting %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// setSetting writes a setting, replacing any previous value
+func setSetting(ctx context.Context, ex execer, key, value string) error {
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO engram_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to write setting %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// ErrSeedNotFound is returned by a similar_to search whose seed episode
+// doesn't exist
+var ErrSeedNotFound = errors.New("similar_to episode not found")
+
+// seedSearch turns a search for episodes similar to params.SimilarTo into an
+// ordinary one. The seed's stored vector becomes the query embedding, so no
+// embedding call is needed; a seed without one is searched for by keyword,
+// with its content as the query. A query already in params is kept.
+func (s *Store) seedSearch(ctx context.Context, params models.SearchParams) (models.SearchParams, error) {
+	var content string
+	err := s.db.QueryRowContext(ctx, "SELECT content FROM episodes WHERE id = ?", params.SimilarTo).Scan(&content)
+	if err == sql.ErrNoRows {
+		return params, fmt.Errorf("%w: %s", ErrSeedNotFound, params.SimilarTo)
+	}
+	if err != nil {
+		return params, fmt.Errorf("failed to read similar_to episode: %w", err)
+	}
+	if params.Query == "" {
+		params.Query = content
+	}
+
+	if params.SearchMode != "keyword" {
+		vectors, err := s.searchVectors(ctx, []models.Episode{{ID: params.SimilarTo}})
+		if err != nil {
+			return params, err
+		}
+		if vec, ok := vectors[params.SimilarTo]; ok {
+			params.QueryEmbedding = vec
+			return params, nil
+		}
+	}
+	params.SearchMode = "keyword"
+	return params, nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestSearchSimilarTo(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	episodes := []*models.Episode{
+		{Content: "rollbacks use the previous image tag", Source: "test", Embedding: emb(1, 0)},
+		{Content: "deploys pin the image digest", Source: "test", Embedding: emb(0.9, 0.1)},
+		{Content: "lunch is at noon", Source: "test", GroupID: "other", Embedding: emb(0, 1)},
+		{Content: "staging rollbacks are manual", Source: "test"},
+	}
+	for _, ep := range episodes {
+		if ep.Embedding != nil {
+			ep.EmbeddingModel = "test-model"
+		}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	t.Run("uses the stored vector and excludes the seed", func(t *testing.T) {
+		results, err := store.Search(ctx, models.SearchParams{SimilarTo: episodes[0].ID, MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 2 || results[0].ID != episodes[1].ID {
+			t.Fatalf("Expected the nearest other episode first of 2, got %v", results)
+		}
+		for _, ep := range results {
+			if ep.ID == episodes[0].ID {
+				t.Error("Expected the seed to be excluded")
+			}
+		}
+	})
+
+	t.Run("honors filters", func(t *testing.T) {
+		results, err := store.Search(ctx, models.SearchParams{SimilarTo: episodes[0].ID, GroupID: "other", MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != episodes[2].ID {
+			t.Errorf("Expected only the episode in the filtered group, got %v", results)
+		}
+	})
+
+	t.Run("falls back to keyword without a vector", func(t *testing.T) {
+		results, err := store.Search(ctx, models.SearchParams{SimilarTo: episodes[3].ID, MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != episodes[0].ID {
+			t.Errorf("Expected the episode sharing a keyword, got %v", results)
+		}
+	})
+
+	t.Run("unknown seed", func(t *testing.T) {
+		_, err := store.Search(ctx, models.SearchParams{SimilarTo: "missing"})
+		if !errors.Is(err, ErrSeedNotFound) {
+			t.Errorf("Expected ErrSeedNotFound, got %v", err)
+		}
+	})
+}
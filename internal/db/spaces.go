@@ -0,0 +1,307 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EmbeddingSpace is a registered per-model vector table. The episodes table
+// keeps its primary embedding column; each space holds an independent set of
+// vectors for one model with its own HNSW index, so a replacement model can
+// be backfilled alongside the live one and switched to in a single step.
+type EmbeddingSpace struct {
+	Model      string    `json:"model"`
+	Dimensions int       `json:"dimensions"`
+	CreatedAt  time.Time `json:"created_at"`
+	table      string
+}
+
+// loadEmbeddingSpaces reads the space registry and the active-space setting
+// into memory. Search consults them on every call, so they are cached rather
+// than queried.
+func (s *Store) loadEmbeddingSpaces(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT model, table_name, dimensions, created_at FROM embedding_spaces")
+	if err != nil {
+		return fmt.Errorf("failed to load embedding spaces: %w", err)
+	}
+	defer rows.Close()
+
+	spaces := make(map[string]EmbeddingSpace)
+	for rows.Next() {
+		var sp EmbeddingSpace
+		if err := rows.Scan(&sp.Model, &sp.table, &sp.Dimensions, &sp.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan embedding space: %w", err)
+		}
+		spaces[sp.Model] = sp
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load embedding spaces: %w", err)
+	}
+
+	active, _, err := s.getSetting(ctx, settingActiveEmbeddingModel)
+	if err != nil {
+		return err
+	}
+	if _, ok := spaces[active]; active != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Warning: active embedding space %q is not registered; searching the primary embedding column\n", active)
+		active = ""
+	}
+
+	s.spacesMu.Lock()
+	s.spaces = spaces
+	s.activeModel = active
+	s.spacesMu.Unlock()
+	return nil
+}
+
+// lookupSpace returns the registered space for model, if any
+func (s *Store) lookupSpace(model string) (EmbeddingSpace, bool) {
+	if model == "" {
+		return EmbeddingSpace{}, false
+	}
+	s.spacesMu.RLock()
+	defer s.spacesMu.RUnlock()
+	sp, ok := s.spaces[model]
+	return sp, ok
+}
+
+// LookupEmbeddingSpace returns the registered space for model, if any
+func (s *Store) LookupEmbeddingSpace(model string) (EmbeddingSpace, bool) {
+	return s.lookupSpace(model)
+}
+
+// activeSpace returns the space Search queries. ok is false when search uses
+// the primary embedding column.
+func (s *Store) activeSpace() (EmbeddingSpace, bool) {
+	s.spacesMu.RLock()
+	defer s.spacesMu.RUnlock()
+	if s.activeModel == "" {
+		return EmbeddingSpace{}, false
+	}
+	sp, ok := s.spaces[s.activeModel]
+	return sp, ok
+}
+
+// ActiveEmbeddingModel returns the model whose space Search queries, or ""
+// when search uses the primary embedding column
+func (s *Store) ActiveEmbeddingModel() string {
+	s.spacesMu.RLock()
+	defer s.spacesMu.RUnlock()
+	return s.activeModel
+}
+
+// ActiveEmbeddingDimensions returns the vector size Search expects query
+// embeddings to have
+func (s *Store) ActiveEmbeddingDimensions() int {
+	if sp, ok := s.activeSpace(); ok {
+		return sp.Dimensions
+	}
+	return s.dims
+}
+
+// SpaceDimensions returns the vector size stored for model: its space's size
+// when one is registered, otherwise the primary column's
+func (s *Store) SpaceDimensions(model string) int {
+	if sp, ok := s.lookupSpace(model); ok {
+		return sp.Dimensions
+	}
+	return s.dims
+}
+
+// EmbeddingSpaces returns the registered spaces ordered by model name
+func (s *Store) EmbeddingSpaces() []EmbeddingSpace {
+	s.spacesMu.RLock()
+	spaces := make([]EmbeddingSpace, 0, len(s.spaces))
+	for _, sp := range s.spaces {
+		spaces = append(spaces, sp)
+	}
+	s.spacesMu.RUnlock()
+	sort.Slice(spaces, func(i, j int) bool { return spaces[i].Model < spaces[j].Model })
+	return spaces
+}
+
+// CreateEmbeddingSpace registers a vector table for model. From then on,
+// vectors stamped with model are written to the space instead of the primary
+// column, and the re-embed pass for model fills the space. The new space is
+// not searched until ActivateEmbeddingSpace selects it.
+func (s *Store) CreateEmbeddingSpace(ctx context.Context, model string, dims int) (EmbeddingSpace, error) {
+	if strings.TrimSpace(model) == "" {
+		return EmbeddingSpace{}, fmt.Errorf("embedding space model is required")
+	}
+	if dims <= 0 {
+		return EmbeddingSpace{}, fmt.Errorf("embedding dimensions must be positive, got %d", dims)
+	}
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if _, ok := s.lookupSpace(model); ok {
+		return EmbeddingSpace{}, fmt.Errorf("embedding space already exists: %s", model)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Table names come from a counter, never from the model name, so any
+	// model string is safe to register
+	var id int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) + 1 FROM embedding_spaces").Scan(&id); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to allocate embedding space: %w", err)
+	}
+	sp := EmbeddingSpace{
+		Model:      model,
+		Dimensions: dims,
+		CreatedAt:  time.Now(),
+		table:      fmt.Sprintf("embedding_space_%d", id),
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO embedding_spaces (id, model, table_name, dimensions, created_at) VALUES (?, ?, ?, ?, ?)",
+		id, sp.Model, sp.table, sp.Dimensions, sp.CreatedAt,
+	); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to register embedding space: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %s (
+			episode_id VARCHAR PRIMARY KEY,
+			embedding FLOAT[%d] NOT NULL
+		)`, sp.table, sp.Dimensions)); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to create embedding space table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, chunkTableDDL(sp.table, sp.Dimensions)); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to create embedding space chunk table: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return EmbeddingSpace{}, fmt.Errorf("failed to commit embedding space: %w", err)
+	}
+
+	// Best-effort like the primary column's index
+	ensureVectorIndex(ctx, s.db, sp.table)
+	ensureVectorIndex(ctx, s.db, chunkTable(sp.table))
+
+	// Catalog changes must not wait in the WAL (see initialize)
+	if _, err := s.db.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: post-DDL checkpoint failed: %v\n", err)
+	}
+
+	s.spacesMu.Lock()
+	s.spaces[sp.Model] = sp
+	s.spacesMu.Unlock()
+	return sp, nil
+}
+
+// ErrSpaceIncomplete is returned when activating a space that live episodes
+// still have no vector in
+var ErrSpaceIncomplete = errors.New("embedding space backfill incomplete")
+
+// ActivateEmbeddingSpace switches Search to model's space. An empty model
+// switches back to the primary embedding column. Unless force is set, a
+// space that some live episode has no vector in is refused with
+// ErrSpaceIncomplete: those episodes would drop out of vector search. The
+// check, the setting and the in-memory flip run with episode writes and
+// other space changes held off, and every search sees either the old space
+// or the new one — never a mix.
+func (s *Store) ActivateEmbeddingSpace(ctx context.Context, model string, force bool) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if model != "" {
+		if _, ok := s.lookupSpace(model); !ok {
+			return fmt.Errorf("embedding space not found: %s", model)
+		}
+		if !force {
+			counts, err := s.CountReembedTargets(ctx, model, false)
+			if err != nil {
+				return err
+			}
+			if counts.Total() > 0 {
+				return fmt.Errorf("%w: %d live episodes have no %s embedding yet", ErrSpaceIncomplete, counts.Total(), model)
+			}
+		}
+	}
+	if err := setSetting(ctx, s.db, settingActiveEmbeddingModel, model); err != nil {
+		return err
+	}
+	s.spacesMu.Lock()
+	s.activeModel = model
+	s.spacesMu.Unlock()
+	return nil
+}
+
+// DropEmbeddingSpace removes model's space and its vectors, chunk vectors
+// included. The active space cannot be dropped; activate another one first.
+// Like activation, it runs with other space changes held off.
+func (s *Store) DropEmbeddingSpace(ctx context.Context, model string) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	sp, ok := s.lookupSpace(model)
+	if !ok {
+		return fmt.Errorf("embedding space not found: %s", model)
+	}
+	if s.ActiveEmbeddingModel() == model {
+		return fmt.Errorf("cannot drop the active embedding space %s", model)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM embedding_spaces WHERE model = ?", model); err != nil {
+		return fmt.Errorf("failed to unregister embedding space: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", sp.table)); err != nil {
+		return fmt.Errorf("failed to drop embedding space table: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", chunkTable(sp.table))); err != nil {
+		return fmt.Errorf("failed to drop embedding space chunk table: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embedding space drop: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: post-DDL checkpoint failed: %v\n", err)
+	}
+
+	s.spacesMu.Lock()
+	delete(s.spaces, model)
+	s.spacesMu.Unlock()
+	return nil
+}
+
+// upsertSpaceVector writes id's vector into a space table, replacing any
+// previous one
+func upsertSpaceVector(ctx context.Context, ex execer, sp EmbeddingSpace, id string, embeddingJSON string) error {
+	query := fmt.Sprintf("INSERT OR REPLACE INTO %s (episode_id, embedding) VALUES (?, ?)", sp.table)
+	if _, err := ex.ExecContext(ctx, query, id, embeddingJSON); err != nil {
+		return fmt.Errorf("failed to write %s embedding: %w", sp.Model, err)
+	}
+	return nil
+}
+
+// deleteSpaceVectors removes id's vectors from every space
+func (s *Store) deleteSpaceVectors(ctx context.Context, ex execer, id string) error {
+	for _, sp := range s.EmbeddingSpaces() {
+		if _, err := ex.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE episode_id = ?", sp.table), id); err != nil {
+			return fmt.Errorf("failed to delete %s embedding: %w", sp.Model, err)
+		}
+	}
+	return nil
+}
+
+// episodeExists reports whether an episode row with id exists
+func (s *Store) episodeExists(ctx context.Context, id string) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM episodes WHERE id = ?", id).Scan(&n)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to look up episode: %w", err)
+	}
+	return n > 0, nil
+}
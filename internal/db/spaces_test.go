@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// unitVector returns a dims-sized vector pointing along axis
+func unitVector(dims, axis int) []float32 {
+	emb := make([]float32, dims)
+	emb[axis] = 1
+	return emb
+}
+
+func TestEmbeddingSpaces(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/test.duckdb"
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Two episodes embedded with the live model in the primary column. In
+	// the primary space "cat" is nearest the query; the new model's space
+	// will say the opposite, so results reveal which space was searched.
+	cat := &models.Episode{Content: "cat", Source: "test", Embedding: unitVector(768, 0), EmbeddingModel: "old-model"}
+	dog := &models.Episode{Content: "dog", Source: "test", Embedding: unitVector(768, 1), EmbeddingModel: "old-model"}
+	for _, ep := range []*models.Episode{cat, dog} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+	}
+
+	if _, err := store.CreateEmbeddingSpace(ctx, "new-model", 1024); err != nil {
+		t.Fatalf("CreateEmbeddingSpace failed: %v", err)
+	}
+
+	t.Run("duplicate space is rejected", func(t *testing.T) {
+		if _, err := store.CreateEmbeddingSpace(ctx, "new-model", 1024); err == nil {
+			t.Error("Expected error registering a space twice")
+		}
+	})
+
+	t.Run("new space starts empty and stale", func(t *testing.T) {
+		counts, err := store.CountReembedTargets(ctx, "new-model", false)
+		if err != nil {
+			t.Fatalf("CountReembedTargets failed: %v", err)
+		}
+		if counts.Episodes != 2 {
+			t.Errorf("Expected 2 episodes missing from the new space, got %d", counts.Episodes)
+		}
+	})
+
+	t.Run("activation waits for the backfill", func(t *testing.T) {
+		if err := store.ActivateEmbeddingSpace(ctx, "new-model", false); !errors.Is(err, ErrSpaceIncomplete) {
+			t.Errorf("Expected ErrSpaceIncomplete, got %v", err)
+		}
+		if got := store.ActiveEmbeddingModel(); got != "" {
+			t.Errorf("Expected the primary column still active, got %q", got)
+		}
+	})
+
+	t.Run("backfill writes to the space, not the primary column", func(t *testing.T) {
+		items, err := store.ListEpisodesForReembed(ctx, "new-model", "", 10, false)
+		if err != nil {
+			t.Fatalf("ListEpisodesForReembed failed: %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("Expected 2 backfill targets, got %d", len(items))
+		}
+		for _, it := range items {
+			axis := 0 // the new model thinks "dog" is the cat-like one
+			if it.ID == cat.ID {
+				axis = 1
+			}
+			if err := store.UpdateEpisodeEmbedding(ctx, it.ID, unitVector(1024, axis), "new-model"); err != nil {
+				t.Fatalf("UpdateEpisodeEmbedding failed: %v", err)
+			}
+		}
+
+		counts, _ := store.CountReembedTargets(ctx, "new-model", false)
+		if counts.Episodes != 0 {
+			t.Errorf("Expected new space fully backfilled, got %d missing", counts.Episodes)
+		}
+		old, _ := store.CountReembedTargets(ctx, "old-model", false)
+		if old.Episodes != 0 {
+			t.Errorf("Primary column should be untouched by the backfill, got %d stale", old.Episodes)
+		}
+	})
+
+	t.Run("wrong-size vector is refused by the space", func(t *testing.T) {
+		if err := store.UpdateEpisodeEmbedding(ctx, cat.ID, unitVector(768, 0), "new-model"); err == nil {
+			t.Error("Expected dimension mismatch error")
+		}
+	})
+
+	t.Run("search stays on the primary column until activation", func(t *testing.T) {
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: unitVector(768, 0), MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 2 || results[0].ID != cat.ID {
+			t.Errorf("Expected cat first from the primary space, got %+v", results)
+		}
+	})
+
+	if err := store.ActivateEmbeddingSpace(ctx, "new-model", false); err != nil {
+		t.Fatalf("ActivateEmbeddingSpace failed: %v", err)
+	}
+
+	t.Run("search uses only the active space", func(t *testing.T) {
+		if got := store.ActiveEmbeddingDimensions(); got != 1024 {
+			t.Errorf("Expected active dimensions 1024, got %d", got)
+		}
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: unitVector(1024, 0), MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 2 || results[0].ID != dog.ID {
+			t.Errorf("Expected dog first from the new space, got %+v", results)
+		}
+
+		// A query embedded with the old model no longer fits
+		results, err = store.Search(ctx, models.SearchParams{QueryEmbedding: unitVector(768, 0), MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		for _, r := range results {
+			if r.Similarity != nil {
+				t.Error("Old-model query vector should not be scored against the new space")
+			}
+		}
+	})
+
+	t.Run("new writes for the active model land in its space", func(t *testing.T) {
+		ep := &models.Episode{Content: "bird", Source: "test", Embedding: unitVector(1024, 2), EmbeddingModel: "new-model"}
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert episode: %v", err)
+		}
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: unitVector(1024, 2), MaxResults: 1})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != ep.ID {
+			t.Errorf("Expected the new episode from the active space, got %+v", results)
+		}
+	})
+
+	t.Run("active space cannot be dropped", func(t *testing.T) {
+		if err := store.DropEmbeddingSpace(ctx, "new-model"); err == nil {
+			t.Error("Expected error dropping the active space")
+		}
+	})
+
+	t.Run("delete removes space vectors", func(t *testing.T) {
+		if err := store.DeleteEpisode(ctx, dog.ID); err != nil {
+			t.Fatalf("DeleteEpisode failed: %v", err)
+		}
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: unitVector(1024, 0), MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		for _, r := range results {
+			if r.ID == dog.ID {
+				t.Error("Deleted episode still returned from the space")
+			}
+		}
+	})
+
+	store.Close()
+
+	t.Run("registry and active space survive reopen", func(t *testing.T) {
+		reopened, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		defer reopened.Close()
+		if got := reopened.ActiveEmbeddingModel(); got != "new-model" {
+			t.Errorf("Expected active space new-model after reopen, got %q", got)
+		}
+		if spaces := reopened.EmbeddingSpaces(); len(spaces) != 1 || spaces[0].Dimensions != 1024 {
+			t.Errorf("Expected one 1024-dim space after reopen, got %+v", spaces)
+		}
+
+		if err := reopened.ActivateEmbeddingSpace(ctx, "", false); err != nil {
+			t.Fatalf("Switching back to the primary column failed: %v", err)
+		}
+		if err := reopened.DropEmbeddingSpace(ctx, "new-model"); err != nil {
+			t.Fatalf("DropEmbeddingSpace failed: %v", err)
+		}
+		if _, ok := reopened.LookupEmbeddingSpace("new-model"); ok {
+			t.Error("Dropped space still registered")
+		}
+	})
+}
+
+func TestSpaceActivateDropRace(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if _, err := store.CreateEmbeddingSpace(ctx, "racer", 4); err != nil {
+			t.Fatalf("CreateEmbeddingSpace failed: %v", err)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			store.ActivateEmbeddingSpace(ctx, "racer", true)
+		}()
+		go func() {
+			defer wg.Done()
+			store.DropEmbeddingSpace(ctx, "racer")
+		}()
+		wg.Wait()
+
+		if active := store.ActiveEmbeddingModel(); active != "" {
+			if _, ok := store.LookupEmbeddingSpace(active); !ok {
+				t.Fatalf("Active space %q was dropped", active)
+			}
+		}
+		if err := store.ActivateEmbeddingSpace(ctx, "", true); err != nil {
+			t.Fatalf("ActivateEmbeddingSpace failed: %v", err)
+		}
+		if _, ok := store.LookupEmbeddingSpace("racer"); ok {
+			if err := store.DropEmbeddingSpace(ctx, "racer"); err != nil {
+				t.Fatalf("DropEmbeddingSpace failed: %v", err)
+			}
+		}
+	}
+}
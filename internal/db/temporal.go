@@ -0,0 +1,51 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Episodes are bitemporal. created_at is transaction time: when memory
+// learned the fact. valid_at is valid time: when the fact became true, which
+// defaults to when it was learned. expired_at ends both; an episode expired
+// at T is no longer what memory says after T.
+//
+// An as-of search answers "what did memory say was true at T": episodes
+// learned by T, already valid at T, and not yet expired at T.
+
+// validTime is an episode's valid time, falling back to when it was stored
+const validTime = "COALESCE(valid_at, created_at)"
+
+// validityConditions returns the search conditions on when episodes were
+// true: the as-of point, the valid-time range, and the expiry check, which
+// is evaluated at the as-of point when there is one. Placeholders are
+// numbered from argIdx.
+func validityConditions(p models.SearchParams, argIdx int) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	expiredBy := "CURRENT_TIMESTAMP"
+	if p.AsOf != nil {
+		conditions = append(conditions,
+			fmt.Sprintf("created_at <= $%d", argIdx),
+			fmt.Sprintf("%s <= $%d", validTime, argIdx))
+		expiredBy = fmt.Sprintf("$%d", argIdx)
+		args = append(args, *p.AsOf)
+		argIdx++
+	}
+	if !p.IncludeExpired {
+		conditions = append(conditions, fmt.Sprintf("(expired_at IS NULL OR expired_at > %s)", expiredBy))
+	}
+
+	if p.ValidBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", validTime, argIdx))
+		args = append(args, *p.ValidBefore)
+		argIdx++
+	}
+	if p.ValidAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("%s > $%d", validTime, argIdx))
+		args = append(args, *p.ValidAfter)
+	}
+	return conditions, args
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestSearchAsOf(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	daysAgo := func(n int) *time.Time {
+		ts := time.Now().AddDate(0, 0, -n)
+		return &ts
+	}
+	// Stored 10 days ago and expired 2 days ago; stored 5 days ago but only
+	// true from yesterday; stored yesterday
+	retired := &models.Episode{Content: "deploys go through Jenkins", Source: "test", CreatedAt: *daysAgo(10), ExpiredAt: daysAgo(2)}
+	planned := &models.Episode{Content: "deploys go through Actions", Source: "test", CreatedAt: *daysAgo(5), ValidAt: daysAgo(1)}
+	recent := &models.Episode{Content: "deploys need a green build", Source: "test", CreatedAt: *daysAgo(1)}
+	for _, ep := range []*models.Episode{retired, planned, recent} {
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	search := func(p models.SearchParams) []string {
+		t.Helper()
+		p.MaxResults = 10
+		results, err := store.Search(ctx, p)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		ids := make([]string, len(results))
+		for i, ep := range results {
+			ids[i] = ep.ID
+		}
+		return ids
+	}
+	expect := func(t *testing.T, got []string, want ...*models.Episode) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("Expected %d results, got %d", len(want), len(got))
+		}
+		for i, ep := range want {
+			if got[i] != ep.ID {
+				t.Errorf("Result %d: expected %q", i, ep.Content)
+			}
+		}
+	}
+
+	t.Run("now", func(t *testing.T) {
+		expect(t, search(models.SearchParams{}), recent, planned)
+	})
+
+	t.Run("as of three days ago", func(t *testing.T) {
+		expect(t, search(models.SearchParams{AsOf: daysAgo(3)}), retired)
+	})
+
+	t.Run("as of with a keyword query", func(t *testing.T) {
+		expect(t, search(models.SearchParams{Query: "deploys", SearchMode: "keyword", AsOf: daysAgo(3)}), retired)
+	})
+
+	t.Run("valid-time range", func(t *testing.T) {
+		expect(t, search(models.SearchParams{ValidBefore: daysAgo(2), IncludeExpired: true}), retired)
+		expect(t, search(models.SearchParams{ValidAfter: daysAgo(3)}), recent, planned)
+	})
+}
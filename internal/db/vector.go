@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Vector search runs in two phases. The candidate phase asks the HNSW index
+// for the pool nearest vectors — a bare ORDER BY cosine distance LIMIT over
+// the vector table, the only shape DuckDB's VSS extension answers from the
+// index. The ranking phase joins those candidates to their episodes, applies
+// the search's filters, and normalises and ranks just that set, so a query
+// touches pool rows rather than every embedded episode.
+//
+// Filters are applied after the index lookup, so a selective filter can
+// discard the whole pool. When a first page comes back short because the
+// filters discarded candidates of a full pool — one that did not already
+// hold every vector — Search retries with exact candidates: the filters move
+// into the candidate query and the nearest pool vectors among the matching
+// episodes are found by scan.
+
+// vectorCandidates is the minimum candidate pool a vector search ranks.
+// Results beyond it are not reachable by paging.
+var vectorCandidates = 1000
+
+// exactSearches counts the searches retried with exact candidates
+var exactSearches atomic.Int64
+
+// candidatePool sizes the pool for a page of limit results
+func candidatePool(limit int) int {
+	return max(vectorCandidates, limit+1)
+}
+
+// vectorSource is where a search reads vectors from: the primary embedding
+// column of episodes, or an embedding space's table
+type vectorSource struct {
+	table string // the table holding the vectors
+	id    string // its column naming the episode
+}
+
+// approximateCandidates selects the pool nearest vectors to query (a
+// FLOAT[dims] literal) through the table's HNSW index
+func (v vectorSource) approximateCandidates(query string, pool int) string {
+	return fmt.Sprintf(`SELECT %s AS cand_id, array_cosine_distance(embedding, %s) AS distance
+		FROM %s ORDER BY distance LIMIT %d`, v.id, query, v.table, pool)
+}
+
+// exactCandidates selects the pool nearest vectors to query among the
+// episodes matching where, by scan. from must join the vectors as vecCol.
+func exactCandidates(vecCol, from, where, query string, pool int) string {
+	return fmt.Sprintf(`SELECT episodes.id AS cand_id, array_cosine_distance(%s, %s) AS distance
+		FROM %s WHERE %s ORDER BY distance LIMIT %d`, vecCol, query, from, where, pool)
+}
+
+// vectorIndexName names table's HNSW index. The metric is part of the name:
+// indexes built before search moved to cosine candidates use L2 distance,
+// which VSS won't use for a cosine ORDER BY.
+func vectorIndexName(table string) string {
+	return fmt.Sprintf("idx_%s_embedding_cosine", table)
+}
+
+// ensureVectorIndex creates table's HNSW index over its embedding column,
+// replacing an L2 index from an older release. Best-effort: the index is an
+// accelerator, not a correctness requirement, so failures are only warned
+// about.
+func ensureVectorIndex(ctx context.Context, ex execer, table string) {
+	if _, err := ex.ExecContext(ctx, fmt.Sprintf("DROP INDEX IF EXISTS idx_%s_embedding", table)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to drop legacy vector index on %s: %v\n", table, err)
+	}
+	if _, err := ex.ExecContext(ctx, fmt.Sprintf(
+		"CREATE INDEX IF NOT EXISTS %s ON %s USING HNSW (embedding) WITH (metric = 'cosine')",
+		vectorIndexName(table), table)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to create vector index on %s: %v\n", table, err)
+	}
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestVectorCandidatesUseHNSWIndex(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+
+	if err := store.InsertEpisode(context.Background(), &models.Episode{
+		Content: "indexed", Source: "test", Embedding: makeEmbedding(1), EmbeddingModel: "test-model",
+	}); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	query := fmt.Sprintf("%s::FLOAT[%d]", vectorLiteral(makeEmbedding(1)), store.dims)
+	candidates := vectorSource{table: "episodes", id: "id"}.approximateCandidates(query, 10)
+	rows, err := store.db.Query("EXPLAIN " + candidates)
+	if err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	defer rows.Close()
+	var plan strings.Builder
+	for rows.Next() {
+		var kind, text string
+		if err := rows.Scan(&kind, &text); err != nil {
+			t.Fatalf("Failed to scan plan: %v", err)
+		}
+		plan.WriteString(text)
+	}
+	if !strings.Contains(plan.String(), "HNSW_INDEX_SCAN") {
+		t.Errorf("Expected the candidate query to scan the HNSW index, got plan:\n%s", plan.String())
+	}
+}
+
+func TestVectorSearchCandidatePool(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	defer func(n int) { vectorCandidates = n }(vectorCandidates)
+	vectorCandidates = 2
+
+	// Four close episodes in one group, one distant episode in another, and
+	// a distant one that only a keyword finds
+	near := func(dim1 float32) []float32 {
+		emb := makeEmbedding(1)
+		emb[1] = dim1
+		return emb
+	}
+	far := make([]float32, 768)
+	far[2] = 1
+	episodes := []*models.Episode{
+		{Content: "near one", Source: "test", GroupID: "crowd", Embedding: near(0)},
+		{Content: "near two", Source: "test", GroupID: "crowd", Embedding: near(0.1)},
+		{Content: "near three", Source: "test", GroupID: "crowd", Embedding: near(0.2)},
+		{Content: "near four", Source: "test", GroupID: "crowd", Embedding: near(0.3)},
+		{Content: "far away", Source: "test", GroupID: "loner", Embedding: far},
+		{Content: "zebra crossing", Source: "test", GroupID: "crowd", Embedding: far},
+	}
+	for _, ep := range episodes {
+		ep.EmbeddingModel = "test-model"
+		if err := store.InsertEpisode(ctx, ep); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	query := makeEmbedding(1)
+
+	t.Run("ranks only the pool", func(t *testing.T) {
+		page, err := store.SearchPage(ctx, models.SearchParams{QueryEmbedding: query, MaxResults: 1})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(page.Episodes) != 1 || page.Episodes[0].ID != episodes[0].ID {
+			t.Fatalf("Expected the nearest episode first, got %v", page.Episodes)
+		}
+		next, err := store.SearchPage(ctx, models.SearchParams{QueryEmbedding: query, MaxResults: 1, Cursor: page.NextCursor})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(next.Episodes) != 1 || next.NextCursor != "" {
+			t.Errorf("Expected paging to end with the pool, got %d episodes, cursor %q", len(next.Episodes), next.NextCursor)
+		}
+	})
+
+	t.Run("filters outside the pool search exactly", func(t *testing.T) {
+		before := exactSearches.Load()
+		results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: query, GroupID: "loner", MaxResults: 1})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != episodes[4].ID {
+			t.Errorf("Expected the filtered episode despite the pool, got %v", results)
+		}
+		if exactSearches.Load() == before {
+			t.Error("Expected the search retried with exact candidates")
+		}
+	})
+
+	t.Run("hybrid keeps keyword matches outside the pool", func(t *testing.T) {
+		pages := walkPages(t, store, models.SearchParams{
+			Query: "zebra", QueryEmbedding: query, SearchMode: "hybrid", GroupID: "crowd", MaxResults: 1,
+		})
+		var results []models.Episode
+		for _, page := range pages {
+			results = append(results, page...)
+		}
+		var found bool
+		for _, ep := range results {
+			if ep.ID == episodes[5].ID {
+				found = ep.Similarity != nil
+			}
+		}
+		if !found {
+			t.Errorf("Expected the keyword match with its similarity, got %v", results)
+		}
+	})
+}
+
+func TestSmallStoreSkipsExactSearch(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		emb := makeEmbedding(1)
+		emb[1] = float32(i) / 10
+		if err := store.InsertEpisode(ctx, &models.Episode{
+			Content: fmt.Sprintf("episode %d", i), Source: "test", Embedding: emb, EmbeddingModel: "test-model",
+		}); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+
+	// The page is short, but the pool already held every vector
+	before := exactSearches.Load()
+	results, err := store.Search(ctx, models.SearchParams{QueryEmbedding: makeEmbedding(1), MaxResults: 10, MinSimilarity: 0.5})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected all 3 episodes, got %d", len(results))
+	}
+	if n := exactSearches.Load() - before; n != 0 {
+		t.Errorf("Expected no exact retry, got %d", n)
+	}
+}
+
+// vectorLiteral renders an embedding as a DuckDB list literal
+func vectorLiteral(emb []float32) string {
+	parts := make([]string, len(emb))
+	for i, v := range emb {
+		parts[i] = fmt.Sprint(v)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
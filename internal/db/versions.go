@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Editing an episode's content or name keeps its ID and moves the prior
+// text here, numbered from 1 per episode. The current text is never stored
+// as a version; restoring one is itself an edit, so history only grows.
+const episodeVersionsDDL = `
+	CREATE TABLE IF NOT EXISTS episode_versions (
+		episode_id VARCHAR NOT NULL,
+		version INTEGER NOT NULL,
+		content VARCHAR NOT NULL,
+		name VARCHAR,
+		replaced_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (episode_id, version)
+	);
+`
+
+// EpisodeVersion is a prior content and name of an episode
+type EpisodeVersion struct {
+	EpisodeID  string    `json:"episode_id"`
+	Version    int       `json:"version"`
+	Content    string    `json:"content"`
+	Name       string    `json:"name,omitempty"`
+	ReplacedAt time.Time `json:"replaced_at"` // When an edit superseded it
+}
+
+// editEpisode applies the content and name changes of params to episode id
+// within tx: it records the current text as a new version, reindexes
+// keywords and, when the content changed, replaces the episode's vectors
+// with params.Embedding — or, without a usable one, clears them and queues
+// the episode for background embedding. Returns the SET clauses and args
+// for the episodes row; none when the text is unchanged.
+func (s *Store) editEpisode(ctx context.Context, tx *sql.Tx, id string, params models.UpdateParams) ([]string, []interface{}, error) {
+	var content, name string
+	err := tx.QueryRowContext(ctx, "SELECT content, COALESCE(name, '') FROM episodes WHERE id = ?", id).Scan(&content, &name)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("%w: %s", ErrEpisodeNotFound, id)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read episode: %w", err)
+	}
+
+	newContent, newName := content, name
+	if params.Content != nil {
+		newContent = *params.Content
+	}
+	if params.Name != nil {
+		newName = *params.Name
+	}
+	if newContent == "" {
+		return nil, nil, fmt.Errorf("content cannot be empty")
+	}
+	if newContent == content && newName == name {
+		return nil, nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO episode_versions (episode_id, version, content, name, replaced_at)
+		SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ? FROM episode_versions WHERE episode_id = ?
+	`, id, content, nullIfEmpty(name), time.Now(), id); err != nil {
+		return nil, nil, fmt.Errorf("failed to record episode version: %w", err)
+	}
+	if err := indexKeywords(ctx, tx, id, newName, newContent); err != nil {
+		return nil, nil, err
+	}
+
+	// No name is stored as "", as on insert: readers scan name into a string
+	set := []string{"content = ?", "name = ?"}
+	args := []interface{}{newContent, newName}
+	if newContent == content {
+		return set, args, nil
+	}
+
+	// The stored vectors describe the old content
+	if err := s.deleteSpaceVectors(ctx, tx, id); err != nil {
+		return nil, nil, err
+	}
+	if err := s.deleteChunks(ctx, tx, id); err != nil {
+		return nil, nil, err
+	}
+
+	model := params.EmbeddingModel
+	if len(params.Embedding) > 0 && model != "" {
+		if dims := s.SpaceDimensions(model); len(params.Embedding) != dims {
+			// Dropped rather than failing the edit, as on insert
+			fmt.Fprintf(os.Stderr, "Warning: %s embedding has %d dimensions, the store expects %d; editing episode without it\n",
+				model, len(params.Embedding), dims)
+		} else {
+			data, err := json.Marshal(params.Embedding)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to marshal embedding: %w", err)
+			}
+			if sp, ok := s.lookupSpace(model); ok {
+				if err := upsertSpaceVector(ctx, tx, sp, id, string(data)); err != nil {
+					return nil, nil, err
+				}
+				set = append(set, "embedding = NULL", "embedding_model = NULL")
+			} else {
+				set = append(set, "embedding = ?", "embedding_model = ?")
+				args = append(args, string(data), model)
+			}
+			if err := s.writeChunks(ctx, tx, id, model, params.Chunks); err != nil {
+				return nil, nil, err
+			}
+			return set, args, nil
+		}
+	}
+
+	// No vector for the new content: queue it, as an insert without one is
+	// queued
+	set = append(set, "embedding = NULL", "embedding_model = NULL")
+	if err := enqueueEmbedding(ctx, tx, id, time.Now()); err != nil {
+		return nil, nil, err
+	}
+	return set, args, nil
+}
+
+// ListEpisodeVersions returns episode id's prior versions, newest first
+func (s *Store) ListEpisodeVersions(ctx context.Context, id string) ([]EpisodeVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT episode_id, version, content, COALESCE(name, ''), replaced_at
+		FROM episode_versions
+		WHERE episode_id = ?
+		ORDER BY version DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list episode versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []EpisodeVersion{}
+	for rows.Next() {
+		var v EpisodeVersion
+		if err := rows.Scan(&v.EpisodeID, &v.Version, &v.Content, &v.Name, &v.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan episode version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetEpisodeVersion returns one prior version of episode id
+func (s *Store) GetEpisodeVersion(ctx context.Context, id string, version int) (*EpisodeVersion, error) {
+	v := EpisodeVersion{EpisodeID: id, Version: version}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT content, COALESCE(name, ''), replaced_at
+		FROM episode_versions
+		WHERE episode_id = ? AND version = ?
+	`, id, version).Scan(&v.Content, &v.Name, &v.ReplacedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("version %d of episode %s not found", version, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episode version: %w", err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+func TestEditEpisodeContent(t *testing.T) {
+	store := setupTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	emb := func(x, y float32) []float32 {
+		v := make([]float32, 768)
+		v[0], v[1] = x, y
+		return v
+	}
+	ep := &models.Episode{
+		Content: "the user's laptop is a macbook", Name: "laptop", Source: "test",
+		Embedding: emb(1, 0), EmbeddingModel: "old-model",
+		Chunks: []models.Chunk{{Index: 0, Content: "the user's laptop", Embedding: emb(1, 0)}},
+	}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	keywordHits := func(query string) int {
+		t.Helper()
+		page, err := store.SearchPage(ctx, models.SearchParams{Query: query, SearchMode: "keyword", MaxResults: 10})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		return len(page.Episodes)
+	}
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := store.db.QueryRow(query, ep.ID).Scan(&n); err != nil {
+			t.Fatalf("Count failed: %v", err)
+		}
+		return n
+	}
+
+	t.Run("a content edit keeps the ID and re-embeds", func(t *testing.T) {
+		content := "the user's laptop is a thinkpad"
+		err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{
+			Content: &content, Embedding: emb(0, 1), EmbeddingModel: "new-model",
+		})
+		if err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		got, err := store.GetEpisode(ctx, ep.ID)
+		if err != nil || got.Content != content || got.Name != "laptop" {
+			t.Fatalf("Expected the edited episode, got %+v, %v", got, err)
+		}
+		if keywordHits("thinkpad") != 1 || keywordHits("macbook") != 0 {
+			t.Error("Expected the keyword index to follow the edit")
+		}
+		if n := count("SELECT COUNT(*) FROM episodes WHERE id = ? AND embedding_model = 'new-model' AND embedding[2] = 1"); n != 1 {
+			t.Error("Expected the new vector stamped with its model")
+		}
+		if n := count("SELECT COUNT(*) FROM episodes_chunks WHERE episode_id = ?"); n != 0 {
+			t.Errorf("Expected the old content's chunks cleared, got %d", n)
+		}
+
+		versions, err := store.ListEpisodeVersions(ctx, ep.ID)
+		if err != nil || len(versions) != 1 {
+			t.Fatalf("Expected one version, got %v, %v", versions, err)
+		}
+		if v := versions[0]; v.Version != 1 || v.Content != ep.Content || v.Name != "laptop" {
+			t.Errorf("Expected the original text as version 1, got %+v", v)
+		}
+	})
+
+	t.Run("an edit without a vector is queued", func(t *testing.T) {
+		content := "the user's laptop is a framework"
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Content: &content}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		if n := count("SELECT COUNT(*) FROM episodes WHERE id = ? AND embedding IS NULL AND embedding_model IS NULL"); n != 1 {
+			t.Error("Expected the stale vector cleared")
+		}
+		if n := count("SELECT COUNT(*) FROM embedding_queue WHERE episode_id = ?"); n != 1 {
+			t.Error("Expected the episode queued for embedding")
+		}
+	})
+
+	t.Run("renaming adds a version, an unchanged edit doesn't", func(t *testing.T) {
+		name := "work laptop"
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Name: &name}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Name: &name}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		versions, _ := store.ListEpisodeVersions(ctx, ep.ID)
+		if len(versions) != 3 || versions[0].Version != 3 || versions[0].Name != "laptop" {
+			t.Errorf("Expected three versions, newest first, got %+v", versions)
+		}
+		if keywordHits("work") != 1 {
+			t.Error("Expected the new name indexed")
+		}
+
+		v, err := store.GetEpisodeVersion(ctx, ep.ID, 1)
+		if err != nil || v.Content != ep.Content {
+			t.Errorf("Expected version 1, got %+v, %v", v, err)
+		}
+		if _, err := store.GetEpisodeVersion(ctx, ep.ID, 9); err == nil {
+			t.Error("Expected a missing version to fail")
+		}
+	})
+
+	t.Run("an unnamed episode stays readable", func(t *testing.T) {
+		empty := ""
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Name: &empty}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		content := "the user's laptop is a thinkpad again"
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Content: &content}); err != nil {
+			t.Fatalf("UpdateEpisode failed: %v", err)
+		}
+		got, err := store.GetEpisode(ctx, ep.ID)
+		if err != nil || got.Name != "" || got.Content != content {
+			t.Fatalf("Expected the unnamed edit readable, got %+v, %v", got, err)
+		}
+		if keywordHits("thinkpad") != 1 {
+			t.Error("Expected the unnamed edit searchable")
+		}
+	})
+
+	t.Run("a missing episode is ErrEpisodeNotFound", func(t *testing.T) {
+		name := "anything"
+		err := store.UpdateEpisode(ctx, "missing", models.UpdateParams{Name: &name})
+		if !errors.Is(err, ErrEpisodeNotFound) {
+			t.Errorf("Expected ErrEpisodeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("empty content is refused", func(t *testing.T) {
+		empty := ""
+		if err := store.UpdateEpisode(ctx, ep.ID, models.UpdateParams{Content: &empty}); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+
+	t.Run("deleting an episode deletes its versions", func(t *testing.T) {
+		if _, err := store.DeleteEpisodes(ctx, []string{ep.ID}, Deletion{}); err != nil {
+			t.Fatalf("DeleteEpisodes failed: %v", err)
+		}
+		if n := count("SELECT COUNT(*) FROM episode_versions WHERE episode_id = ?"); n != 0 {
+			t.Errorf("Expected no versions left, got %d", n)
+		}
+	})
+}
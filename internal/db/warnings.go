@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// ErrDegraded is returned by a strict search that would otherwise have
+// fallen back to a weaker search mode
+var ErrDegraded = errors.New("search degraded")
+
+// searchWarnings collects what a search couldn't do as asked, for its page.
+// Each is also logged to stderr.
+type searchWarnings []models.SearchWarning
+
+// degraded records a fall back from the requested search mode
+func (w *searchWarnings) degraded(requested, effective, reason string) {
+	msg := fmt.Sprintf("%s search ran as %s: %s", requested, effective, reason)
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	*w = append(*w, models.SearchWarning{
+		Code: "mode_degraded", Message: msg, RequestedMode: requested, EffectiveMode: effective,
+	})
+}
+
+// ignored records a parameter the search had no use for
+func (w *searchWarnings) ignored(param, reason string) {
+	msg := fmt.Sprintf("%s is ignored: %s", param, reason)
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	*w = append(*w, models.SearchWarning{Code: "parameter_ignored", Message: msg, Parameter: param})
+}
+
+// strictError is the error of a strict search with these warnings: the
+// first degradation, if any
+func (w searchWarnings) strictError() error {
+	for _, warning := range w {
+		if warning.Code == "mode_degraded" {
+			return fmt.Errorf("%w: %s", ErrDegraded, warning.Message)
+		}
+	}
+	return nil
+}
+
+// list returns the warnings for a page, empty rather than nil
+func (w searchWarnings) list() []models.SearchWarning {
+	if w == nil {
+		return []models.SearchWarning{}
+	}
+	return w
+}
@@ -0,0 +1,145 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DefaultBatchSize is how many inputs GenerateBatch sends per request unless
+// configured otherwise. Small enough for local servers' request limits, large
+// enough to cut bulk round-trips by an order of magnitude.
+const DefaultBatchSize = 32
+
+// batchRequest is embedRequest with an array input, which OpenAI-compatible
+// servers answer with one embedding per element
+type batchRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// batchResponse carries each embedding's position in the input array
+type batchResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// BatchError reports the items of a GenerateBatch call that failed. Items
+// not listed were embedded successfully.
+type BatchError struct {
+	Total  int
+	Failed map[int]error // input index -> cause
+}
+
+func (e *BatchError) Error() string {
+	first := -1
+	for i := range e.Failed {
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	return fmt.Sprintf("%d of %d embeddings failed (first, item %d: %v)", len(e.Failed), e.Total, first, e.Failed[first])
+}
+
+// Indexes returns the failed input indexes in ascending order
+func (e *BatchError) Indexes() []int {
+	idx := make([]int, 0, len(e.Failed))
+	for i := range e.Failed {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	return idx
+}
+
+// ItemError returns why item i of a GenerateBatch call failed: its entry in
+// a *BatchError, or err itself when the call failed as a whole
+func ItemError(err error, i int) error {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Failed[i]
+	}
+	return err
+}
+
+// GenerateBatch embeds texts, sending up to the configured batch size per
+// request. The result is index-aligned with texts; a failed item is left nil
+// and the returned error is a *BatchError naming every failure.
+//
+// A request the server rejects or answers malformed is retried one item at a
+// time, so a single bad input (e.g. over the model's context length) fails
+// alone rather than taking its whole chunk down. A request that cannot reach
+// the server at all fails its chunk and every later one without further
+// calls — the endpoint is down, and the caller's retry policy applies.
+func (c *Client) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	results := make([][]float32, len(texts))
+	failed := map[int]error{}
+	size := c.batchSize
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+
+	for start := 0; start < len(texts); start += size {
+		end := min(start+size, len(texts))
+
+		embs, err := c.generateChunk(ctx, texts[start:end])
+		if err == nil {
+			copy(results[start:], embs)
+			continue
+		}
+
+		var unreachable *unreachableError
+		if errors.As(err, &unreachable) || ctx.Err() != nil {
+			for i := start; i < len(texts); i++ {
+				failed[i] = err
+			}
+			break
+		}
+
+		for i := start; i < end; i++ {
+			emb, err := c.Generate(ctx, texts[i])
+			if err != nil {
+				failed[i] = err
+				continue
+			}
+			results[i] = emb
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &BatchError{Total: len(texts), Failed: failed}
+	}
+	return results, nil
+}
+
+// generateChunk embeds texts in a single request
+func (c *Client) generateChunk(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp batchResponse
+	if err := c.post(ctx, batchRequest{Model: c.model, Input: texts}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d embeddings for %d inputs", len(resp.Data), len(texts))
+	}
+
+	// Servers report each embedding's input position; fall back to response
+	// order for ones that leave index out (every index decodes as 0)
+	embs := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) || embs[d.Index] != nil {
+			for i, d := range resp.Data {
+				embs[i] = d.Embedding
+			}
+			break
+		}
+		embs[d.Index] = d.Embedding
+	}
+	for _, emb := range embs {
+		if len(emb) == 0 {
+			return nil, fmt.Errorf("embedding API returned an empty embedding")
+		}
+	}
+	return embs, nil
+}
@@ -0,0 +1,134 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// batchServer embeds each input as a one-element vector holding its length.
+// Array requests containing "bad" are rejected, as are single "bad" inputs.
+func batchServer(t *testing.T, requests *[]int) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw struct {
+			Input json.RawMessage `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Fatalf("Failed to decode request body: %v", err)
+		}
+		var inputs []string
+		if err := json.Unmarshal(raw.Input, &inputs); err != nil {
+			var single string
+			if err := json.Unmarshal(raw.Input, &single); err != nil {
+				t.Fatalf("Unexpected input shape: %s", raw.Input)
+			}
+			inputs = []string{single}
+		}
+		mu.Lock()
+		*requests = append(*requests, len(inputs))
+		mu.Unlock()
+
+		var resp batchResponse
+		for i, in := range inputs {
+			if in == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("input too long"))
+				return
+			}
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{Index: i, Embedding: []float32{float32(len(in))}})
+		}
+		// Answer out of order: placement must follow index, not position
+		for i, j := 0, len(resp.Data)-1; i < j; i, j = i+1, j-1 {
+			resp.Data[i], resp.Data[j] = resp.Data[j], resp.Data[i]
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestGenerateBatch(t *testing.T) {
+	t.Run("chunks by batch size and aligns results", func(t *testing.T) {
+		var requests []int
+		server := batchServer(t, &requests)
+		defer server.Close()
+
+		client := NewClient(server.URL, "test-model", "")
+		client.SetBatchSize(2)
+		texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+		embs, err := client.GenerateBatch(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("GenerateBatch failed: %v", err)
+		}
+		for i, text := range texts {
+			if len(embs[i]) != 1 || embs[i][0] != float32(len(text)) {
+				t.Errorf("Item %d: expected embedding of %q, got %v", i, text, embs[i])
+			}
+		}
+		if len(requests) != 3 || requests[0] != 2 || requests[2] != 1 {
+			t.Errorf("Expected requests of 2, 2 and 1 inputs, got %v", requests)
+		}
+	})
+
+	t.Run("rejected chunk is retried per item", func(t *testing.T) {
+		var requests []int
+		server := batchServer(t, &requests)
+		defer server.Close()
+
+		client := NewClient(server.URL, "test-model", "")
+		embs, err := client.GenerateBatch(context.Background(), []string{"ok", "bad", "fine"})
+
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("Expected *BatchError, got %v", err)
+		}
+		if idx := batchErr.Indexes(); len(idx) != 1 || idx[0] != 1 {
+			t.Errorf("Expected only item 1 to fail, got %v", idx)
+		}
+		if embs[0] == nil || embs[1] != nil || embs[2] == nil {
+			t.Errorf("Expected items 0 and 2 embedded and 1 nil, got %v", embs)
+		}
+	})
+
+	t.Run("unreachable endpoint fails everything without per-item retries", func(t *testing.T) {
+		var requests []int
+		server := batchServer(t, &requests)
+		server.Close()
+
+		client := NewClient(server.URL, "test-model", "")
+		client.SetBatchSize(1)
+		embs, err := client.GenerateBatch(context.Background(), []string{"a", "b", "c"})
+
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || len(batchErr.Failed) != 3 {
+			t.Fatalf("Expected all 3 items failed, got %v", err)
+		}
+		for i, emb := range embs {
+			if emb != nil {
+				t.Errorf("Item %d: expected nil, got %v", i, emb)
+			}
+		}
+	})
+
+	t.Run("model switch batches with the active model", func(t *testing.T) {
+		var requests []int
+		server := batchServer(t, &requests)
+		defer server.Close()
+
+		base := NewClient(server.URL, "base-model", "")
+		base.SetBatchSize(4)
+		sw := NewModelSwitch(base, func() string { return "other-model" })
+		if _, err := sw.GenerateBatch(context.Background(), []string{"a", "b", "c", "d", "e"}); err != nil {
+			t.Fatalf("GenerateBatch failed: %v", err)
+		}
+		if len(requests) != 2 || requests[0] != 4 {
+			t.Errorf("Expected pinned client to inherit batch size 4, got requests %v", requests)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package embedding
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultCacheSize is how many query vectors Cache keeps in memory unless
+// configured otherwise
+const DefaultCacheSize = 1000
+
+// Embedder is what Cache wraps: a Client, or a ModelSwitch over several
+type Embedder interface {
+	Generate(ctx context.Context, text string) ([]float32, error)
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
+	Model() string
+}
+
+// VectorStore persists content vectors by model and text hash (the store's
+// embedding_cache table)
+type VectorStore interface {
+	CachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error)
+	CacheEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error
+	PurgeEmbeddingCache(ctx context.Context, keep string) error
+}
+
+// CacheStats is a point-in-time snapshot of a Cache, shaped for status
+// responses
+type CacheStats struct {
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Entries    int   `json:"entries"`    // Vectors held in memory
+	Capacity   int   `json:"capacity"`   // Most vectors held in memory
+	Persistent bool  `json:"persistent"` // Whether content vectors are also kept in the database
+}
+
+// Cache is an embedder that remembers what it embedded, keyed by model and
+// a hash of the whitespace-normalized text. Single texts (search queries,
+// one-off writes) are kept in an in-memory LRU; batches (bulk writes, the
+// embedding queue, re-embed passes) are content, kept in the VectorStore
+// when there is one. Both are consulted on every call. When the wrapped
+// embedder's model changes, the LRU is emptied and other models' persisted
+// vectors are purged.
+type Cache struct {
+	inner    Embedder
+	store    VectorStore // nil: memory only
+	capacity int
+
+	mu      sync.Mutex
+	model   string
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+	hits    int64
+	misses  int64
+}
+
+type cacheEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewCache wraps inner. capacity bounds the in-memory LRU; below 1 it
+// restores DefaultCacheSize. store may be nil.
+func NewCache(inner Embedder, capacity int, store VectorStore) *Cache {
+	if capacity < 1 {
+		capacity = DefaultCacheSize
+	}
+	return &Cache{
+		inner:    inner,
+		store:    store,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Model returns the wrapped embedder's model
+func (c *Cache) Model() string {
+	return c.inner.Model()
+}
+
+// Stats returns the cache's counters
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Entries:    c.order.Len(),
+		Capacity:   c.capacity,
+		Persistent: c.store != nil,
+	}
+}
+
+// TextHash is the cache key of text. Whitespace runs are collapsed and the
+// ends trimmed first, so texts differing only in layout share a vector. The
+// store uses it to erase a deleted episode's cached vectors.
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate embeds text, from the cache when it can
+func (c *Cache) Generate(ctx context.Context, text string) ([]float32, error) {
+	model := c.sync(ctx)
+	key := TextHash(text)
+	if v, ok := c.peek(model, key); ok {
+		c.count(1, 0)
+		return v, nil
+	}
+	if v := c.persisted(ctx, model, []string{key})[key]; v != nil {
+		c.count(1, 0)
+		c.remember(model, key, v)
+		return clone(v), nil
+	}
+	c.count(0, 1)
+
+	v, err := c.inner.Generate(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	c.remember(model, key, v)
+	return clone(v), nil
+}
+
+// GenerateBatch embeds texts, sending only those not cached to the wrapped
+// embedder, each distinct text once. Failures are reported as by
+// Client.GenerateBatch, indexed by texts.
+func (c *Cache) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	model := c.sync(ctx)
+	results := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	var missing []string // keys not in memory, in first-seen order
+	seen := map[string]bool{}
+	hits := 0
+	for i, text := range texts {
+		keys[i] = TextHash(text)
+		if v, ok := c.peek(model, keys[i]); ok {
+			results[i] = v
+			hits++
+		} else if !seen[keys[i]] {
+			seen[keys[i]] = true
+			missing = append(missing, keys[i])
+		}
+	}
+
+	// Then the database, then the embedder for what remains
+	found := c.persisted(ctx, model, missing)
+	var embedTexts []string
+	embedAt := map[string]int{} // key -> index in embedTexts
+	for i, key := range keys {
+		if results[i] != nil {
+			continue
+		}
+		if v := found[key]; v != nil {
+			results[i] = clone(v)
+			hits++
+			continue
+		}
+		if _, ok := embedAt[key]; !ok {
+			embedAt[key] = len(embedTexts)
+			embedTexts = append(embedTexts, texts[i])
+		}
+	}
+	c.count(hits, len(texts)-hits)
+	if len(embedTexts) == 0 {
+		return results, nil
+	}
+
+	embs, err := c.inner.GenerateBatch(ctx, embedTexts)
+	fresh := map[string][]float32{}
+	for key, j := range embedAt {
+		if j < len(embs) && embs[j] != nil {
+			fresh[key] = embs[j]
+		}
+	}
+	c.keep(ctx, model, fresh)
+
+	failed := map[int]error{}
+	for i, key := range keys {
+		if results[i] != nil {
+			continue
+		}
+		if v := fresh[key]; v != nil {
+			results[i] = clone(v)
+			continue
+		}
+		failed[i] = ItemError(err, embedAt[key])
+		if failed[i] == nil {
+			failed[i] = fmt.Errorf("no embedding returned")
+		}
+	}
+	if len(failed) > 0 {
+		return results, &BatchError{Total: len(texts), Failed: failed}
+	}
+	return results, nil
+}
+
+// sync returns the current model, resetting the cache if it changed
+func (c *Cache) sync(ctx context.Context) string {
+	model := c.inner.Model()
+	c.mu.Lock()
+	changed := c.model != "" && c.model != model
+	c.model = model
+	if changed {
+		c.order.Init()
+		c.entries = map[string]*list.Element{}
+	}
+	c.mu.Unlock()
+
+	if changed && c.store != nil {
+		if err := c.store.PurgeEmbeddingCache(ctx, model); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return model
+}
+
+// peek returns a copy of the remembered vector for key, marking it recently
+// used
+func (c *Cache) peek(model, key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[model+"\x00"+key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return clone(el.Value.(*cacheEntry).vector), true
+}
+
+// remember keeps v in memory, evicting the least recently used vector when
+// full
+func (c *Cache) remember(model, key string, v []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.model != model {
+		return // the model changed while v was being embedded
+	}
+	k := model + "\x00" + key
+	if el, ok := c.entries[k]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[k] = c.order.PushFront(&cacheEntry{key: k, vector: clone(v)})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// persisted reads keys from the database, if there is one. A failed read
+// is a miss, not an error: the embedder can still answer.
+func (c *Cache) persisted(ctx context.Context, model string, keys []string) map[string][]float32 {
+	if c.store == nil || len(keys) == 0 {
+		return nil
+	}
+	vectors, err := c.store.CachedEmbeddings(ctx, model, keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	return vectors
+}
+
+// keep stores freshly embedded batch vectors: in the database when there
+// is one, else in memory
+func (c *Cache) keep(ctx context.Context, model string, vectors map[string][]float32) {
+	if len(vectors) == 0 {
+		return
+	}
+	if c.store == nil {
+		for key, v := range vectors {
+			c.remember(model, key, v)
+		}
+		return
+	}
+	if err := c.store.CacheEmbeddings(ctx, model, vectors); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func (c *Cache) count(hits, misses int) {
+	c.mu.Lock()
+	c.hits += int64(hits)
+	c.misses += int64(misses)
+	c.mu.Unlock()
+}
+
+func clone(v []float32) []float32 {
+	return append([]float32(nil), v...)
+}
@@ -0,0 +1,148 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// countingEmbedder embeds a text as its length, counting the texts it is
+// asked for, and fails texts listed in fail
+type countingEmbedder struct {
+	model string
+	texts []string
+	fail  map[string]bool
+}
+
+func (e *countingEmbedder) Generate(ctx context.Context, text string) ([]float32, error) {
+	e.texts = append(e.texts, text)
+	if e.fail[text] {
+		return nil, errors.New("rejected")
+	}
+	return []float32{float32(len(text))}, nil
+}
+
+func (e *countingEmbedder) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embs := make([][]float32, len(texts))
+	failed := map[int]error{}
+	for i, text := range texts {
+		emb, err := e.Generate(ctx, text)
+		if err != nil {
+			failed[i] = err
+		}
+		embs[i] = emb
+	}
+	if len(failed) > 0 {
+		return embs, &BatchError{Total: len(texts), Failed: failed}
+	}
+	return embs, nil
+}
+
+func (e *countingEmbedder) Model() string { return e.model }
+
+// mapStore is a VectorStore in memory
+type mapStore map[string][]float32
+
+func (m mapStore) CachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error) {
+	found := map[string][]float32{}
+	for _, h := range hashes {
+		if v, ok := m[model+"/"+h]; ok {
+			found[h] = v
+		}
+	}
+	return found, nil
+}
+
+func (m mapStore) CacheEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	for h, v := range vectors {
+		m[model+"/"+h] = v
+	}
+	return nil
+}
+
+func (m mapStore) PurgeEmbeddingCache(ctx context.Context, keep string) error {
+	for k := range m {
+		if !strings.HasPrefix(k, keep+"/") {
+			delete(m, k)
+		}
+	}
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("queries are embedded once", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m"}
+		c := NewCache(inner, 10, nil)
+		for _, q := range []string{"deploy notes", "  deploy\tnotes\n", "deploy notes"} {
+			if _, err := c.Generate(ctx, q); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+		}
+		if len(inner.texts) != 1 {
+			t.Errorf("Expected one embedding call for whitespace variants, got %v", inner.texts)
+		}
+		if st := c.Stats(); st.Hits != 2 || st.Misses != 1 || st.Entries != 1 {
+			t.Errorf("Expected 2 hits, 1 miss, 1 entry, got %+v", st)
+		}
+	})
+
+	t.Run("evicts the least recently used", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m"}
+		c := NewCache(inner, 2, nil)
+		for _, q := range []string{"a", "b", "a", "c", "a", "b"} {
+			c.Generate(ctx, q)
+		}
+		// b was evicted by c, while a stayed in use
+		if want := []string{"a", "b", "c", "b"}; !reflect.DeepEqual(inner.texts, want) {
+			t.Errorf("Expected calls %v, got %v", want, inner.texts)
+		}
+	})
+
+	t.Run("model change invalidates", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m1"}
+		store := mapStore{}
+		c := NewCache(inner, 10, store)
+		c.Generate(ctx, "x")
+		c.GenerateBatch(ctx, []string{"content"})
+		inner.model = "m2"
+		c.Generate(ctx, "x")
+		if len(inner.texts) != 3 {
+			t.Errorf("Expected the new model to re-embed, got calls %v", inner.texts)
+		}
+		if len(store) != 0 {
+			t.Errorf("Expected the old model's persisted vectors purged, got %v", store)
+		}
+	})
+
+	t.Run("batches persist, dedupe, and report failures by input index", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m", fail: map[string]bool{"bad": true}}
+		store := mapStore{}
+		c := NewCache(inner, 10, store)
+		c.Generate(ctx, "query")
+
+		embs, err := c.GenerateBatch(ctx, []string{"query", "doc", "bad", "doc "})
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || len(batchErr.Failed) != 1 || batchErr.Failed[2] == nil || batchErr.Total != 4 {
+			t.Fatalf("Expected item 2 to fail alone, got %v", err)
+		}
+		if embs[0] == nil || embs[1] == nil || embs[3] == nil || embs[2] != nil {
+			t.Errorf("Expected every other item embedded, got %v", embs)
+		}
+		if want := []string{"query", "doc", "bad"}; !reflect.DeepEqual(inner.texts, want) {
+			t.Errorf("Expected calls %v, got %v", want, inner.texts)
+		}
+		if len(store) != 1 {
+			t.Errorf("Expected the batch's content persisted, got %v", store)
+		}
+
+		// A fresh cache over the same store finds the content there
+		inner2 := &countingEmbedder{model: "m"}
+		if _, err := NewCache(inner2, 10, store).Generate(ctx, "doc"); err != nil || len(inner2.texts) != 0 {
+			t.Errorf("Expected a persisted hit, got err %v, calls %v", err, inner2.texts)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Defaults for Chunker, in characters. 2000 characters is roughly 500
+// tokens of English, inside the context window of common embedding models.
+const (
+	DefaultChunkSize    = 2000
+	DefaultChunkOverlap = 200
+)
+
+// BatchEmbedder is what a Chunker embeds with
+type BatchEmbedder interface {
+	Generate(ctx context.Context, text string) ([]float32, error)
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Chunker embeds episode content that may be too long for the model's
+// context window. Content over Size characters is split into windows of at
+// most Size characters, each overlapping the previous by about Overlap and
+// broken at whitespace where possible. Every window is embedded on its own,
+// and the episode's vector is the mean of its windows' unit vectors. A Size
+// below 1 disables chunking: content is embedded whole.
+type Chunker struct {
+	Size    int
+	Overlap int
+}
+
+// Split returns text's windows, or nil when text fits in one
+func (c Chunker) Split(text string) []string {
+	runes := []rune(text)
+	if c.Size < 1 || len(runes) <= c.Size {
+		return nil
+	}
+	overlap := min(max(c.Overlap, 0), c.Size/2)
+
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+c.Size, len(runes))
+		if end < len(runes) {
+			// Break at the last whitespace in the window's back half
+			for i := end; i > start+c.Size/2; i-- {
+				if unicode.IsSpace(runes[i-1]) {
+					end = i
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// Step back by the overlap, then forward to the start of a word
+		next := end - overlap
+		for next < end && next > start && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// Embed embeds one episode's content. Short content is a single Generate
+// call with no chunks; long content is one batched call over its windows.
+func (c Chunker) Embed(ctx context.Context, e BatchEmbedder, text string) ([]float32, []models.Chunk, error) {
+	pieces := c.Split(text)
+	if pieces == nil {
+		emb, err := e.Generate(ctx, text)
+		return emb, nil, err
+	}
+	embs, err := e.GenerateBatch(ctx, pieces)
+	return assemble(pieces, embs, 0, err)
+}
+
+// EmbedBatch embeds many episodes' content in one batched call, long ones
+// as their windows. The results are index-aligned with texts; a text whose
+// vector or any of whose windows failed is nil, and described by a
+// BatchError indexed by texts.
+func (c Chunker) EmbedBatch(ctx context.Context, e BatchEmbedder, texts []string) ([][]float32, [][]models.Chunk, error) {
+	var inputs []string
+	pieces := make([][]string, len(texts))
+	offsets := make([]int, len(texts))
+	for i, text := range texts {
+		offsets[i] = len(inputs)
+		pieces[i] = c.Split(text)
+		if pieces[i] == nil {
+			inputs = append(inputs, text)
+		} else {
+			inputs = append(inputs, pieces[i]...)
+		}
+	}
+
+	embs, genErr := e.GenerateBatch(ctx, inputs)
+	if len(embs) != len(inputs) {
+		embs = make([][]float32, len(inputs))
+	}
+
+	vectors := make([][]float32, len(texts))
+	chunks := make([][]models.Chunk, len(texts))
+	failed := map[int]error{}
+	for i := range texts {
+		var err error
+		if pieces[i] == nil {
+			vectors[i], err = embs[offsets[i]], ItemError(genErr, offsets[i])
+		} else {
+			n := len(pieces[i])
+			vectors[i], chunks[i], err = assemble(pieces[i], embs[offsets[i]:offsets[i]+n], offsets[i], genErr)
+		}
+		if vectors[i] == nil {
+			if err == nil {
+				err = fmt.Errorf("no embedding returned")
+			}
+			failed[i] = err
+		}
+	}
+	if len(failed) > 0 {
+		return vectors, chunks, &BatchError{Total: len(texts), Failed: failed}
+	}
+	return vectors, chunks, nil
+}
+
+// assemble turns one text's embedded windows into its chunks and mean
+// vector. embs[j] belongs to pieces[j], which was input base+j of a batch
+// that returned genErr. Any missing window fails the whole text.
+func assemble(pieces []string, embs [][]float32, base int, genErr error) ([]float32, []models.Chunk, error) {
+	chunks := make([]models.Chunk, len(pieces))
+	for j, piece := range pieces {
+		if j >= len(embs) || embs[j] == nil {
+			err := ItemError(genErr, base+j)
+			if err == nil {
+				err = fmt.Errorf("no embedding returned")
+			}
+			return nil, nil, fmt.Errorf("chunk %d of %d: %w", j+1, len(pieces), err)
+		}
+		chunks[j] = models.Chunk{Index: j, Content: piece, Embedding: embs[j]}
+	}
+	return meanVector(chunks), chunks, nil
+}
+
+// meanVector averages the chunks' vectors after scaling each to unit
+// length, so every window weighs the same in the episode's vector
+func meanVector(chunks []models.Chunk) []float32 {
+	mean := make([]float32, len(chunks[0].Embedding))
+	for _, ch := range chunks {
+		var norm float64
+		for _, x := range ch.Embedding {
+			norm += float64(x) * float64(x)
+		}
+		if norm == 0 {
+			continue
+		}
+		scale := 1 / math.Sqrt(norm)
+		for k := range mean {
+			if k < len(ch.Embedding) {
+				mean[k] += float32(float64(ch.Embedding[k]) * scale)
+			}
+		}
+	}
+	for k := range mean {
+		mean[k] /= float32(len(chunks))
+	}
+	return mean
+}
@@ -0,0 +1,101 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChunkerSplit(t *testing.T) {
+	words := make([]string, 300)
+	for i := range words {
+		words[i] = "word" + strings.Repeat("x", i%5)
+	}
+	text := strings.Join(words, " ")
+	c := Chunker{Size: 200, Overlap: 40}
+
+	if got := c.Split("short text"); got != nil {
+		t.Errorf("Expected short text to stay whole, got %q", got)
+	}
+	if got := (Chunker{}).Split(text); got != nil {
+		t.Errorf("Expected the zero Chunker not to split, got %d chunks", len(got))
+	}
+
+	chunks := c.Split(text)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+	for i, ch := range chunks {
+		if n := len([]rune(ch)); n > c.Size {
+			t.Errorf("Chunk %d has %d characters, over the size of %d", i, n, c.Size)
+		}
+		// Breaks fall between words
+		for _, w := range strings.Fields(ch) {
+			if !strings.HasPrefix(w, "word") {
+				t.Errorf("Chunk %d splits a word: %q", i, w)
+			}
+		}
+		if i > 0 {
+			prev := strings.Fields(chunks[i-1])
+			if first := strings.Fields(ch)[0]; !strings.Contains(strings.Join(prev[len(prev)/2:], " "), first) {
+				t.Errorf("Chunk %d doesn't overlap the previous one", i)
+			}
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasSuffix(text, last) {
+		t.Errorf("Expected the last chunk to end the text, got %q", last)
+	}
+}
+
+func TestChunkerEmbed(t *testing.T) {
+	ctx := context.Background()
+	c := Chunker{Size: 10, Overlap: 2}
+	long := "alpha beta gamma delta"
+
+	t.Run("short content is embedded whole", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m"}
+		emb, chunks, err := c.Embed(ctx, inner, "short")
+		if err != nil || emb == nil || chunks != nil {
+			t.Fatalf("Expected one vector and no chunks, got %v, %v, %v", emb, chunks, err)
+		}
+		if len(inner.texts) != 1 || inner.texts[0] != "short" {
+			t.Errorf("Expected the content embedded as is, got %v", inner.texts)
+		}
+	})
+
+	t.Run("long content is the mean of its chunks", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m"}
+		emb, chunks, err := c.Embed(ctx, inner, long)
+		if err != nil {
+			t.Fatalf("Embed failed: %v", err)
+		}
+		if len(chunks) < 2 || len(inner.texts) != len(chunks) {
+			t.Fatalf("Expected each chunk embedded once, got %d chunks from calls %v", len(chunks), inner.texts)
+		}
+		for i, ch := range chunks {
+			if ch.Index != i || ch.Content != inner.texts[i] || ch.Embedding == nil {
+				t.Errorf("Chunk %d malformed: %+v", i, ch)
+			}
+		}
+		// countingEmbedder's vectors are 1-dimensional, so each normalizes to 1
+		if len(emb) != 1 || emb[0] != 1 {
+			t.Errorf("Expected the mean of unit vectors, got %v", emb)
+		}
+	})
+
+	t.Run("a failed chunk fails only its text", func(t *testing.T) {
+		inner := &countingEmbedder{model: "m", fail: map[string]bool{c.Split(long)[1]: true}}
+		embs, chunks, err := c.EmbedBatch(ctx, inner, []string{"one", long, "two"})
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) || len(batchErr.Failed) != 1 || batchErr.Failed[1] == nil {
+			t.Fatalf("Expected item 1 to fail alone, got %v", err)
+		}
+		if embs[0] == nil || embs[1] != nil || embs[2] == nil || chunks[1] != nil {
+			t.Errorf("Expected the short items embedded, got %v, chunks %v", embs, chunks)
+		}
+		if len(inner.texts) != 2+len(c.Split(long)) {
+			t.Errorf("Expected one batched call over every input, got %v", inner.texts)
+		}
+	})
+}
@@ -18,6 +18,8 @@ type Client struct {
 	model    string
 	apiKey   string
 	client   *http.Client
+	// batchSize caps how many inputs GenerateBatch sends per request
+	batchSize int
 }
 
 // NewClient creates a new embedding client for an OpenAI-compatible server.
@@ -33,9 +35,19 @@ func NewClient(baseURL, model, apiKey string) *Client {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		batchSize: DefaultBatchSize,
 	}
 }
 
+// SetBatchSize sets how many inputs GenerateBatch sends per request. Values
+// below 1 restore DefaultBatchSize.
+func (c *Client) SetBatchSize(n int) {
+	if n < 1 {
+		n = DefaultBatchSize
+	}
+	c.batchSize = n
+}
+
 // Model returns the embedding model name this client generates with
 func (c *Client) Model() string {
 	return c.model
@@ -74,14 +86,42 @@ func (c *Client) Generate(ctx context.Context, text string) ([]float32, error) {
 		Input: text,
 	}
 
+	var embedResp embedResponse
+	if err := c.post(ctx, reqBody, &embedResp); err != nil {
+		return nil, err
+	}
+
+	if len(embedResp.Data) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+
+	return embedResp.Data[0].Embedding, nil
+}
+
+// unreachableError marks a request that never got an HTTP response, as
+// opposed to one the server rejected
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string {
+	return "failed to call embedding API: " + e.err.Error()
+}
+
+func (e *unreachableError) Unwrap() error {
+	return e.err
+}
+
+// post sends reqBody to the embeddings endpoint and decodes the reply into out
+func (c *Client) post(ctx context.Context, reqBody interface{}, out interface{}) error {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -91,23 +131,17 @@ func (c *Client) Generate(ctx context.Context, text string) ([]float32, error) {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call embedding API: %w", err)
+		return &unreachableError{err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embedding API returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("embedding API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var embedResp embedResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	if len(embedResp.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
-	}
-
-	return embedResp.Data[0].Embedding, nil
+	return nil
 }
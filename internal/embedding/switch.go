@@ -0,0 +1,67 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+)
+
+// ModelSwitch is an embedder that follows the store's active embedding
+// space: each call resolves the current model and dispatches to a Client for
+// it, so flipping the active space changes which model embeds queries and new
+// episodes without a restart. Every Client shares the base client's endpoint
+// and credentials, so one embeddings server must host all models in use.
+type ModelSwitch struct {
+	base   *Client
+	active func() string // "" selects the base client's model
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// NewModelSwitch creates a switch over base. active reports the model to use;
+// an empty result falls back to base's model.
+func NewModelSwitch(base *Client, active func() string) *ModelSwitch {
+	return &ModelSwitch{
+		base:    base,
+		active:  active,
+		clients: map[string]*Client{base.model: base},
+	}
+}
+
+// Model returns the model the next Generate call will use
+func (m *ModelSwitch) Model() string {
+	if model := m.active(); model != "" {
+		return model
+	}
+	return m.base.model
+}
+
+// Generate creates an embedding with the currently active model
+func (m *ModelSwitch) Generate(ctx context.Context, text string) ([]float32, error) {
+	return m.For(m.Model()).Generate(ctx, text)
+}
+
+// GenerateBatch embeds texts with the currently active model; see
+// Client.GenerateBatch
+func (m *ModelSwitch) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return m.For(m.Model()).GenerateBatch(ctx, texts)
+}
+
+// For returns a client pinned to model, regardless of which one is active.
+// The re-embed job uses it to backfill a space before it is activated.
+func (m *ModelSwitch) For(model string) *Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.clients[model]
+	if !ok {
+		c = &Client{
+			endpoint:  m.base.endpoint,
+			model:     model,
+			apiKey:    m.base.apiKey,
+			client:    m.base.client,
+			batchSize: m.base.batchSize,
+		}
+		m.clients[model] = c
+	}
+	return c
+}
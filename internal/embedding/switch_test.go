@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestModelSwitch(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request body: %v", err)
+		}
+		mu.Lock()
+		seen = append(seen, req.Model)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(embedResponse{
+			Data: []struct {
+				Embedding []float32 `json:"embedding"`
+			}{{Embedding: []float32{1}}},
+		})
+	}))
+	defer server.Close()
+
+	active := ""
+	sw := NewModelSwitch(NewClient(server.URL, "base-model", ""), func() string { return active })
+	lastModel := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen[len(seen)-1]
+	}
+
+	t.Run("falls back to base model", func(t *testing.T) {
+		if sw.Model() != "base-model" {
+			t.Errorf("Expected base-model, got %q", sw.Model())
+		}
+		if _, err := sw.Generate(context.Background(), "x"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if got := lastModel(); got != "base-model" {
+			t.Errorf("Expected request for base-model, got %q", got)
+		}
+	})
+
+	t.Run("follows the active model", func(t *testing.T) {
+		active = "next-model"
+		defer func() { active = "" }()
+		if sw.Model() != "next-model" {
+			t.Errorf("Expected next-model, got %q", sw.Model())
+		}
+		if _, err := sw.Generate(context.Background(), "x"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if got := lastModel(); got != "next-model" {
+			t.Errorf("Expected request for next-model, got %q", got)
+		}
+	})
+
+	t.Run("For pins a model and reuses clients", func(t *testing.T) {
+		c := sw.For("pinned-model")
+		if c.Model() != "pinned-model" || c.endpoint != sw.base.endpoint {
+			t.Errorf("Pinned client misconfigured: model %q endpoint %q", c.Model(), c.endpoint)
+		}
+		if sw.For("pinned-model") != c {
+			t.Error("Expected For to reuse the pinned client")
+		}
+	})
+}
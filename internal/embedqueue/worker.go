@@ -0,0 +1,231 @@
+// Package embedqueue drains the durable embedding retry queue: episodes
+// stored while the embedding endpoint was unavailable are embedded in the
+// background, with exponential backoff, so they become vector-searchable
+// without operator action.
+package embedqueue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/embedding"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+// Embedder generates vector embeddings for text
+type Embedder interface {
+	Generate(ctx context.Context, text string) ([]float32, error)
+	// GenerateBatch embeds texts in as few requests as possible. The result
+	// is index-aligned with texts; failed items are nil and described by the
+	// error (see embedding.BatchError).
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
+	// Model returns the embedding model name, used to stamp provenance
+	Model() string
+}
+
+const (
+	// pageSize is how many due entries are fetched per query
+	pageSize = 32
+	// baseBackoff is the delay after the first failed attempt; each further
+	// failure doubles it, up to maxBackoff
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Backoff returns the delay before the next attempt after attempts failures
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Worker periodically embeds due queue entries. Kick and Recover wake it
+// early; otherwise it polls every interval.
+type Worker struct {
+	store    *db.Store
+	embedder Embedder
+	chunker  embedding.Chunker
+	interval time.Duration
+	timeout  time.Duration
+
+	kick   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	resetNext bool // clear backoff before the next pass
+}
+
+// NewWorker creates a worker. interval <= 0 defaults to 30 seconds.
+func NewWorker(store *db.Store, embedder Embedder, interval time.Duration) *Worker {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Worker{
+		store:    store,
+		embedder: embedder,
+		interval: interval,
+		timeout:  30 * time.Second,
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetChunker sets how long content is split for embedding. Call before
+// Start; the zero Chunker embeds content whole.
+func (w *Worker) SetChunker(c embedding.Chunker) {
+	w.chunker = c
+}
+
+// Start launches the drain loop: one immediate pass (entries may be left
+// over from before a restart), then one per interval or kick until ctx is
+// cancelled or Stop is called.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.drain(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-w.kick:
+			}
+		}
+	}()
+}
+
+// Stop cancels the loop and waits for an in-flight pass to finish, so the
+// store can be closed safely afterwards
+func (w *Worker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+// Kick requests a pass as soon as possible without blocking
+func (w *Worker) Kick() {
+	select {
+	case w.kick <- struct{}{}:
+	default: // a pass is already pending
+	}
+}
+
+// Recover clears every entry's backoff and kicks the loop. Intended as the
+// embedding prober's recovery hook.
+func (w *Worker) Recover() {
+	w.mu.Lock()
+	w.resetNext = true
+	w.mu.Unlock()
+	w.Kick()
+}
+
+// drain embeds due entries until none remain. Any failure ends the pass
+// early — the endpoint is most likely down, and hammering it with the
+// rest of the queue only delays recovery.
+func (w *Worker) drain(ctx context.Context) {
+	w.mu.Lock()
+	reset := w.resetNext
+	w.resetNext = false
+	w.mu.Unlock()
+	if reset {
+		if err := w.store.ResetEmbeddingBackoff(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	model := w.embedder.Model()
+	if _, err := w.store.PruneEmbeddingQueue(ctx, model); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	dims := w.store.SpaceDimensions(model)
+
+	embedded := 0
+	for ctx.Err() == nil {
+		items, err := w.store.DueEmbeddings(ctx, model, time.Now(), pageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			break
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		failed := w.embedPage(ctx, items, model, dims)
+		embedded += len(items) - failed
+		if failed > 0 || ctx.Err() != nil {
+			break
+		}
+	}
+	if embedded > 0 {
+		fmt.Fprintf(os.Stderr, "Embedding queue: %d queued episodes embedded with model %s\n", embedded, model)
+	}
+}
+
+// embedPage embeds items in one batched call, long ones as their chunks,
+// stores and dequeues the successes, and reschedules each failure with
+// backoff. Returns the number of failures.
+func (w *Worker) embedPage(ctx context.Context, items []db.QueuedEmbedding, model string, dims int) int {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.Text
+	}
+	embedCtx, cancel := context.WithTimeout(ctx, w.timeout)
+	embs, chunks, genErr := w.chunker.EmbedBatch(embedCtx, w.embedder, texts)
+	cancel()
+	if ctx.Err() != nil {
+		return 0 // shutdown, not a failed attempt
+	}
+
+	failed := 0
+	for i, item := range items {
+		err := w.save(ctx, item, model, dims, embs[i], chunks[i], embedding.ItemError(genErr, i))
+		if err == nil {
+			continue
+		}
+		failed++
+		attempts := item.Attempts + 1
+		if rerr := w.store.RetryEmbeddingLater(ctx, item.ID, time.Now().Add(Backoff(attempts)), err.Error()); rerr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", rerr)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: queued embedding for episode %s failed (attempt %d, next try in %s): %v\n",
+			item.ID, attempts, Backoff(attempts), err)
+	}
+	return failed
+}
+
+// save stores one generated vector, and its chunks, and dequeues its episode
+func (w *Worker) save(ctx context.Context, item db.QueuedEmbedding, model string, dims int, emb []float32, chunks []models.Chunk, genErr error) error {
+	if emb == nil {
+		if genErr == nil {
+			genErr = fmt.Errorf("no embedding returned")
+		}
+		return genErr
+	}
+	if len(emb) != dims {
+		return fmt.Errorf("model %q produced %d-dimensional embedding, store requires %d", model, len(emb), dims)
+	}
+	if err := w.store.UpdateEpisodeEmbedding(ctx, item.ID, emb, model); err != nil {
+		return err
+	}
+	if err := w.store.ReplaceEpisodeChunks(ctx, item.ID, model, chunks); err != nil {
+		return err
+	}
+	return w.store.CompleteEmbedding(ctx, item.ID)
+}
@@ -0,0 +1,114 @@
+package embedqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/models"
+)
+
+type flakyEmbedder struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *flakyEmbedder) Model() string { return "test-model" }
+
+func (f *flakyEmbedder) Generate(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return make([]float32, 768), nil
+}
+
+func (f *flakyEmbedder) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embs := make([][]float32, len(texts))
+	for i, text := range texts {
+		emb, err := f.Generate(ctx, text)
+		if err != nil {
+			return embs, err
+		}
+		embs[i] = emb
+	}
+	return embs, nil
+}
+
+func (f *flakyEmbedder) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, c := range cases {
+		if got := Backoff(c.attempts); got != c.want {
+			t.Errorf("Backoff(%d) = %s, want %s", c.attempts, got, c.want)
+		}
+	}
+}
+
+func TestWorkerDrainsAfterRecovery(t *testing.T) {
+	ctx := context.Background()
+	store, err := db.NewStore(t.TempDir() + "/test.duckdb")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Stored while the endpoint was down: no vector, so it is queued
+	ep := &models.Episode{Content: "queued while offline", Source: "test"}
+	if err := store.InsertEpisode(ctx, ep); err != nil {
+		t.Fatalf("Failed to insert episode: %v", err)
+	}
+
+	embedder := &flakyEmbedder{err: errors.New("connection refused")}
+	w := NewWorker(store, embedder, time.Hour)
+
+	w.drain(ctx)
+	if n, _ := store.CountQueuedEmbeddings(ctx); n != 1 {
+		t.Fatalf("Expected the entry to stay queued after a failure, got %d", n)
+	}
+	due, err := store.DueEmbeddings(ctx, "test-model", time.Now(), 10)
+	if err != nil {
+		t.Fatalf("DueEmbeddings failed: %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("Failed entry should be backed off, got %d due", len(due))
+	}
+
+	// The endpoint comes back; recovery clears the backoff so the next pass
+	// does not wait out the schedule
+	embedder.setErr(nil)
+	w.Recover()
+	w.drain(ctx)
+
+	if n, _ := store.CountQueuedEmbeddings(ctx); n != 0 {
+		t.Errorf("Expected queue drained after recovery, got %d", n)
+	}
+	counts, err := store.CountReembedTargets(ctx, "test-model", false)
+	if err != nil {
+		t.Fatalf("CountReembedTargets failed: %v", err)
+	}
+	if counts.Total() != 0 {
+		t.Errorf("Expected the queued episode to be embedded, got %d missing", counts.Total())
+	}
+}
@@ -0,0 +1,336 @@
+// Package filter is the filter expression language of search and listing:
+// boolean combinations of conditions on an episode's fields, written either
+// as JSON or in a compact string syntax, and compiled to parameterized SQL
+// over the episodes table.
+//
+// JSON form, one of these per node:
+//
+//	{"and": [...]}  {"or": [...]}  {"not": {...}}
+//	{"field": "source", "op": "eq", "value": "claude-code"}
+//
+// String form, the same tree with AND binding tighter than OR:
+//
+//	source = "claude-code" AND (tags any [deploy, ci] OR NOT metadata.reviewed exists)
+//
+// Fields and the operators they take:
+//
+//	group_id, source, source_model   eq, ne, in
+//	tags                             any, all, none
+//	created_at, valid_at, expired_at lt, lte, gt, gte (RFC 3339 values)
+//	metadata.<path>                  eq, ne, lt, lte, gt, gte, in, exists
+//
+// group is accepted for group_id. valid_at falls back to created_at, as
+// everywhere else in search. A metadata path is a dotted key path into the
+// metadata JSON; numbers compare numerically, strings and booleans as text.
+// A condition on a missing value is false, so ne and none match episodes
+// that lack the value.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Expr is a node of a filter expression: exactly one of And, Or, Not, or a
+// condition (Field, Op, Value)
+type Expr struct {
+	And []*Expr `json:"and,omitempty"`
+	Or  []*Expr `json:"or,omitempty"`
+	Not *Expr   `json:"not,omitempty"`
+
+	Field string      `json:"field,omitempty"`
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// UnmarshalJSON accepts the JSON form, or a JSON string in the string syntax
+func (e *Expr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := Parse(s)
+		if err != nil {
+			return err
+		}
+		*e = *parsed
+		return nil
+	}
+	type plain Expr
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// Validate reports whether e compiles
+func (e *Expr) Validate() error {
+	_, _, err := e.Compile(1)
+	return err
+}
+
+// References reports whether any condition of e is on field, group counting
+// as group_id
+func (e *Expr) References(field string) bool {
+	if e == nil {
+		return false
+	}
+	if f := e.Field; f == field || (f == "group" && field == "group_id") {
+		return true
+	}
+	for _, sub := range append(append([]*Expr{e.Not}, e.And...), e.Or...) {
+		if sub.References(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile renders e as a SQL boolean expression over the episodes table's
+// columns. Values become positional parameters numbered from argIdx, in the
+// order of the returned args.
+func (e *Expr) Compile(argIdx int) (string, []interface{}, error) {
+	c := &compiler{argIdx: argIdx}
+	sql, err := c.expr(e)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+// compiler accumulates the parameters of one compilation
+type compiler struct {
+	argIdx int
+	args   []interface{}
+}
+
+// bind adds a parameter and returns its placeholder
+func (c *compiler) bind(v interface{}) string {
+	c.args = append(c.args, v)
+	c.argIdx++
+	return fmt.Sprintf("$%d", c.argIdx-1)
+}
+
+func (c *compiler) expr(e *Expr) (string, error) {
+	if e == nil {
+		return "", fmt.Errorf("empty expression")
+	}
+	nodes := 0
+	for _, set := range []bool{e.And != nil, e.Or != nil, e.Not != nil, e.Field != "" || e.Op != ""} {
+		if set {
+			nodes++
+		}
+	}
+	if nodes != 1 {
+		return "", fmt.Errorf("each expression needs exactly one of and, or, not, or field and op")
+	}
+
+	switch {
+	case e.And != nil:
+		return c.list(e.And, " AND ", "and")
+	case e.Or != nil:
+		return c.list(e.Or, " OR ", "or")
+	case e.Not != nil:
+		inner, err := c.expr(e.Not)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + inner, nil
+	default:
+		return c.condition(e)
+	}
+}
+
+func (c *compiler) list(exprs []*Expr, sep, name string) (string, error) {
+	if len(exprs) == 0 {
+		return "", fmt.Errorf("%s needs at least one expression", name)
+	}
+	parts := make([]string, len(exprs))
+	for i, sub := range exprs {
+		sql, err := c.expr(sub)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = sql
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+// metadataPath matches one key of a metadata path
+var metadataPath = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// comparisons maps ordering operators to SQL
+var comparisons = map[string]string{"lt": "<", "lte": "<=", "gt": ">", "gte": ">="}
+
+// fieldKind classifies a field by the operators it takes
+func fieldKind(field string) string {
+	switch {
+	case field == "group_id" || field == "source" || field == "source_model":
+		return "text"
+	case field == "tags":
+		return "tags"
+	case field == "created_at" || field == "valid_at" || field == "expired_at":
+		return "time"
+	case strings.HasPrefix(field, "metadata."):
+		return "metadata"
+	}
+	return ""
+}
+
+// kindOps lists each kind's operators, for error messages
+var kindOps = map[string]string{
+	"text":     "eq, ne, and in",
+	"tags":     "any, all, and none",
+	"time":     "lt, lte, gt, and gte",
+	"metadata": "eq, ne, lt, lte, gt, gte, in, and exists",
+}
+
+// condition compiles a leaf. Every condition is wrapped so that a NULL
+// (a missing value) is false rather than unknown, which keeps NOT exact.
+func (c *compiler) condition(e *Expr) (string, error) {
+	field := e.Field
+	if field == "group" {
+		field = "group_id"
+	}
+	kind := fieldKind(field)
+	if kind == "" {
+		return "", fmt.Errorf("unknown filter field %q", e.Field)
+	}
+	unsupported := fmt.Errorf("%s supports %s, not %q", e.Field, kindOps[kind], e.Op)
+
+	// in is any of several eq conditions, and ne is not eq
+	if e.Op == "in" || e.Op == "ne" {
+		if kind != "text" && kind != "metadata" {
+			return "", unsupported
+		}
+		if e.Op == "ne" {
+			eq, err := c.condition(&Expr{Field: e.Field, Op: "eq", Value: e.Value})
+			if err != nil {
+				return "", err
+			}
+			return "NOT " + eq, nil
+		}
+		values, ok := e.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("%s in needs a non-empty list", e.Field)
+		}
+		or := make([]*Expr, len(values))
+		for i, v := range values {
+			or[i] = &Expr{Field: e.Field, Op: "eq", Value: v}
+		}
+		return c.list(or, " OR ", "in")
+	}
+
+	var pred string
+	switch kind {
+	case "text":
+		if e.Op != "eq" {
+			return "", unsupported
+		}
+		s, ok := e.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("%s needs a string value", e.Field)
+		}
+		pred = fmt.Sprintf("%s = %s", field, c.bind(s))
+
+	case "tags":
+		if e.Op != "any" && e.Op != "all" && e.Op != "none" {
+			return "", unsupported
+		}
+		tags, err := tagList(e.Value)
+		if err != nil {
+			return "", err
+		}
+		checks := make([]string, len(tags))
+		for i, tag := range tags {
+			checks[i] = fmt.Sprintf("list_contains(tags, %s)", c.bind(tag))
+		}
+		switch e.Op {
+		case "any":
+			pred = strings.Join(checks, " OR ")
+		case "all":
+			pred = strings.Join(checks, " AND ")
+		case "none":
+			pred = fmt.Sprintf("NOT COALESCE(%s, false)", strings.Join(checks, " OR "))
+		}
+
+	case "time":
+		op, ok := comparisons[e.Op]
+		if !ok {
+			return "", unsupported
+		}
+		s, _ := e.Value.(string)
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return "", fmt.Errorf("%s needs an ISO 8601 time, got %v", e.Field, e.Value)
+		}
+		col := field
+		if field == "valid_at" {
+			col = "COALESCE(valid_at, created_at)"
+		}
+		pred = fmt.Sprintf("%s %s %s", col, op, c.bind(t))
+
+	case "metadata":
+		if _, ordered := comparisons[e.Op]; !ordered && e.Op != "eq" && e.Op != "exists" {
+			return "", unsupported
+		}
+		keys := strings.Split(strings.TrimPrefix(field, "metadata."), ".")
+		for _, key := range keys {
+			if !metadataPath.MatchString(key) {
+				return "", fmt.Errorf("invalid metadata path %q", e.Field)
+			}
+		}
+		var err error
+		if pred, err = c.metadata(e, c.bind("$."+strings.Join(keys, "."))+"::VARCHAR"); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("COALESCE(%s, false)", pred), nil
+}
+
+// metadata compiles a condition on the metadata value at path
+func (c *compiler) metadata(e *Expr, path string) (string, error) {
+	text := fmt.Sprintf("json_extract_string(metadata, %s)", path)
+	if e.Op == "exists" {
+		if e.Value != nil {
+			return "", fmt.Errorf("%s exists takes no value", e.Field)
+		}
+		return fmt.Sprintf("json_exists(metadata, %s)", path), nil
+	}
+
+	op, ordered := comparisons[e.Op]
+	if !ordered {
+		op = "="
+	}
+	switch v := e.Value.(type) {
+	case float64:
+		return fmt.Sprintf("TRY_CAST(%s AS DOUBLE) %s %s", text, op, c.bind(v)), nil
+	case string:
+		return fmt.Sprintf("%s %s %s", text, op, c.bind(v)), nil
+	case bool:
+		if ordered {
+			return "", fmt.Errorf("%s %s needs a number or string", e.Field, e.Op)
+		}
+		return fmt.Sprintf("%s = %s", text, c.bind(fmt.Sprint(v))), nil
+	default:
+		return "", fmt.Errorf("%s needs a string, number, or boolean value", e.Field)
+	}
+}
+
+// tagList reads the tags of a tags condition: a list, or a single tag
+func tagList(value interface{}) ([]string, error) {
+	if s, ok := value.(string); ok {
+		return []string{s}, nil
+	}
+	values, ok := value.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, fmt.Errorf("tags needs a tag or a non-empty list of tags")
+	}
+	tags := make([]string, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("tags must be strings, got %v", v)
+		}
+		tags[i] = s
+	}
+	return tags, nil
+}
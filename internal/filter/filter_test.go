@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string // the JSON form
+	}{
+		{`source = claude-code`, `{"field":"source","op":"eq","value":"claude-code"}`},
+		{`group != 'work'`, `{"field":"group","op":"ne","value":"work"}`},
+		{`tags any [deploy, "ci cd"]`, `{"field":"tags","op":"any","value":["deploy","ci cd"]}`},
+		{`metadata.priority >= 2`, `{"field":"metadata.priority","op":"gte","value":2}`},
+		{`metadata.reviewed exists`, `{"field":"metadata.reviewed","op":"exists"}`},
+		{`metadata.done = FALSE`, `{"field":"metadata.done","op":"eq","value":false}`},
+		{`created_at < 2025-01-01T00:00:00Z`, `{"field":"created_at","op":"lt","value":"2025-01-01T00:00:00Z"}`},
+		// AND binds tighter than OR
+		{`source = a or source = b and not tags none [x]`,
+			`{"or":[{"field":"source","op":"eq","value":"a"},{"and":[{"field":"source","op":"eq","value":"b"},{"not":{"field":"tags","op":"none","value":["x"]}}]}]}`},
+		{`(source = a OR source = b) AND source_model in [m1, m2]`,
+			`{"and":[{"or":[{"field":"source","op":"eq","value":"a"},{"field":"source","op":"eq","value":"b"}]},{"field":"source_model","op":"in","value":["m1","m2"]}]}`},
+		// JSON form passes through
+		{` {"not": {"field": "tags", "op": "all", "value": ["a"]}}`, `{"not":{"field":"tags","op":"all","value":["a"]}}`},
+	}
+	for _, tc := range cases {
+		e, err := Parse(tc.in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tc.in, err)
+			continue
+		}
+		got, _ := json.Marshal(e)
+		if string(got) != tc.want {
+			t.Errorf("Parse(%q)\n got %s\nwant %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		`source =`:               "expected a value",
+		`source = "open`:         "unterminated string",
+		`(source = a`:            "expected )",
+		`source = a source = b`:  "unexpected",
+		`colour = red`:           "unknown filter field",
+		`tags = a`:               "tags supports any, all, and none",
+		`created_at in [a]`:      "created_at supports lt, lte, gt, and gte",
+		`created_at < yesterday`: "ISO 8601",
+		`source < a`:             "source supports eq, ne, and in",
+		`metadata.a b = 1`:       "expected an operator",
+		`metadata.a$b = 1`:       "unexpected",
+		`metadata..a = 1`:        "invalid metadata path",
+		`metadata.flag < true`:   "needs a number or string",
+		`source in []`:           "non-empty list",
+		`{"field": "source"}`:    "source supports eq, ne, and in, not \"\"",
+		`{"and": []}`:            "at least one",
+		`{"or": [{"field": "source", "op": "eq", "value": 1}]}`: "needs a string",
+	}
+	for in, want := range cases {
+		_, err := Parse(in)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q): expected an error containing %q, got %v", in, want, err)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	e, err := Parse(`group = work AND (tags none [old] OR metadata.priority > 2) AND valid_at >= "2025-01-01T00:00:00Z" AND source != x`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	sql, args, err := e.Compile(3)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	wantSQL := "(COALESCE(group_id = $3, false)" +
+		" AND (COALESCE(NOT COALESCE(list_contains(tags, $4), false), false)" +
+		" OR COALESCE(TRY_CAST(json_extract_string(metadata, $5::VARCHAR) AS DOUBLE) > $6, false))" +
+		" AND COALESCE(COALESCE(valid_at, created_at) >= $7, false)" +
+		" AND NOT COALESCE(source = $8, false))"
+	if sql != wantSQL {
+		t.Errorf("Compile SQL\n got %s\nwant %s", sql, wantSQL)
+	}
+	wantArgs := []interface{}{"work", "old", "$.priority", 2.0, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "x"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Compile args: got %v, want %v", args, wantArgs)
+	}
+}
+
+func TestUnmarshalString(t *testing.T) {
+	var req struct {
+		Filter *Expr `json:"filter"`
+	}
+	if err := json.Unmarshal([]byte(`{"filter": "tags all [a, b]"}`), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if req.Filter == nil || req.Filter.Op != "all" {
+		t.Errorf("Expected the string syntax to be parsed, got %+v", req.Filter)
+	}
+	if err := json.Unmarshal([]byte(`{"filter": "tags all"}`), &req); err == nil {
+		t.Error("Expected a malformed string filter to fail")
+	}
+}
+
+func TestReferences(t *testing.T) {
+	cases := map[string]bool{
+		`source = a`:                                    false,
+		`tags any [x] AND metadata.a exists`:            false,
+		`group = work`:                                  true,
+		`source = a OR NOT group_id in [a, b]`:          true,
+		`(source = a AND tags any [x]) OR group_id = b`: true,
+	}
+	for in, want := range cases {
+		e, err := Parse(in)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", in, err)
+		}
+		if got := e.References("group_id"); got != want {
+			t.Errorf("References(group_id) of %q = %v, want %v", in, got, want)
+		}
+	}
+}
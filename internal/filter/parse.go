@@ -0,0 +1,287 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse reads a filter in the string syntax, or in the JSON form when it
+// starts with '{', and validates it.
+//
+//	expr  = and { OR and }
+//	and   = unary { AND unary }
+//	unary = NOT unary | "(" expr ")" | field op value | field EXISTS
+//	value = scalar | "[" [ scalar { "," scalar } ] "]"
+//
+// op is one of = != < <= > >= IN ANY ALL NONE. A scalar is a quoted string,
+// a number, true, false, or a bare word, read as a string. Keywords are
+// case-insensitive.
+func Parse(s string) (*Expr, error) {
+	var e *Expr
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		e = &Expr{}
+		if err := json.Unmarshal([]byte(s), e); err != nil {
+			return nil, fmt.Errorf("invalid filter JSON: %w", err)
+		}
+	} else {
+		tokens, err := lex(s)
+		if err != nil {
+			return nil, err
+		}
+		p := &parser{tokens: tokens}
+		if e, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if tok := p.peek(); tok.kind != tokEOF {
+			return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+		}
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// symbolOps maps the syntax's comparison symbols to operators
+var symbolOps = map[string]string{"=": "eq", "!=": "ne", "<": "lt", "<=": "lte", ">": "gt", ">=": "gte"}
+
+// wordOps are the operators written as words
+var wordOps = map[string]bool{"in": true, "any": true, "all": true, "none": true}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of filter"
+	}
+	return strconv.Quote(t.text)
+}
+
+// isWord reports whether r can continue a bare word: field names, dotted
+// metadata paths, and unquoted values
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == ':'
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokString, b.String(), i})
+			i = j + 1
+
+		case strings.ContainsRune("()[],", r):
+			tokens = append(tokens, token{tokSymbol, string(r), i})
+			i++
+
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if _, ok := symbolOps[op]; !ok {
+				return nil, fmt.Errorf("unknown operator %q at offset %d", op, i)
+			}
+			tokens = append(tokens, token{tokSymbol, op, i})
+			i += len(op)
+
+		case isWord(r):
+			j := i
+			for j < len(runes) && isWord(runes[j]) {
+				j++
+			}
+			text := string(runes[i:j])
+			kind := tokWord
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				kind = tokNumber
+			}
+			tokens = append(tokens, token{kind, text, i})
+			i = j
+
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword consumes the next token if it is the word kw
+func (p *parser) keyword(kw string) bool {
+	if tok := p.peek(); tok.kind == tokWord && strings.EqualFold(tok.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it is sym
+func (p *parser) symbol(sym string) bool {
+	if tok := p.peek(); tok.kind == tokSymbol && tok.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expr() (*Expr, error) {
+	return p.chain("or", p.and, func(parts []*Expr) *Expr { return &Expr{Or: parts} })
+}
+
+func (p *parser) and() (*Expr, error) {
+	return p.chain("and", p.unary, func(parts []*Expr) *Expr { return &Expr{And: parts} })
+}
+
+// chain parses operands separated by the keyword op, combining two or more
+func (p *parser) chain(op string, operand func() (*Expr, error), combine func([]*Expr) *Expr) (*Expr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	parts := []*Expr{first}
+	for p.keyword(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, next)
+	}
+	if len(parts) == 1 {
+		return first, nil
+	}
+	return combine(parts), nil
+}
+
+func (p *parser) unary() (*Expr, error) {
+	if p.keyword("not") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Not: inner}, nil
+	}
+	if p.symbol("(") {
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, fmt.Errorf("expected ) at offset %d, got %s", p.peek().pos, p.peek())
+		}
+		return inner, nil
+	}
+
+	field := p.next()
+	if field.kind != tokWord {
+		return nil, fmt.Errorf("expected a field at offset %d, got %s", field.pos, field)
+	}
+	if p.keyword("exists") {
+		return &Expr{Field: field.text, Op: "exists"}, nil
+	}
+
+	var op string
+	switch tok := p.next(); {
+	case tok.kind == tokSymbol && symbolOps[tok.text] != "":
+		op = symbolOps[tok.text]
+	case tok.kind == tokWord && wordOps[strings.ToLower(tok.text)]:
+		op = strings.ToLower(tok.text)
+	default:
+		return nil, fmt.Errorf("expected an operator after %s at offset %d, got %s", field.text, tok.pos, tok)
+	}
+
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{Field: field.text, Op: op, Value: value}, nil
+}
+
+func (p *parser) value() (interface{}, error) {
+	if !p.symbol("[") {
+		return p.scalar()
+	}
+	values := []interface{}{}
+	if p.symbol("]") {
+		return values, nil
+	}
+	for {
+		v, err := p.scalar()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.symbol("]") {
+			return values, nil
+		}
+		if !p.symbol(",") {
+			return nil, fmt.Errorf("expected , or ] at offset %d, got %s", p.peek().pos, p.peek())
+		}
+	}
+}
+
+func (p *parser) scalar() (interface{}, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return tok.text, nil
+	case tokNumber:
+		return strconv.ParseFloat(tok.text, 64)
+	case tokWord:
+		switch strings.ToLower(tok.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return tok.text, nil
+	}
+	return nil, fmt.Errorf("expected a value at offset %d, got %s", tok.pos, tok)
+}
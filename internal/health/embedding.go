@@ -4,15 +4,19 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/embedding"
 )
 
 // Embedder is the minimal embedding capability the prober exercises
 type Embedder interface {
 	Generate(ctx context.Context, text string) ([]float32, error)
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
 	Model() string
 }
 
@@ -37,6 +41,8 @@ type EmbeddingProber struct {
 	interval     time.Duration
 	timeout      time.Duration
 	expectedDims int // 0 disables the dimension check
+	dimsFunc     func() int
+	onRecover    func()
 
 	mu     sync.Mutex
 	status EmbeddingStatus
@@ -57,6 +63,20 @@ func NewEmbeddingProber(e Embedder, interval time.Duration, expectedDims int) *E
 	}
 }
 
+// SetExpectedDimensionsFunc makes the dimension check follow a value that can
+// change at runtime (the active embedding space's size), replacing the fixed
+// expectedDims passed to NewEmbeddingProber. Call before Start.
+func (p *EmbeddingProber) SetExpectedDimensionsFunc(f func() int) {
+	p.dimsFunc = f
+}
+
+// SetRecoveryHook registers f to run (on the probe goroutine) each time the
+// endpoint transitions from degraded back to ok — the moment work deferred
+// during the outage can resume. Call before Start.
+func (p *EmbeddingProber) SetRecoveryHook(f func()) {
+	p.onRecover = f
+}
+
 // Start launches the probe loop: one immediate probe (so a dead endpoint is
 // loud at startup), then one per interval until ctx is cancelled.
 func (p *EmbeddingProber) Start(ctx context.Context) {
@@ -87,12 +107,26 @@ func (p *EmbeddingProber) probe(ctx context.Context) {
 	probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
 	defer cancel()
 
+	// Probe through the batch path that re-embed, the retry queue and bulk
+	// writes depend on
 	start := time.Now()
-	emb, err := p.embedder.Generate(probeCtx, probeText)
+	var emb []float32
+	embs, err := p.embedder.GenerateBatch(probeCtx, []string{probeText})
 	latency := time.Since(start)
-	if err == nil && p.expectedDims > 0 && len(emb) != p.expectedDims {
+	var batchErr *embedding.BatchError
+	if errors.As(err, &batchErr) && len(batchErr.Failed) == 1 {
+		err = batchErr.Failed[0]
+	}
+	if err == nil && len(embs) == 1 {
+		emb = embs[0]
+	}
+	expected := p.expectedDims
+	if p.dimsFunc != nil {
+		expected = p.dimsFunc()
+	}
+	if err == nil && expected > 0 && len(emb) != expected {
 		err = fmt.Errorf("model %q produced %d-dimensional embedding, schema requires %d",
-			p.embedder.Model(), len(emb), p.expectedDims)
+			p.embedder.Model(), len(emb), expected)
 	}
 	if ctx.Err() != nil {
 		return // shutdown, not a health signal
@@ -127,5 +161,8 @@ func (p *EmbeddingProber) probe(ctx context.Context) {
 		}
 	case next == "ok" && prev == "degraded":
 		fmt.Fprintf(os.Stderr, "Embedding endpoint recovered, vector search restored (probe latency %dms)\n", latency.Milliseconds())
+		if p.onRecover != nil {
+			p.onRecover()
+		}
 	}
 }
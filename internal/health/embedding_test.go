@@ -16,6 +16,17 @@ func (f *fakeEmbedder) Generate(ctx context.Context, text string) ([]float32, er
 	return f.emb, f.err
 }
 
+func (f *fakeEmbedder) GenerateBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if f.err != nil {
+		return make([][]float32, len(texts)), f.err
+	}
+	embs := make([][]float32, len(texts))
+	for i := range embs {
+		embs[i] = f.emb
+	}
+	return embs, nil
+}
+
 func (f *fakeEmbedder) Model() string { return "fake-model" }
 
 func TestEmbeddingProber(t *testing.T) {
@@ -67,6 +78,21 @@ func TestEmbeddingProber(t *testing.T) {
 		}
 	})
 
+	t.Run("dimension check follows a runtime value", func(t *testing.T) {
+		expected := 768
+		p := NewEmbeddingProber(&fakeEmbedder{emb: make([]float32, 1024)}, time.Minute, 768)
+		p.SetExpectedDimensionsFunc(func() int { return expected })
+		p.probe(ctx)
+		if s := p.Status(); s.Status != "degraded" {
+			t.Errorf("Expected degraded before the switch, got %q", s.Status)
+		}
+		expected = 1024
+		p.probe(ctx)
+		if s := p.Status(); s.Status != "ok" {
+			t.Errorf("Expected ok after the expected size changed, got %q (error %q)", s.Status, s.Error)
+		}
+	})
+
 	t.Run("recovery clears error and failure count", func(t *testing.T) {
 		f := &fakeEmbedder{err: errors.New("boom")}
 		p := NewEmbeddingProber(f, time.Minute, 0)
@@ -80,6 +106,24 @@ func TestEmbeddingProber(t *testing.T) {
 		}
 	})
 
+	t.Run("recovery hook fires only on the degraded to ok transition", func(t *testing.T) {
+		f := &fakeEmbedder{emb: make([]float32, 768)}
+		p := NewEmbeddingProber(f, time.Minute, 0)
+		recoveries := 0
+		p.SetRecoveryHook(func() { recoveries++ })
+
+		p.probe(ctx) // unknown -> ok: not a recovery
+		f.err = errors.New("boom")
+		p.probe(ctx)
+		p.probe(ctx)
+		f.err = nil
+		p.probe(ctx) // degraded -> ok
+		p.probe(ctx) // ok -> ok
+		if recoveries != 1 {
+			t.Errorf("Expected exactly 1 recovery, got %d", recoveries)
+		}
+	})
+
 	t.Run("cancelled context does not overwrite status", func(t *testing.T) {
 		p := NewEmbeddingProber(&fakeEmbedder{emb: make([]float32, 768)}, time.Minute, 0)
 		p.probe(ctx)
@@ -0,0 +1,114 @@
+// Package keyword turns text into the terms of the keyword (BM25) index.
+// Documents and queries must go through the same analysis, so both use
+// Terms and QueryTerms from here.
+//
+// The analysis mirrors what DuckDB's FTS extension did with its defaults:
+// lowercase, strip accents, split on anything that isn't a letter, drop
+// English stop words, and Porter-stem what remains. Digits never form terms,
+// which is why keyword search falls back to a substring match for numeric
+// identifiers.
+package keyword
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Version identifies the analysis. Bump it whenever Terms would produce
+// different output for the same text, so stores rebuild their index.
+const Version = "1"
+
+// Terms returns each term in text with its frequency, and the total number
+// of terms (the document length BM25 normalises by)
+func Terms(text string) (freq map[string]int, length int) {
+	freq = make(map[string]int)
+	for _, tok := range tokenize(text) {
+		freq[tok]++
+		length++
+	}
+	return freq, length
+}
+
+// QueryTerms returns the distinct terms of a query, sorted. Empty when the
+// query has nothing indexable (only stop words, digits or punctuation).
+func QueryTerms(query string) []string {
+	freq, _ := Terms(query)
+	terms := make([]string, 0, len(freq))
+	for t := range freq {
+		terms = append(terms, t)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// tokenize splits text into stemmed, non-stop-word tokens
+func tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		if stopWords[w] {
+			return
+		}
+		tokens = append(tokens, Stem(w))
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		if folded, ok := accents[r]; ok {
+			word.WriteString(folded)
+			continue
+		}
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		if r >= 'a' && r <= 'z' {
+			word.WriteRune(r)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return tokens
+}
+
+// accents folds common Latin accented letters to their base letters
+var accents = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'À': "a", 'Á': "a", 'Â': "a", 'Ã': "a", 'Ä': "a", 'Å': "a", 'Æ': "ae",
+	'ç': "c", 'Ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'È': "e", 'É': "e", 'Ê': "e", 'Ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'Ì': "i", 'Í': "i", 'Î': "i", 'Ï': "i",
+	'ñ': "n", 'Ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'Ò': "o", 'Ó': "o", 'Ô': "o", 'Õ': "o", 'Ö': "o", 'Ø': "o", 'Œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'Ù': "u", 'Ú': "u", 'Û': "u", 'Ü': "u",
+	'ý': "y", 'ÿ': "y", 'Ý': "y",
+	'ß': "ss",
+}
+
+// stopWords are common English words too frequent to help ranking
+var stopWords = func() map[string]bool {
+	words := strings.Fields(`
+		a about above after again against all am an and any are as at
+		be because been before being below between both but by
+		can could did do does doing down during each few for from further
+		had has have having he her here hers herself him himself his how
+		i if in into is it its itself just me more most my myself
+		no nor not now of off on once only or other our ours ourselves out over own
+		same she should so some such than that the their theirs them themselves
+		then there these they this those through to too under until up
+		very was we were what when where which while who whom why will with would
+		you your yours yourself yourselves s t`)
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}()
@@ -0,0 +1,58 @@
+package keyword
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"caresses": "caress", "ponies": "poni", "ties": "ti", "caress": "caress", "cats": "cat",
+		"feed": "feed", "agreed": "agre", "plastered": "plaster", "bled": "bled",
+		"motoring": "motor", "sing": "sing", "conflated": "conflat", "troubled": "troubl",
+		"sized": "size", "hopping": "hop", "tanned": "tan", "falling": "fall",
+		"hissing": "hiss", "fizzed": "fizz", "failing": "fail", "filing": "file",
+		"happy": "happi", "sky": "sky", "relational": "relat", "conditional": "condit",
+		"rational": "ration", "generalization": "gener", "hopeful": "hope",
+		"goodness": "good", "adjustment": "adjust", "effective": "effect",
+		"adoption": "adopt", "communism": "commun", "databases": "databas",
+		"lazy": "lazi", "controll": "control", "rate": "rate", "cease": "ceas",
+		"is": "is", "a": "a",
+	}
+	for word, want := range cases {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	freq, length := Terms("The quick brown Fox jumps over the lazy fox's den")
+	want := map[string]int{"quick": 1, "brown": 1, "fox": 2, "jump": 1, "lazi": 1, "den": 1}
+	if !reflect.DeepEqual(freq, want) {
+		t.Errorf("Terms = %v, want %v", freq, want)
+	}
+	if length != 7 {
+		t.Errorf("length = %d, want 7 (stop words don't count)", length)
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"Databases and caching", []string{"cach", "databas"}},
+		{"café résumé", []string{"cafe", "resum"}},
+		{"123456789012", []string{}},
+		{"the and of", []string{}},
+		// Query syntax and SQL metacharacters are just separators
+		{"'; DROP TABLE episodes; --", []string{"drop", "episod", "tabl"}},
+		{"+foo -bar* \"baz\"", []string{"bar", "baz", "foo"}},
+	}
+	for _, tc := range cases {
+		if got := QueryTerms(tc.query); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("QueryTerms(%q) = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
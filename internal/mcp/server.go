@@ -3,13 +3,17 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/oscillatelabsllc/engram/internal/db"
+	"github.com/oscillatelabsllc/engram/internal/embedding"
+	"github.com/oscillatelabsllc/engram/internal/filter"
 	"github.com/oscillatelabsllc/engram/internal/health"
 	"github.com/oscillatelabsllc/engram/internal/models"
 )
@@ -17,6 +21,10 @@ import (
 // Embedder generates vector embeddings for text
 type Embedder interface {
 	Generate(ctx context.Context, text string) ([]float32, error)
+	// GenerateBatch embeds texts in as few requests as possible. The result
+	// is index-aligned with texts; failed items are nil and described by the
+	// error (see embedding.BatchError).
+	GenerateBatch(ctx context.Context, texts []string) ([][]float32, error)
 	// Model returns the embedding model name, used to stamp provenance
 	Model() string
 }
@@ -26,11 +34,18 @@ type EmbeddingHealth interface {
 	Status() health.EmbeddingStatus
 }
 
+// EmbeddingQueue wakes the background worker that embeds queued episodes
+type EmbeddingQueue interface {
+	Kick()
+}
+
 // Server implements the MCP server for Engram
 type Server struct {
 	store           *db.Store
 	embedder        Embedder
 	embeddingHealth EmbeddingHealth
+	embeddingQueue  EmbeddingQueue
+	chunker         embedding.Chunker
 	mcpServer       *server.MCPServer
 }
 
@@ -40,6 +55,18 @@ func (s *Server) SetEmbeddingHealth(h EmbeddingHealth) {
 	s.embeddingHealth = h
 }
 
+// SetEmbeddingQueue attaches the background embedding worker so add_memories
+// can wake it instead of waiting for its next poll. Optional.
+func (s *Server) SetEmbeddingQueue(q EmbeddingQueue) {
+	s.embeddingQueue = q
+}
+
+// SetChunker sets how long content is split for embedding. Optional: the
+// zero Chunker embeds content whole.
+func (s *Server) SetChunker(c embedding.Chunker) {
+	s.chunker = c
+}
+
 // NewServer creates a new MCP server
 func NewServer(store *db.Store, embedder Embedder) *Server {
 	s := &Server{
@@ -66,67 +93,77 @@ func (s *Server) registerTools() {
 	s.mcpServer.AddTool(mcp.Tool{
 		Name:        "add_memory",
 		Description: "Store a new episode in memory",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: addMemoryProperties(),
+			Required:   []string{"content", "source"},
+		},
+	}, s.handleAddMemory)
+
+	// add_memories tool
+	s.mcpServer.AddTool(mcp.Tool{
+		Name:        "add_memories",
+		Description: "Store many episodes in one call, e.g. when importing a backlog. All valid items are stored together; each result reports the stored ID or why that item was rejected. Embeddings are generated in the background, so new items become searchable by keyword at once and by meaning shortly after.",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
-				"content": map[string]interface{}{
-					"type":        "string",
-					"description": "The episode content to store",
-				},
-				"name": map[string]interface{}{
-					"type":        "string",
-					"description": "Human-readable label for the episode",
-				},
-				"source": map[string]interface{}{
-					"type":        "string",
-					"description": "Identifier for what created this memory (e.g., 'claude-desktop', 'claude-code', 'my-app'). Use a consistent value per client so you can filter by it later.",
-				},
-				"source_model": map[string]interface{}{
-					"type":        "string",
-					"description": "Model that created this episode (e.g., 'opus-4.6')",
-				},
-				"source_description": map[string]interface{}{
-					"type":        "string",
-					"description": "Freeform context about the episode",
-				},
-				"group_id": map[string]interface{}{
-					"type":        "string",
-					"description": "Advanced: namespace for multi-tenant isolation. Omit this in almost all cases — the server assigns a default automatically. Only set if you are deliberately partitioning memories between separate users or contexts.",
-				},
-				"tags": map[string]interface{}{
-					"type": "array",
+				"memories": map[string]interface{}{
+					"type":        "array",
+					"description": fmt.Sprintf("Episodes to store (at most %d), each with the same fields as add_memory", maxBatchMemories),
 					"items": map[string]interface{}{
-						"type": "string",
+						"type":       "object",
+						"properties": memoryProperties(),
+						"required":   []string{"content", "source"},
 					},
-					"description": "Tags for categorization",
-				},
-				"valid_at": map[string]interface{}{
-					"type":        "string",
-					"description": "When the information became true (ISO 8601)",
-				},
-				"metadata": map[string]interface{}{
-					"type":        "string",
-					"description": "JSON string with additional metadata",
 				},
 			},
-			Required: []string{"content", "source"},
+			Required: []string{"memories"},
 		},
-	}, s.handleAddMemory)
+	}, s.handleAddMemories)
 
 	// search tool
 	s.mcpServer.AddTool(mcp.Tool{
 		Name:        "search",
-		Description: "Search episodes using semantic similarity, keyword matching, or hybrid mode. For most searches, only provide 'query'. All other parameters are optional secondary filters — omit them unless you have a specific reason to narrow results.\n\nSearch mode guidance:\n- hybrid (recommended): best for most queries — balances semantic understanding with exact term matching.\n- vector: best for concept/intent queries where your words won't match the stored text (e.g. \"deployment preferences\" finding CI/CD memories).\n- keyword: best for exact terms, proper nouns, error codes, or version strings where semantic drift would hurt (e.g. \"mlx_lm.server\").\n\nNote: the default search_mode will change from 'vector' to 'hybrid' in the next major version.",
+		Description: "Search episodes using semantic similarity, keyword matching, or hybrid mode. For most searches, only provide 'query'. All other parameters are optional secondary filters — omit them unless you have a specific reason to narrow results.\n\nSearch mode guidance:\n- hybrid (recommended): best for most queries — balances semantic understanding with exact term matching.\n- vector: best for concept/intent queries where your words won't match the stored text (e.g. \"deployment preferences\" finding CI/CD memories).\n- keyword: best for exact terms, proper nouns, error codes, or version strings where semantic drift would hurt (e.g. \"mlx_lm.server\").\n\nReturns {episodes, next_cursor, search_mode_effective, warnings}; pass next_cursor back as cursor to get more results (empty when there are no more). search_mode_effective is how results were actually ranked, which differs from search_mode when a fallback was taken (e.g. 'keyword' when no embedding was available); warnings say why, and name parameters that were ignored.\n\nNote: the default search_mode will change from 'vector' to 'hybrid' in the next major version.",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: searchProperties(),
+			Required:   []string{},
+		},
+	}, s.handleSearch)
+
+	// find_similar tool
+	similarProperties := searchProperties()
+	delete(similarProperties, "query")
+	delete(similarProperties, "similar_to")
+	similarProperties["id"] = map[string]interface{}{
+		"type":        "string",
+		"description": "ID of the episode to find related memories for",
+	}
+	s.mcpServer.AddTool(mcp.Tool{
+		Name:        "find_similar",
+		Description: "Find memories related to an episode you already have, by its ID. Uses the episode's stored embedding, so it is cheaper than searching with its content, and never returns the episode itself. An episode without an embedding is matched by keyword on its content instead. Takes the same filters and options as search, and returns the same {episodes, next_cursor, search_mode_effective, warnings}.",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: similarProperties,
+			Required:   []string{"id"},
+		},
+	}, s.handleFindSimilar)
+
+	// get_episodes tool
+	s.mcpServer.AddTool(mcp.Tool{
+		Name:        "get_episodes",
+		Description: "Retrieve recent episodes in chronological order. All parameters are optional — call with no arguments to get the most recent episodes. Returns {episodes, next_cursor}; pass next_cursor back as cursor to page further into the past.",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
-				"query": map[string]interface{}{
-					"type":        "string",
-					"description": "Natural language text to search for. The system handles semantic matching automatically — just describe what you're looking for.",
-				},
 				"max_results": map[string]interface{}{
 					"type":        "integer",
-					"description": "Maximum number of results to return (default: 10)",
+					"description": "Maximum number of episodes to return (default: 10)",
+				},
+				"cursor": map[string]interface{}{
+					"type":        "string",
+					"description": "next_cursor from a previous call with the same arguments, to fetch the following page. Optional.",
 				},
 				"before": map[string]interface{}{
 					"type":        "string",
@@ -136,83 +173,70 @@ func (s *Server) registerTools() {
 					"type":        "string",
 					"description": "Only return episodes created after this time (ISO 8601). Optional.",
 				},
-				"tags": map[string]interface{}{
-					"type": "array",
-					"items": map[string]interface{}{
-						"type": "string",
-					},
-					"description": "Narrow results to episodes that have ALL of these tags. Only use if you know specific tags were stored. Optional.",
-				},
-				"source": map[string]interface{}{
+				"filter": map[string]interface{}{
 					"type":        "string",
-					"description": "Advanced filter: narrow results to a specific source client (e.g., 'claude-desktop'). Omit this in almost all cases — it will exclude memories from other sources. Only use if you need results from one specific client.",
+					"description": "Filter expression combining conditions with AND, OR, NOT and parentheses, e.g. `source = claude-code AND (tags any [deploy, ci] OR metadata.priority >= 2)`. Fields: group_id, source, source_model (=, !=, in [..]); tags (any/all/none [..]); created_at, valid_at, expired_at (<, <=, >, >= an ISO 8601 time); metadata.<key.path> (=, !=, <, <=, >, >=, in [..], exists). The JSON form ({\"and\": [...]}, {\"field\": ..., \"op\": ..., \"value\": ...}) is also accepted. Optional.",
 				},
 				"group_id": map[string]interface{}{
 					"type":        "string",
 					"description": "Advanced filter: narrow results to a specific group namespace. Omit this in almost all cases — it will exclude memories stored under other group IDs. Only use if you deliberately stored memories under a specific group.",
 				},
-				"include_expired": map[string]interface{}{
-					"type":        "boolean",
-					"description": "Include episodes that have been marked as expired (default: false). Optional.",
-				},
-				"min_similarity": map[string]interface{}{
-					"type":        "number",
-					"description": "Minimum cosine similarity threshold (0.0-1.0). 0.5 is a reasonable floor to filter noise. Only applies in vector/hybrid mode. Optional.",
-				},
-				"search_mode": map[string]interface{}{
+			},
+			Required: []string{},
+		},
+	}, s.handleGetEpisodes)
+
+	// get_episode tool
+	s.mcpServer.AddTool(mcp.Tool{
+		Name:        "get_episode",
+		Description: "Re-read full episodes by ID, e.g. ones returned by search. Expired episodes are returned too, with expired=true. Pass id for one episode or ids for several.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"id": map[string]interface{}{
 					"type":        "string",
-					"enum":        []string{"vector", "keyword", "hybrid"},
-					"description": "How to search: 'vector' (default) finds by meaning, 'keyword' finds by exact words (BM25), 'hybrid' combines both. Use keyword for proper nouns/error codes, vector for concept queries, hybrid for everything else.",
+					"description": "Episode ID",
 				},
-				"search_alpha": map[string]interface{}{
-					"type":        "number",
-					"description": "Hybrid mode weighting. Higher values (0.7+) favor semantic similarity, lower values (0.3-0.5) favor keyword matching (default: 0.7). For pure keyword search, use search_mode='keyword' instead.",
-					"minimum":     0.0,
-					"maximum":     1.0,
+				"ids": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "string",
+					},
+					"description": fmt.Sprintf("Several episode IDs (at most %d); results keep this order", maxLookupIDs),
 				},
-				"tag_boost": map[string]interface{}{
-					"type":        "number",
-					"description": "When > 0, tags boost rather than filter: results with matching tags rank higher but untagged results are still returned. 0.0 (default) = tags are hard AND filters that exclude non-matching episodes.",
-					"minimum":     0.0,
-					"maximum":     2.0,
+				"include_embedding": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include the stored embedding vector (default: false)",
 				},
 			},
 			Required: []string{},
 		},
-	}, s.handleSearch)
+	}, s.handleGetEpisode)
 
-	// get_episodes tool
+	// get_by_key tool
 	s.mcpServer.AddTool(mcp.Tool{
-		Name:        "get_episodes",
-		Description: "Retrieve recent episodes in chronological order. All parameters are optional — call with no arguments to get the most recent episodes.",
+		Name:        "get_by_key",
+		Description: "Read the current memory stored under a key by add_memory, e.g. a preference or setting. Returns the episode, or an error when the key holds no live memory. Earlier values are expired episodes, found by search with include_expired.",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
-				"max_results": map[string]interface{}{
-					"type":        "integer",
-					"description": "Maximum number of episodes to return (default: 10)",
-				},
-				"before": map[string]interface{}{
+				"key": map[string]interface{}{
 					"type":        "string",
-					"description": "Only return episodes created before this time (ISO 8601). Optional.",
-				},
-				"after": map[string]interface{}{
-					"type":        "string",
-					"description": "Only return episodes created after this time (ISO 8601). Optional.",
+					"description": "The key the memory was stored under",
 				},
 				"group_id": map[string]interface{}{
 					"type":        "string",
-					"description": "Advanced filter: narrow results to a specific group namespace. Omit this in almost all cases — it will exclude memories stored under other group IDs. Only use if you deliberately stored memories under a specific group.",
+					"description": "Advanced: the group namespace the key belongs to. Omit this in almost all cases — only set it if the memory was stored with a group_id.",
 				},
 			},
-			Required: []string{},
+			Required: []string{"key"},
 		},
-	}, s.handleGetEpisodes)
+	}, s.handleGetByKey)
 
 	// update_episode tool
 	s.mcpServer.AddTool(mcp.Tool{
 		Name:        "update_episode",
-		Description: "Update metadata, tags, or expiration of an episode. Setting expired_at to a past timestamp performs a soft-delete — the episode is hidden from default search but remains recoverable by setting expired_at back to null. Use tags to demote (e.g. add 'deprecated') so callers can filter stale content at query time.",
+		Description: "Update metadata, tags, expiration, content or name of an episode. Editing content or name keeps the episode's ID, saves the prior text as a version, and re-embeds new content — use it to correct a mistake rather than writing a new episode. Setting expired_at to a past timestamp performs a soft-delete — the episode is hidden from default search but remains recoverable by setting expired_at back to null. Use tags to demote (e.g. add 'deprecated') so callers can filter stale content at query time.",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
@@ -235,11 +259,43 @@ func (s *Server) registerTools() {
 					"type":        "string",
 					"description": "JSON string with metadata",
 				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Corrected content (replaces the current content; the prior text is kept as a version)",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "New name for the episode",
+				},
 			},
 			Required: []string{"id"},
 		},
 	}, s.handleUpdateEpisode)
 
+	// delete_episode tool
+	s.mcpServer.AddTool(mcp.Tool{
+		Name:        "delete_episode",
+		Description: "Permanently delete an episode: its content and embeddings are erased and cannot be recovered. Only the ID, who deleted it, when and why are kept in an audit log. Prefer update_episode with a past expired_at unless the content must be removed (e.g. the user asked for it to be forgotten).",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"id": map[string]interface{}{
+					"type":        "string",
+					"description": "Episode ID to delete",
+				},
+				"reason": map[string]interface{}{
+					"type":        "string",
+					"description": "Why the episode is being deleted (e.g. 'user asked to forget'), recorded in the audit log",
+				},
+				"deleted_by": map[string]interface{}{
+					"type":        "string",
+					"description": "Who requested the deletion, recorded in the audit log (defaults to 'mcp')",
+				},
+			},
+			Required: []string{"id", "reason"},
+		},
+	}, s.handleDeleteEpisode)
+
 	// get_status tool
 	s.mcpServer.AddTool(mcp.Tool{
 		Name:        "get_status",
@@ -252,6 +308,206 @@ func (s *Server) registerTools() {
 	}, s.handleGetStatus)
 }
 
+// memoryProperties is the input schema shared by add_memory and each
+// add_memories item
+func memoryProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"content": map[string]interface{}{
+			"type":        "string",
+			"description": "The episode content to store",
+		},
+		"name": map[string]interface{}{
+			"type":        "string",
+			"description": "Human-readable label for the episode",
+		},
+		"source": map[string]interface{}{
+			"type":        "string",
+			"description": "Identifier for what created this memory (e.g., 'claude-desktop', 'claude-code', 'my-app'). Use a consistent value per client so you can filter by it later.",
+		},
+		"source_model": map[string]interface{}{
+			"type":        "string",
+			"description": "Model that created this episode (e.g., 'opus-4.6')",
+		},
+		"source_description": map[string]interface{}{
+			"type":        "string",
+			"description": "Freeform context about the episode",
+		},
+		"group_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Advanced: namespace for multi-tenant isolation. Omit this in almost all cases — the server assigns a default automatically. Only set if you are deliberately partitioning memories between separate users or contexts.",
+		},
+		"key": map[string]interface{}{
+			"type":        "string",
+			"description": "Name of a value that should have one current memory, e.g. 'preferred_editor'. Storing a memory with a key that already holds one in its group expires the old memory and links it to the new one (superseded_by in its metadata); read the current value with get_by_key. Optional.",
+		},
+		"tags": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+			"description": "Tags for categorization",
+		},
+		"valid_at": map[string]interface{}{
+			"type":        "string",
+			"description": "When the information became true (ISO 8601)",
+		},
+		"metadata": map[string]interface{}{
+			"type":        "string",
+			"description": "JSON string with additional metadata",
+		},
+		"importance": map[string]interface{}{
+			"type":        "number",
+			"description": "How much this memory matters (0.0-1.0). Searches that set importance_weight rank important memories higher; unset counts as 0.5. Optional.",
+			"minimum":     0.0,
+			"maximum":     1.0,
+		},
+	}
+}
+
+// addMemoryProperties is the input schema of add_memory: an episode plus
+// its dedupe policy
+func addMemoryProperties() map[string]interface{} {
+	props := memoryProperties()
+	props["dedupe"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{db.DedupeReject, db.DedupeReturnExisting, db.DedupeMerge},
+		"description": "What to do if this memory duplicates a live one in its group — same content, or an embedding at least dedupe_threshold similar: 'reject' stores nothing and fails, 'return_existing' stores nothing and returns the existing memory's ID, 'merge' adds this memory's tags and metadata to the existing one. The response's dedupe.action says which happened. Omit to always store. Optional.",
+	}
+	props["dedupe_threshold"] = map[string]interface{}{
+		"type":        "number",
+		"description": fmt.Sprintf("Cosine similarity from which a memory counts as a near-duplicate (default %.2f). Optional.", db.DefaultDedupeThreshold),
+		"minimum":     0.0,
+		"maximum":     1.0,
+	}
+	return props
+}
+
+// searchProperties is the input schema of search. find_similar shares its
+// filters and ranking options.
+func searchProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"query": map[string]interface{}{
+			"type":        "string",
+			"description": "Natural language text to search for. The system handles semantic matching automatically — just describe what you're looking for.",
+		},
+		"similar_to": map[string]interface{}{
+			"type":        "string",
+			"description": "Episode ID to search around instead of a query: finds memories related to that episode using its stored embedding (or its content, without one), and leaves the episode itself out. Cannot be combined with query. Optional.",
+		},
+		"max_results": map[string]interface{}{
+			"type":        "integer",
+			"description": "Maximum number of results to return (default: 10)",
+		},
+		"cursor": map[string]interface{}{
+			"type":        "string",
+			"description": "next_cursor from a previous call with the same arguments, to fetch the following page. Optional.",
+		},
+		"before": map[string]interface{}{
+			"type":        "string",
+			"description": "Only return episodes created before this time (ISO 8601). Optional.",
+		},
+		"after": map[string]interface{}{
+			"type":        "string",
+			"description": "Only return episodes created after this time (ISO 8601). Optional.",
+		},
+		"tags": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+			},
+			"description": "Narrow results to episodes that have ALL of these tags. Only use if you know specific tags were stored. Optional.",
+		},
+		"source": map[string]interface{}{
+			"type":        "string",
+			"description": "Advanced filter: narrow results to a specific source client (e.g., 'claude-desktop'). Omit this in almost all cases — it will exclude memories from other sources. Only use if you need results from one specific client.",
+		},
+		"group_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Advanced filter: narrow results to a specific group namespace. Omit this in almost all cases — it will exclude memories stored under other group IDs. Only use if you deliberately stored memories under a specific group.",
+		},
+		"include_expired": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Include episodes that have been marked as expired (default: false). Optional.",
+		},
+		"filter": map[string]interface{}{
+			"type":        "string",
+			"description": "Filter expression combining conditions with AND, OR, NOT and parentheses, e.g. `source = claude-code AND (tags any [deploy, ci] OR metadata.priority >= 2)`. Fields: group_id, source, source_model (=, !=, in [..]); tags (any/all/none [..]); created_at, valid_at, expired_at (<, <=, >, >= an ISO 8601 time); metadata.<key.path> (=, !=, <, <=, >, >=, in [..], exists). The JSON form ({\"and\": [...]}, {\"field\": ..., \"op\": ..., \"value\": ...}) is also accepted. Optional.",
+		},
+		"as_of": map[string]interface{}{
+			"type":        "string",
+			"description": "Search memory as it stood at this time (ISO 8601): only memories stored by then, already true by then (valid_at), and not yet expired then. Use to reconstruct what was known when a past decision was made. Optional.",
+		},
+		"valid_before": map[string]interface{}{
+			"type":        "string",
+			"description": "Only memories that became true (valid_at, else when stored) before this time (ISO 8601). Optional.",
+		},
+		"valid_after": map[string]interface{}{
+			"type":        "string",
+			"description": "Only memories that became true (valid_at, else when stored) after this time (ISO 8601). Optional.",
+		},
+		"min_similarity": map[string]interface{}{
+			"type":        "number",
+			"description": "Minimum cosine similarity threshold (0.0-1.0). 0.5 is a reasonable floor to filter noise. Only applies in vector/hybrid mode. Optional.",
+		},
+		"search_mode": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"vector", "keyword", "hybrid"},
+			"description": "How to search: 'vector' (default) finds by meaning, 'keyword' finds by exact words (BM25), 'hybrid' combines both. Use keyword for proper nouns/error codes, vector for concept queries, hybrid for everything else.",
+		},
+		"search_alpha": map[string]interface{}{
+			"type":        "number",
+			"description": "Hybrid mode weighting. Higher values (0.7+) favor semantic similarity, lower values (0.3-0.5) favor keyword matching (default: 0.7). For pure keyword search, use search_mode='keyword' instead.",
+			"minimum":     0.0,
+			"maximum":     1.0,
+		},
+		"fusion": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"linear", "rrf"},
+			"description": "How hybrid mode merges the top vector and top keyword results: 'linear' (default) blends their normalized scores by search_alpha, 'rrf' (reciprocal rank fusion) uses only their ranks, so no single outlier score dominates. search_alpha is ignored with 'rrf'. Optional.",
+		},
+		"tag_boost": map[string]interface{}{
+			"type":        "number",
+			"description": "When > 0, tags boost rather than filter: results with matching tags rank higher but untagged results are still returned. 0.0 (default) = tags are hard AND filters that exclude non-matching episodes.",
+			"minimum":     0.0,
+			"maximum":     2.0,
+		},
+		"diversity": map[string]interface{}{
+			"type":        "number",
+			"description": "When > 0, results are re-ranked so near-duplicates don't crowd out other relevant memories (maximal marginal relevance). Higher values trade more relevance for variety; 0.3 is a good start when the same fact was saved many times. 0.0 (default) = off. Vector and hybrid modes only. Optional.",
+			"minimum":     0.0,
+			"maximum":     1.0,
+		},
+		"recency_weight": map[string]interface{}{
+			"type":        "number",
+			"description": "When > 0, newer memories rank higher: adds this weight times a decay term that is 1.0 for a memory written now and halves every recency_half_life_days. 0.2 nudges ties toward recent memories; 1.0 makes recency as strong as relevance. 0.0 (default) = off. Optional.",
+			"minimum":     0.0,
+		},
+		"recency_half_life_days": map[string]interface{}{
+			"type":        "number",
+			"description": "Days for the recency term to halve (default: 30). Optional.",
+			"minimum":     0.0,
+		},
+		"recency_field": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{"created_at", "valid_at"},
+			"description": "Which timestamp recency decays from: 'created_at' (default, when the memory was stored) or 'valid_at' (when it became true, falling back to created_at). Optional.",
+		},
+		"importance_weight": map[string]interface{}{
+			"type":        "number",
+			"description": "When > 0, memories stored with a higher importance rank higher: adds this weight times the memory's importance (0.5 when unset). 0.0 (default) = off. Optional.",
+			"minimum":     0.0,
+		},
+		"explain": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Attach an explanation to each result showing how its relevance was computed: raw and normalized BM25 and similarity, search_alpha, hybrid ranks, the tag, recency, and importance terms, and any fallback taken. For debugging rankings; omit otherwise. Optional.",
+		},
+		"strict": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Fail instead of falling back to a weaker search mode (e.g. vector search running chronologically because the embeddings server is down). Default false: degraded searches succeed and say so in warnings. Optional.",
+		},
+	}
+}
+
 // Tool handlers
 
 // parseParams converts MCP request arguments to a struct
@@ -271,25 +527,39 @@ func (s *Server) handleAddMemory(ctx context.Context, request mcp.CallToolReques
 		SourceModel       string   `json:"source_model"`
 		SourceDescription string   `json:"source_description"`
 		GroupID           string   `json:"group_id"`
+		Key               string   `json:"key"`
 		Tags              []string `json:"tags"`
 		ValidAt           string   `json:"valid_at"`
 		Metadata          string   `json:"metadata"`
+		Importance        *float64 `json:"importance"`
+		Dedupe            string   `json:"dedupe"`
+		DedupeThreshold   float64  `json:"dedupe_threshold"`
 	}
 
 	if err := parseParams(request.Params.Arguments, &params); err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
 	}
+	if params.Importance != nil && (*params.Importance < 0 || *params.Importance > 1) {
+		return mcp.NewToolResultError("importance must be between 0.0 and 1.0"), nil
+	}
+	if params.Key != "" && strings.TrimSpace(params.Key) == "" {
+		return mcp.NewToolResultError("key cannot be blank"), nil
+	}
+	policy := db.DedupePolicy{Action: params.Dedupe, Threshold: params.DedupeThreshold}
+	if err := policy.Validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Generate embedding with a fresh context (5 second timeout)
 	// Using background context to avoid cancellation from MCP request context
 	embedCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	emb, err := s.embedder.Generate(embedCtx, params.Content)
+	emb, chunks, err := s.chunker.Embed(embedCtx, s.embedder, params.Content)
 	if err != nil {
 		// Log error but continue with NULL embedding
 		fmt.Fprintf(os.Stderr, "Warning: Failed to generate embedding: %v\n", err)
-		emb = nil
+		emb, chunks = nil, nil
 	} else {
 		fmt.Fprintf(os.Stderr, "Success: Generated embedding with %d dimensions\n", len(emb))
 	}
@@ -311,21 +581,118 @@ func (s *Server) handleAddMemory(ctx context.Context, request mcp.CallToolReques
 		SourceModel:       params.SourceModel,
 		SourceDescription: params.SourceDescription,
 		GroupID:           params.GroupID,
+		Key:               params.Key,
 		Tags:              params.Tags,
 		Embedding:         emb,
+		Chunks:            chunks,
 		EmbeddingModel:    s.embedder.Model(),
 		ValidAt:           validAt,
 		Metadata:          params.Metadata,
+		Importance:        params.Importance,
 	}
 
-	if err := s.store.InsertEpisode(ctx, ep); err != nil {
+	stored, dedupe, err := s.store.InsertEpisodeDeduped(ctx, ep, policy)
+	if errors.Is(err, db.ErrDuplicate) {
+		result, _ := json.Marshal(map[string]interface{}{"success": false, "error": err.Error(), "dedupe": dedupe})
+		return mcp.NewToolResultError(string(result)), nil
+	}
+	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to store episode: %v", err)), nil
 	}
 
-	result, _ := json.Marshal(map[string]interface{}{
+	resp := map[string]interface{}{
 		"success": true,
-		"id":      ep.ID,
+		"id":      stored.ID,
 		"message": "Episode stored successfully",
+	}
+	if policy.Action != db.DedupeOff {
+		resp["dedupe"] = dedupe
+	}
+	if stored.Supersedes != "" {
+		resp["supersedes"] = stored.Supersedes
+	}
+	switch {
+	case dedupe.Action == "returned_existing":
+		resp["message"] = "Duplicate of an existing episode; nothing stored"
+	case dedupe.Action == "merged":
+		resp["message"] = "Duplicate of an existing episode; its tags and metadata were merged into it"
+	case len(emb) == 0:
+		resp["embedding_queued"] = true
+		resp["message"] = "Episode stored; embedding endpoint unavailable, so it is queued for background embedding and not yet vector-searchable"
+	}
+	result, _ := json.Marshal(resp)
+
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+// maxBatchMemories bounds one add_memories call, matching the HTTP bulk
+// endpoint's limit
+const maxBatchMemories = 1000
+
+func (s *Server) handleAddMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params struct {
+		Memories []models.EpisodeInput `json:"memories"`
+	}
+
+	if err := parseParams(request.Params.Arguments, &params); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
+	}
+	if len(params.Memories) == 0 {
+		return mcp.NewToolResultError("memories must contain at least one episode"), nil
+	}
+	if len(params.Memories) > maxBatchMemories {
+		return mcp.NewToolResultError(fmt.Sprintf("%d memories exceeds the limit of %d per call", len(params.Memories), maxBatchMemories)), nil
+	}
+
+	type itemResult struct {
+		Index      int    `json:"index"`
+		ID         string `json:"id,omitempty"`
+		Supersedes string `json:"supersedes,omitempty"`
+		Error      string `json:"error,omitempty"`
+	}
+	results := make([]itemResult, len(params.Memories))
+	var episodes []*models.Episode
+	var positions []int
+	keys := make(map[[2]string]int) // group and key to the item writing it
+	for i, m := range params.Memories {
+		results[i].Index = i
+		ep, err := m.Episode()
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		// Only a valid item claims its key
+		if ep.Key != "" {
+			k := [2]string{ep.GroupID, ep.Key}
+			if j, ok := keys[k]; ok {
+				results[i].Error = fmt.Sprintf("key %q is already written by item %d", ep.Key, j)
+				continue
+			}
+			keys[k] = i
+		}
+		episodes = append(episodes, ep)
+		positions = append(positions, i)
+	}
+
+	// Stored without vectors: the background worker embeds them, so a large
+	// import never blocks on the embedding server
+	if err := s.store.InsertEpisodes(ctx, episodes); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to store episodes: %v", err)), nil
+	}
+	for j, ep := range episodes {
+		results[positions[j]].ID = ep.ID
+		results[positions[j]].Supersedes = ep.Supersedes
+	}
+	if len(episodes) > 0 && s.embeddingQueue != nil {
+		s.embeddingQueue.Kick()
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"success":          len(episodes) == len(params.Memories),
+		"inserted":         len(episodes),
+		"failed":           len(params.Memories) - len(episodes),
+		"embedding_queued": len(episodes),
+		"results":          results,
 	})
 
 	return mcp.NewToolResultText(string(result)), nil
@@ -333,18 +700,32 @@ func (s *Server) handleAddMemory(ctx context.Context, request mcp.CallToolReques
 
 func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var params struct {
-		Query          string   `json:"query"`
-		GroupID        string   `json:"group_id"`
-		MaxResults     int      `json:"max_results"`
-		Before         string   `json:"before"`
-		After          string   `json:"after"`
-		Tags           []string `json:"tags"`
-		Source         string   `json:"source"`
-		IncludeExpired bool     `json:"include_expired"`
-		MinSimilarity  float64  `json:"min_similarity"`
-		SearchMode     string   `json:"search_mode"`
-		SearchAlpha    float64  `json:"search_alpha"`
-		TagBoost       float64  `json:"tag_boost"`
+		Query               string       `json:"query"`
+		SimilarTo           string       `json:"similar_to"`
+		GroupID             string       `json:"group_id"`
+		MaxResults          int          `json:"max_results"`
+		Before              string       `json:"before"`
+		After               string       `json:"after"`
+		Tags                []string     `json:"tags"`
+		Source              string       `json:"source"`
+		Filter              *filter.Expr `json:"filter"`
+		IncludeExpired      bool         `json:"include_expired"`
+		AsOf                string       `json:"as_of"`
+		ValidBefore         string       `json:"valid_before"`
+		ValidAfter          string       `json:"valid_after"`
+		MinSimilarity       float64      `json:"min_similarity"`
+		SearchMode          string       `json:"search_mode"`
+		SearchAlpha         float64      `json:"search_alpha"`
+		Fusion              string       `json:"fusion"`
+		TagBoost            float64      `json:"tag_boost"`
+		Diversity           float64      `json:"diversity"`
+		RecencyWeight       float64      `json:"recency_weight"`
+		RecencyHalfLifeDays float64      `json:"recency_half_life_days"`
+		RecencyField        string       `json:"recency_field"`
+		ImportanceWeight    float64      `json:"importance_weight"`
+		Cursor              string       `json:"cursor"`
+		Explain             bool         `json:"explain"`
+		Strict              bool         `json:"strict"`
 	}
 
 	if err := parseParams(request.Params.Arguments, &params); err != nil {
@@ -356,21 +737,54 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError("search_mode must be 'vector', 'keyword', or 'hybrid'"), nil
 	}
 
+	// Validate fusion
+	if params.Fusion != "" && params.Fusion != "linear" && params.Fusion != "rrf" {
+		return mcp.NewToolResultError("fusion must be 'linear' or 'rrf'"), nil
+	}
+
+	// A seeded search takes its query from the seed episode
+	if params.Query != "" && params.SimilarTo != "" {
+		return mcp.NewToolResultError("query and similar_to cannot be combined"), nil
+	}
+
 	// Validate search_alpha range
 	if params.SearchAlpha < 0 || params.SearchAlpha > 1 {
 		return mcp.NewToolResultError("search_alpha must be between 0.0 and 1.0"), nil
 	}
 
+	// Validate diversity range
+	if params.Diversity < 0 || params.Diversity > 1 {
+		return mcp.NewToolResultError("diversity must be between 0.0 and 1.0"), nil
+	}
+
+	// Validate the filter expression
+	if params.Filter != nil {
+		if err := params.Filter.Validate(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid filter: %v", err)), nil
+		}
+	}
+
+	// Validate recency and importance weighting
+	if params.RecencyWeight < 0 || params.RecencyHalfLifeDays < 0 || params.ImportanceWeight < 0 {
+		return mcp.NewToolResultError("recency_weight, recency_half_life_days, and importance_weight must not be negative"), nil
+	}
+	if params.RecencyField != "" && params.RecencyField != "created_at" && params.RecencyField != "valid_at" {
+		return mcp.NewToolResultError("recency_field must be 'created_at' or 'valid_at'"), nil
+	}
+
 	// Generate embedding for semantic search (skip for keyword mode)
 	var queryEmbedding []float32
+	var embeddingErr error
 	if params.Query != "" && params.SearchMode != "keyword" {
 		embedCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		emb, err := s.embedder.Generate(embedCtx, params.Query)
 		if err != nil {
-			// Log warning but continue without semantic search - will fall back to temporal ordering
+			// Continue without semantic search; the store falls back and
+			// reports it in the page's warnings
 			fmt.Fprintf(os.Stderr, "Warning: Failed to generate query embedding: %v\n", err)
+			embeddingErr = err
 		} else {
 			queryEmbedding = emb
 			fmt.Fprintf(os.Stderr, "Success: Generated query embedding with %d dimensions\n", len(emb))
@@ -392,38 +806,97 @@ func (s *Server) handleSearch(ctx context.Context, request mcp.CallToolRequest)
 		}
 	}
 
+	// Parse as-of and valid-time filters. Unlike before/after these are
+	// rejected when malformed: ignoring one would silently answer about now.
+	var asOf, validBefore, validAfter *time.Time
+	if params.AsOf != "" {
+		t, err := time.Parse(time.RFC3339, params.AsOf)
+		if err != nil {
+			return mcp.NewToolResultError("invalid as_of format, use ISO 8601"), nil
+		}
+		asOf = &t
+	}
+	if params.ValidBefore != "" {
+		t, err := time.Parse(time.RFC3339, params.ValidBefore)
+		if err != nil {
+			return mcp.NewToolResultError("invalid valid_before format, use ISO 8601"), nil
+		}
+		validBefore = &t
+	}
+	if params.ValidAfter != "" {
+		t, err := time.Parse(time.RFC3339, params.ValidAfter)
+		if err != nil {
+			return mcp.NewToolResultError("invalid valid_after format, use ISO 8601"), nil
+		}
+		validAfter = &t
+	}
+
 	// Build search params
 	searchParams := models.SearchParams{
-		Query:          params.Query,
-		QueryEmbedding: queryEmbedding,
-		GroupID:        params.GroupID,
-		MaxResults:     params.MaxResults,
-		Before:         before,
-		After:          after,
-		Tags:           params.Tags,
-		Source:         params.Source,
-		IncludeExpired: params.IncludeExpired,
-		MinSimilarity:  params.MinSimilarity,
-		SearchMode:     params.SearchMode,
-		SearchAlpha:    params.SearchAlpha,
-		TagBoost:       params.TagBoost,
-	}
-
-	episodes, err := s.store.Search(ctx, searchParams)
+		Query:               params.Query,
+		QueryEmbedding:      queryEmbedding,
+		SimilarTo:           params.SimilarTo,
+		GroupID:             params.GroupID,
+		MaxResults:          params.MaxResults,
+		Before:              before,
+		After:               after,
+		Tags:                params.Tags,
+		Source:              params.Source,
+		Filter:              params.Filter,
+		IncludeExpired:      params.IncludeExpired,
+		AsOf:                asOf,
+		ValidBefore:         validBefore,
+		ValidAfter:          validAfter,
+		MinSimilarity:       params.MinSimilarity,
+		SearchMode:          params.SearchMode,
+		SearchAlpha:         params.SearchAlpha,
+		Fusion:              params.Fusion,
+		TagBoost:            params.TagBoost,
+		Diversity:           params.Diversity,
+		RecencyWeight:       params.RecencyWeight,
+		RecencyHalfLifeDays: params.RecencyHalfLifeDays,
+		RecencyField:        params.RecencyField,
+		ImportanceWeight:    params.ImportanceWeight,
+		Cursor:              params.Cursor,
+		Explain:             params.Explain,
+		Strict:              params.Strict,
+		EmbeddingError:      embeddingErr,
+	}
+
+	page, err := s.store.SearchPage(ctx, searchParams)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
 	}
 
-	result, _ := json.Marshal(episodes)
+	result, _ := json.Marshal(page)
 	return mcp.NewToolResultText(string(result)), nil
 }
 
+// handleFindSimilar is search seeded from an episode: id becomes similar_to
+func (s *Server) handleFindSimilar(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := make(map[string]interface{})
+	for k, v := range request.GetArguments() {
+		args[k] = v
+	}
+	id, _ := args["id"].(string)
+	if id == "" {
+		return mcp.NewToolResultError("id is required"), nil
+	}
+	delete(args, "id")
+	delete(args, "query")
+	args["similar_to"] = id
+	request.Params.Arguments = args
+	return s.handleSearch(ctx, request)
+}
+
 func (s *Server) handleGetEpisodes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var params struct {
-		GroupID    string `json:"group_id"`
-		MaxResults int    `json:"max_results"`
-		Before     string `json:"before"`
-		After      string `json:"after"`
+		GroupID    string       `json:"group_id"`
+		MaxResults int          `json:"max_results"`
+		Before     string       `json:"before"`
+		After      string       `json:"after"`
+		Filter     *filter.Expr `json:"filter"`
+		Cursor     string       `json:"cursor"`
 	}
 
 	if err := parseParams(request.Params.Arguments, &params); err != nil {
@@ -445,19 +918,107 @@ func (s *Server) handleGetEpisodes(ctx context.Context, request mcp.CallToolRequ
 		}
 	}
 
+	if params.Filter != nil {
+		if err := params.Filter.Validate(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid filter: %v", err)), nil
+		}
+	}
+
 	searchParams := models.SearchParams{
 		GroupID:    params.GroupID,
 		MaxResults: params.MaxResults,
 		Before:     before,
 		After:      after,
+		Filter:     params.Filter,
+		Cursor:     params.Cursor,
+	}
+
+	page, err := s.store.SearchPage(ctx, searchParams)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get episodes: %v", err)), nil
+	}
+
+	result, _ := json.Marshal(page)
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+// maxLookupIDs bounds one get_episode call, matching the HTTP lookup
+const maxLookupIDs = 100
+
+func (s *Server) handleGetEpisode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params struct {
+		ID               string   `json:"id"`
+		IDs              []string `json:"ids"`
+		IncludeEmbedding bool     `json:"include_embedding"`
+	}
+
+	if err := parseParams(request.Params.Arguments, &params); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
+	}
+
+	if params.ID != "" {
+		episodes, err := s.store.GetEpisodesByID(ctx, []string{params.ID}, params.IncludeEmbedding)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get episode: %v", err)), nil
+		}
+		if len(episodes) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("episode not found: %s", params.ID)), nil
+		}
+		result, _ := json.Marshal(models.NewEpisodeLookups(episodes, time.Now())[0])
+		return mcp.NewToolResultText(string(result)), nil
+	}
+
+	if len(params.IDs) == 0 {
+		return mcp.NewToolResultError("id or ids is required"), nil
+	}
+	if len(params.IDs) > maxLookupIDs {
+		return mcp.NewToolResultError(fmt.Sprintf("at most %d ids per call", maxLookupIDs)), nil
 	}
 
-	episodes, err := s.store.Search(ctx, searchParams)
+	episodes, err := s.store.GetEpisodesByID(ctx, params.IDs, params.IncludeEmbedding)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get episodes: %v", err)), nil
 	}
+	found := make(map[string]bool, len(episodes))
+	for _, ep := range episodes {
+		found[ep.ID] = true
+	}
+	missing := []string{}
+	for _, id := range params.IDs {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"episodes": models.NewEpisodeLookups(episodes, time.Now()),
+		"missing":  missing,
+	})
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+func (s *Server) handleGetByKey(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params struct {
+		Key     string `json:"key"`
+		GroupID string `json:"group_id"`
+	}
+
+	if err := parseParams(request.Params.Arguments, &params); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
+	}
+	if strings.TrimSpace(params.Key) == "" {
+		return mcp.NewToolResultError("key is required"), nil
+	}
 
-	result, _ := json.Marshal(episodes)
+	ep, err := s.store.GetEpisodeByKey(ctx, params.GroupID, params.Key)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get episode: %v", err)), nil
+	}
+	if ep == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no current memory for key %q", params.Key)), nil
+	}
+	result, _ := json.Marshal(ep)
 	return mcp.NewToolResultText(string(result)), nil
 }
 
@@ -467,13 +1028,15 @@ func (s *Server) handleUpdateEpisode(ctx context.Context, request mcp.CallToolRe
 		Tags      []string `json:"tags"`
 		ExpiredAt string   `json:"expired_at"`
 		Metadata  string   `json:"metadata"`
+		Content   string   `json:"content"`
+		Name      *string  `json:"name"`
 	}
 
 	if err := parseParams(request.Params.Arguments, &params); err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
 	}
 
-	updateParams := models.UpdateParams{}
+	updateParams := models.UpdateParams{Name: params.Name}
 
 	if len(params.Tags) > 0 {
 		updateParams.Tags = &params.Tags
@@ -490,13 +1053,66 @@ func (s *Server) handleUpdateEpisode(ctx context.Context, request mcp.CallToolRe
 		updateParams.Metadata = &params.Metadata
 	}
 
+	// New content is re-embedded; without a vector the edit is stored and
+	// queued for background embedding
+	if params.Content != "" {
+		updateParams.Content = &params.Content
+		embedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		emb, chunks, err := s.chunker.Embed(embedCtx, s.embedder, params.Content)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to generate embedding for edit: %v\n", err)
+		} else {
+			updateParams.Embedding = emb
+			updateParams.Chunks = chunks
+			updateParams.EmbeddingModel = s.embedder.Model()
+		}
+	}
+
 	if err := s.store.UpdateEpisode(ctx, params.ID, updateParams); err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to update episode: %v", err)), nil
 	}
 
-	result, _ := json.Marshal(map[string]interface{}{
+	resp := map[string]interface{}{
 		"success": true,
 		"message": "Episode updated successfully",
+	}
+	if params.Content != "" {
+		resp["embedded"] = len(updateParams.Embedding) > 0
+	}
+	result, _ := json.Marshal(resp)
+
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+func (s *Server) handleDeleteEpisode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var params struct {
+		ID        string `json:"id"`
+		Reason    string `json:"reason"`
+		DeletedBy string `json:"deleted_by"`
+	}
+
+	if err := parseParams(request.Params.Arguments, &params); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid parameters: %v", err)), nil
+	}
+	if params.ID == "" || params.Reason == "" {
+		return mcp.NewToolResultError("id and reason are required"), nil
+	}
+	if params.DeletedBy == "" {
+		params.DeletedBy = "mcp"
+	}
+
+	n, err := s.store.DeleteEpisodes(ctx, []string{params.ID}, db.Deletion{By: params.DeletedBy, Reason: params.Reason})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete episode: %v", err)), nil
+	}
+	if n == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("episode not found: %s", params.ID)), nil
+	}
+
+	result, _ := json.Marshal(map[string]interface{}{
+		"success": true,
+		"message": "Episode permanently deleted",
 	})
 
 	return mcp.NewToolResultText(string(result)), nil
@@ -508,10 +1124,16 @@ func (s *Server) handleGetStatus(ctx context.Context, request mcp.CallToolReques
 		"version":         "1.0.0",
 		"embedding_model": s.embedder.Model(),
 	}
+	if space := s.store.ActiveEmbeddingModel(); space != "" {
+		resp["embedding_space"] = space
+	}
 
 	if count, err := s.store.CountEpisodes(ctx); err == nil {
 		resp["episode_count"] = count
 	}
+	if queued, err := s.store.CountQueuedEmbeddings(ctx); err == nil {
+		resp["embedding_queue"] = queued
+	}
 
 	// A degraded embedding endpoint silently downgrades search to
 	// keyword-only — agents reading status must be able to see it
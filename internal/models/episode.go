@@ -1,47 +1,212 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oscillatelabsllc/engram/internal/filter"
+)
 
 // Episode represents a memory episode in the system
 type Episode struct {
-	ID                string     `json:"id"`
-	Content           string     `json:"content"`
-	Name              string     `json:"name,omitempty"`
-	Source            string     `json:"source"`
-	SourceModel       string     `json:"source_model,omitempty"`
-	SourceDescription string     `json:"source_description,omitempty"`
-	GroupID           string     `json:"group_id"`
-	Tags              []string   `json:"tags,omitempty"`
-	Embedding         []float32  `json:"embedding,omitempty"`
-	EmbeddingModel    string     `json:"embedding_model,omitempty"`
-	CreatedAt         time.Time  `json:"created_at"`
-	ValidAt           *time.Time `json:"valid_at,omitempty"`
-	ExpiredAt         *time.Time `json:"expired_at,omitempty"`
-	Metadata          string     `json:"metadata,omitempty"` // JSON string
-	Similarity        *float64   `json:"similarity,omitempty"`
-	Relevance         *float64   `json:"relevance,omitempty"`
+	ID                string       `json:"id"`
+	Content           string       `json:"content"`
+	Name              string       `json:"name,omitempty"`
+	Source            string       `json:"source"`
+	SourceModel       string       `json:"source_model,omitempty"`
+	SourceDescription string       `json:"source_description,omitempty"`
+	GroupID           string       `json:"group_id"`
+	Key               string       `json:"key,omitempty"` // Names a value with one live episode per group; writing it again supersedes the last
+	Tags              []string     `json:"tags,omitempty"`
+	Embedding         []float32    `json:"embedding,omitempty"`
+	EmbeddingModel    string       `json:"embedding_model,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	ValidAt           *time.Time   `json:"valid_at,omitempty"`
+	ExpiredAt         *time.Time   `json:"expired_at,omitempty"`
+	Metadata          string       `json:"metadata,omitempty"`   // JSON string
+	Importance        *float64     `json:"importance,omitempty"` // 0.0-1.0, set by the writer; unset ranks as 0.5
+	Similarity        *float64     `json:"similarity,omitempty"`
+	Relevance         *float64     `json:"relevance,omitempty"`
+	Explanation       *Explanation `json:"explanation,omitempty"` // Set by a search with Explain
+	Snippet           string       `json:"snippet,omitempty"`     // The chunk a semantic search matched, for chunked episodes
+	Chunks            []Chunk      `json:"-"`                     // Chunk vectors to store with Embedding
+	Supersedes        string       `json:"supersedes,omitempty"`  // Set on insert: the episode that held Key until this write
+}
+
+// EpisodeInput is one episode as a write request describes it, over HTTP or
+// MCP
+type EpisodeInput struct {
+	Content           string   `json:"content"`
+	Name              string   `json:"name,omitempty"`
+	Source            string   `json:"source"`
+	SourceModel       string   `json:"source_model,omitempty"`
+	SourceDescription string   `json:"source_description,omitempty"`
+	GroupID           string   `json:"group_id,omitempty"`
+	Key               string   `json:"key,omitempty"` // Supersedes the live episode holding this key in the group
+	Tags              []string `json:"tags,omitempty"`
+	ValidAt           string   `json:"valid_at,omitempty"`
+	Metadata          string   `json:"metadata,omitempty"`
+	Importance        *float64 `json:"importance,omitempty"`
+}
+
+// Episode validates in and builds the episode it describes, without an
+// embedding. An empty group is "default".
+func (in EpisodeInput) Episode() (*Episode, error) {
+	if in.Content == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+	if in.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+
+	if in.GroupID == "" {
+		in.GroupID = "default"
+	}
+
+	var validAt *time.Time
+	if in.ValidAt != "" {
+		t, err := time.Parse(time.RFC3339, in.ValidAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid valid_at format, use ISO 8601")
+		}
+		validAt = &t
+	}
+
+	if in.Importance != nil && (*in.Importance < 0 || *in.Importance > 1) {
+		return nil, fmt.Errorf("importance must be between 0.0 and 1.0")
+	}
+	if in.Key != "" && strings.TrimSpace(in.Key) == "" {
+		return nil, fmt.Errorf("key cannot be blank")
+	}
+
+	return &Episode{
+		Name:              in.Name,
+		Content:           in.Content,
+		Source:            in.Source,
+		SourceModel:       in.SourceModel,
+		SourceDescription: in.SourceDescription,
+		GroupID:           in.GroupID,
+		Key:               in.Key,
+		Tags:              in.Tags,
+		ValidAt:           validAt,
+		Metadata:          in.Metadata,
+		Importance:        in.Importance,
+	}, nil
+}
+
+// Chunk is one window of a long episode's content, embedded on its own so
+// the episode stays searchable past the embedding model's context window
+type Chunk struct {
+	Index     int
+	Content   string
+	Embedding []float32
+}
+
+// Explanation breaks down how a search scored one result. Parts that didn't
+// take part in the search are left out.
+type Explanation struct {
+	BM25Score            *float64 `json:"bm25_score,omitempty"`            // Raw BM25 score
+	Similarity           *float64 `json:"similarity,omitempty"`            // Raw cosine similarity
+	BM25Normalized       *float64 `json:"bm25_normalized,omitempty"`       // BM25 min-max normalized over the scored results
+	SimilarityNormalized *float64 `json:"similarity_normalized,omitempty"` // Similarity min-max normalized over the scored results
+	Alpha                *float64 `json:"alpha,omitempty"`                 // Hybrid linear fusion's weight on similarity
+	VectorRank           *int     `json:"vector_rank,omitempty"`           // Rank among hybrid's vector candidates
+	KeywordRank          *int     `json:"keyword_rank,omitempty"`          // Rank among hybrid's keyword matches
+	TagMatchRatio        *float64 `json:"tag_match_ratio,omitempty"`       // Share of the boosted tags the episode has
+	TagBoost             *float64 `json:"tag_boost,omitempty"`             // tag_boost × tag_match_ratio, added to relevance
+	Recency              *float64 `json:"recency,omitempty"`               // Recency term added to relevance
+	Importance           *float64 `json:"importance,omitempty"`            // Importance term added to relevance
+	Fallback             string   `json:"fallback,omitempty"`              // "ilike", "hybrid_to_keyword", "vector_to_chronological", or "similar_to_keyword"
 }
 
 // SearchParams defines parameters for searching episodes
 type SearchParams struct {
-	Query          string     `json:"query"`
-	QueryEmbedding []float32  `json:"query_embedding,omitempty"` // Embedding vector for semantic search
-	GroupID        string     `json:"group_id"`
-	MaxResults     int        `json:"max_results"`
-	Before         *time.Time `json:"before,omitempty"`
-	After          *time.Time `json:"after,omitempty"`
-	Tags           []string   `json:"tags,omitempty"`
-	Source         string     `json:"source,omitempty"`
-	IncludeExpired bool       `json:"include_expired"`
-	MinSimilarity  float64    `json:"min_similarity,omitempty"` // Minimum cosine similarity threshold (0.0-1.0)
-	SearchMode     string     `json:"search_mode,omitempty"`    // "vector" (default), "keyword", or "hybrid"
-	SearchAlpha    float64    `json:"search_alpha,omitempty"`   // Hybrid weighting: 0.0 = BM25 only, 1.0 = cosine only (default: 0.7)
-	TagBoost       float64    `json:"tag_boost,omitempty"`      // 0.0 = hard filter (default), >0 = boost tag matches by this weight
-}
-
-// UpdateParams defines parameters for updating an episode
+	Query               string       `json:"query"`
+	QueryEmbedding      []float32    `json:"query_embedding,omitempty"` // Embedding vector for semantic search
+	SimilarTo           string       `json:"similar_to,omitempty"`      // Episode ID to search around, by its stored vector (or content, without one); excluded from results
+	GroupID             string       `json:"group_id"`
+	MaxResults          int          `json:"max_results"`
+	Before              *time.Time   `json:"before,omitempty"`
+	After               *time.Time   `json:"after,omitempty"`
+	Tags                []string     `json:"tags,omitempty"`
+	Source              string       `json:"source,omitempty"`
+	Filter              *filter.Expr `json:"filter,omitempty"` // Filter expression, ANDed with the other filters
+	IncludeExpired      bool         `json:"include_expired"`
+	AsOf                *time.Time   `json:"as_of,omitempty"`                  // Point in time: only what memory held true then (stored, valid, and unexpired)
+	ValidBefore         *time.Time   `json:"valid_before,omitempty"`           // Valid time (valid_at, else created_at) upper bound
+	ValidAfter          *time.Time   `json:"valid_after,omitempty"`            // Valid time (valid_at, else created_at) lower bound
+	MinSimilarity       float64      `json:"min_similarity,omitempty"`         // Minimum cosine similarity threshold (0.0-1.0)
+	SearchMode          string       `json:"search_mode,omitempty"`            // "vector" (default), "keyword", or "hybrid"
+	SearchAlpha         float64      `json:"search_alpha,omitempty"`           // Hybrid weighting: 0.0 = BM25 only, 1.0 = cosine only (default: 0.7)
+	Fusion              string       `json:"fusion,omitempty"`                 // How hybrid combines its retrievers: "linear" (default, alpha-weighted) or "rrf"
+	TagBoost            float64      `json:"tag_boost,omitempty"`              // 0.0 = hard filter (default), >0 = boost tag matches by this weight
+	Diversity           float64      `json:"diversity,omitempty"`              // MMR redundancy weight: 0.0 = off (default), 1.0 = diversity only
+	RecencyWeight       float64      `json:"recency_weight,omitempty"`         // >0 = add a time-decay term of this weight to relevance
+	RecencyHalfLifeDays float64      `json:"recency_half_life_days,omitempty"` // Days for the decay term to halve (default: 30)
+	RecencyField        string       `json:"recency_field,omitempty"`          // "created_at" (default) or "valid_at", falling back to created_at
+	ImportanceWeight    float64      `json:"importance_weight,omitempty"`      // >0 = add each episode's importance at this weight to relevance
+	Cursor              string       `json:"cursor,omitempty"`                 // next_cursor from the previous page of the same search
+	Explain             bool         `json:"explain,omitempty"`                // Attach an Explanation of its score to each result
+	Strict              bool         `json:"strict,omitempty"`                 // Fail with ErrDegraded rather than fall back to a weaker search mode
+	EmbeddingError      error        `json:"-"`                                // Why QueryEmbedding is missing, when embedding the query failed
+}
+
+// SearchPage is one page of search results. NextCursor is empty on the last
+// page.
+type SearchPage struct {
+	Episodes   []Episode `json:"episodes"`
+	NextCursor string    `json:"next_cursor"`
+	// SearchModeEffective is how the page was actually ranked: "vector",
+	// "keyword", or "hybrid" as asked, or after a fallback "keyword",
+	// "substring" (ILIKE), or "chronological"
+	SearchModeEffective string `json:"search_mode_effective"`
+	// Warnings report what the search couldn't do as asked; never nil
+	Warnings []SearchWarning `json:"warnings"`
+}
+
+// SearchWarning reports a search that fell back to a weaker mode, or a
+// parameter it ignored
+type SearchWarning struct {
+	Code          string `json:"code"`                     // "mode_degraded" or "parameter_ignored"
+	Message       string `json:"message"`                  // The reason, for people
+	RequestedMode string `json:"requested_mode,omitempty"` // mode_degraded: the mode asked for
+	EffectiveMode string `json:"effective_mode,omitempty"` // mode_degraded: the mode used instead
+	Parameter     string `json:"parameter,omitempty"`      // parameter_ignored: the parameter
+}
+
+// UpdateParams defines parameters for updating an episode. Editing Content
+// or Name keeps the prior text as a version; new Content is stored with
+// Embedding, its vector, or without one and queued for embedding.
 type UpdateParams struct {
-	Tags      *[]string  `json:"tags,omitempty"`
-	ExpiredAt *time.Time `json:"expired_at,omitempty"`
-	Metadata  *string    `json:"metadata,omitempty"`
+	Tags           *[]string  `json:"tags,omitempty"`
+	ExpiredAt      *time.Time `json:"expired_at,omitempty"`
+	Metadata       *string    `json:"metadata,omitempty"`
+	Content        *string    `json:"content,omitempty"`
+	Name           *string    `json:"name,omitempty"`
+	Embedding      []float32  `json:"-"` // Vector of the new Content
+	EmbeddingModel string     `json:"-"` // Model that produced Embedding
+	Chunks         []Chunk    `json:"-"` // Chunk vectors to store with Embedding
+}
+
+// IsExpired reports whether the episode's expiration has passed by now. Search
+// hides such episodes unless include_expired is set.
+func (e *Episode) IsExpired(now time.Time) bool {
+	return e.ExpiredAt != nil && !e.ExpiredAt.After(now)
+}
+
+// EpisodeLookup is an episode fetched by ID. Lookups return expired episodes
+// too, flagged rather than hidden.
+type EpisodeLookup struct {
+	*Episode
+	Expired bool `json:"expired"`
+}
+
+// NewEpisodeLookups flags each episode's expiry as of now
+func NewEpisodeLookups(eps []*Episode, now time.Time) []EpisodeLookup {
+	out := make([]EpisodeLookup, len(eps))
+	for i, ep := range eps {
+		out[i] = EpisodeLookup{Episode: ep, Expired: ep.IsExpired(now)}
+	}
+	return out
 }